@@ -0,0 +1,138 @@
+package api
+
+import "github.com/gorilla/mux"
+import "github.com/humpback/gounits/logger"
+import "humpback-center/api/middleware"
+import "humpback-center/api/request"
+import "humpback-center/api/response"
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// anyGroupRoutes, read-only routes without any group, served for an authenticated token of any groups scope.
+var anyGroupRoutes = map[string]bool{
+	"/metrics":         true,
+	"/v1/openapi.json": true,
+}
+
+// authorizeGroups, authenticated token must scope all request groups.
+// groups are resolved from route vars, query and json body groupid, metaid, containerid, server and jobid,
+// a request without any group, eg: cluster routes, need a token scope of all groups, except anyGroupRoutes.
+// return true if request is rejected.
+func authorizeGroups(c *Context) bool {
+
+	identity := middleware.GetIdentity(c.request)
+	if identity == nil || identity.AllGroups() {
+		return false
+	}
+
+	var err error
+	groupids := requestGroups(c)
+	if len(groupids) == 0 {
+		err = fmt.Errorf("token %s scope all groups required", identity.Name)
+	} else {
+		for _, groupid := range groupids {
+			if !identity.InGroup(groupid) {
+				err = fmt.Errorf("token %s scope not contains group %s", identity.Name, groupid)
+				break
+			}
+		}
+	}
+
+	if err == nil {
+		return false
+	}
+
+	logger.WARN("[#api#] %s forbidden, %s", c.ID, err.Error())
+	result := &response.ResponseResult{ResponseID: c.ID}
+	result.SetError(request.RequestForbidden, request.ErrRequestForbidden, err.Error())
+	c.JSON(http.StatusForbidden, result)
+	return true
+}
+
+// requestGroups, resolve groupids of request.
+func requestGroups(c *Context) []string {
+
+	values := map[string][]string{}
+	vars := mux.Vars(c.request)
+	query := c.request.URL.Query()
+	for _, key := range []string{"groupid", "metaid", "containerid", "server", "jobid"} {
+		if value := strings.TrimSpace(vars[key]); value != "" {
+			values[key] = append(values[key], value)
+		}
+		if value := strings.TrimSpace(query.Get(key)); value != "" {
+			values[key] = append(values[key], value)
+		}
+	}
+
+	if c.request.Method != http.MethodGet && c.request.Body != nil {
+		if buf, err := ioutil.ReadAll(c.request.Body); err == nil {
+			c.request.Body.Close()
+			c.request.Body = ioutil.NopCloser(bytes.NewReader(buf))
+			body := map[string]interface{}{}
+			if json.Unmarshal(buf, &body) == nil {
+				bodyGroups(body, values)
+			}
+		}
+	}
+
+	groups := map[string]bool{}
+	for _, groupid := range values["groupid"] {
+		groups[groupid] = true
+	}
+
+	for _, metaid := range values["metaid"] {
+		if metaBase := c.Controller.GetClusterGroupContainersMetaBase(metaid); metaBase != nil {
+			groups[metaBase.GroupID] = true
+		}
+	}
+
+	for _, containerid := range values["containerid"] {
+		if metaBase := c.Controller.GetClusterContainerMetaBase(containerid); metaBase != nil {
+			groups[metaBase.GroupID] = true
+		}
+	}
+
+	for _, server := range values["server"] {
+		for _, groupid := range c.Controller.GetClusterEngineGroups(server) {
+			groups[groupid] = true
+		}
+	}
+
+	for _, jobid := range values["jobid"] {
+		if job, err := c.Controller.GetClusterJob(jobid); err == nil && job.GroupID != "" {
+			groups[job.GroupID] = true
+		}
+	}
+
+	groupids := []string{}
+	for groupid := range groups {
+		groupids = append(groupids, groupid)
+	}
+	return groupids
+}
+
+// bodyGroups, resolve GroupId, MetaId and ContainerId of json body, metadata archive Metas also.
+func bodyGroups(body map[string]interface{}, values map[string][]string) {
+
+	keys := map[string]string{"GroupId": "groupid", "MetaId": "metaid", "ContainerId": "containerid"}
+	for field, key := range keys {
+		if value, ret := body[field].(string); ret && strings.TrimSpace(value) != "" {
+			values[key] = append(values[key], strings.TrimSpace(value))
+		}
+	}
+
+	if metas, ret := body["Metas"].([]interface{}); ret {
+		for _, meta := range metas {
+			if value, ret := meta.(map[string]interface{}); ret {
+				bodyGroups(value, values)
+			}
+		}
+	}
+}
@@ -4,9 +4,16 @@ import "github.com/humpback/gounits/logger"
 import "humpback-center/api/request"
 import "humpback-center/api/response"
 import "humpback-center/cluster"
+import "humpback-center/cluster/types"
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"time"
 )
 
 func getGroupAllContainers(c *Context) error {
@@ -27,8 +34,9 @@ func getGroupAllContainers(c *Context) error {
 		return c.JSON(http.StatusNotFound, result)
 	}
 
-	logger.INFO("[#api#] %s getr group all containers %p.", c.ID, groupContainers)
-	resp := response.NewGroupAllContainersResponse(req.GroupID, groupContainers)
+	pageContainers, total, nextCursor := cluster.ListGroupContainers(*groupContainers, &req.ListOptions)
+	logger.INFO("[#api#] %s getr group all containers %p, total %d.", c.ID, groupContainers, total)
+	resp := response.NewGroupAllContainersResponse(req.GroupID, total, nextCursor, &pageContainers)
 	result.SetError(request.RequestSuccessed, request.ErrRequestSuccessed, "group containers response")
 	result.SetResponse(resp)
 	return c.JSON(http.StatusOK, result)
@@ -52,8 +60,15 @@ func getGroupContainers(c *Context) error {
 		return c.JSON(http.StatusNotFound, result)
 	}
 
-	logger.INFO("[#api#] %s get group containers %p.", c.ID, groupContainer)
-	resp := response.NewGroupContainersResponse(groupContainer)
+	pageContainers, total, nextCursor := cluster.ListGroupContainers(types.GroupContainers{groupContainer}, &req.ListOptions)
+	if len(pageContainers) > 0 {
+		groupContainer = pageContainers[0]
+	} else {
+		groupContainer.Containers = []*types.EngineContainer{}
+	}
+
+	logger.INFO("[#api#] %s get group containers %p, total %d.", c.ID, groupContainer, total)
+	resp := response.NewGroupContainersResponse(total, nextCursor, groupContainer)
 	result.SetError(request.RequestSuccessed, request.ErrRequestSuccessed, "group containers response")
 	result.SetResponse(resp)
 	return c.JSON(http.StatusOK, result)
@@ -84,6 +99,34 @@ func getGroupContainersMetaBase(c *Context) error {
 	return c.JSON(http.StatusOK, result)
 }
 
+func getGroupContainersStats(c *Context) error {
+
+	result := &response.ResponseResult{ResponseID: c.ID}
+	req, err := request.ResolveGroupContainersStatsRequest(c.Request())
+	if err != nil {
+		logger.ERROR("[#api#] %s resolve get group containers stats request faild, %s", c.ID, err.Error())
+		result.SetError(request.RequestInvalid, request.ErrRequestInvalid, err.Error())
+		return c.JSON(http.StatusBadRequest, result)
+	}
+
+	logger.INFO("[#api#] %s resolve get group containers stats request successed. %+v", c.ID, req)
+	stats, err := c.Controller.GetClusterMetaStats(req.MetaID)
+	if err != nil {
+		logger.ERROR("[#api#] %s get group containers stats %s error, %s", c.ID, req.MetaID, err.Error())
+		result.SetError(request.RequestFailure, request.ErrRequestFailure, err.Error())
+		if err == cluster.ErrClusterMetaDataNotFound {
+			return c.JSON(http.StatusNotFound, result)
+		}
+		return c.JSON(http.StatusInternalServerError, result)
+	}
+
+	logger.INFO("[#api#] %s get group containers stats %s, instances %d, failures %d.", c.ID, req.MetaID, len(stats.Instances), len(stats.Failures))
+	resp := response.NewGroupContainersStatsResponse(stats)
+	result.SetError(request.RequestSuccessed, request.ErrRequestSuccessed, "group containers stats response")
+	result.SetResponse(resp)
+	return c.JSON(http.StatusOK, result)
+}
+
 func getGroupEngines(c *Context) error {
 
 	result := &response.ResponseResult{ResponseID: c.ID}
@@ -102,8 +145,9 @@ func getGroupEngines(c *Context) error {
 		return c.JSON(http.StatusNotFound, result)
 	}
 
-	logger.INFO("[#api#] %s get group engines %p.", c.ID, engines)
-	resp := response.NewGroupEnginesResponse(req.GroupID, engines)
+	pageEngines, total, nextCursor := cluster.ListEngines(engines, &req.ListOptions)
+	logger.INFO("[#api#] %s get group engines %p, total %d.", c.ID, engines, total)
+	resp := response.NewGroupEnginesResponse(req.GroupID, total, nextCursor, pageEngines)
 	result.SetError(request.RequestSuccessed, request.ErrRequestSuccessed, "group engines response")
 	result.SetResponse(resp)
 	return c.JSON(http.StatusOK, result)
@@ -134,9 +178,334 @@ func getGroupEngine(c *Context) error {
 	return c.JSON(http.StatusOK, result)
 }
 
+func getClusterGroups(c *Context) error {
+
+	result := &response.ResponseResult{ResponseID: c.ID}
+	groups := c.Controller.GetClusterGroupsSummary()
+	logger.INFO("[#api#] %s get cluster groups %d.", c.ID, len(groups))
+	resp := response.NewClusterGroupsResponse(groups)
+	result.SetError(request.RequestSuccessed, request.ErrRequestSuccessed, "cluster groups response")
+	result.SetResponse(resp)
+	return c.JSON(http.StatusOK, result)
+}
+
+func getClusterEngines(c *Context) error {
+
+	result := &response.ResponseResult{ResponseID: c.ID}
+	engines := c.Controller.GetClusterPoolEngines()
+	logger.INFO("[#api#] %s get cluster engines %d.", c.ID, len(engines))
+	resp := response.NewClusterEnginesResponse(engines)
+	result.SetError(request.RequestSuccessed, request.ErrRequestSuccessed, "cluster engines response")
+	result.SetResponse(resp)
+	return c.JSON(http.StatusOK, result)
+}
+
+func getClusterCapacity(c *Context) error {
+
+	result := &response.ResponseResult{ResponseID: c.ID}
+	capacity := c.Controller.GetClusterCapacity()
+	logger.INFO("[#api#] %s get cluster capacity %p.", c.ID, capacity)
+	resp := response.NewClusterCapacityResponse(capacity)
+	result.SetError(request.RequestSuccessed, request.ErrRequestSuccessed, "cluster capacity response")
+	result.SetResponse(resp)
+	return c.JSON(http.StatusOK, result)
+}
+
+func getClusterRecoveryRuns(c *Context) error {
+
+	result := &response.ResponseResult{ResponseID: c.ID}
+	req, err := request.ResolveClusterRecoveryRunsRequest(c.Request())
+	if err != nil {
+		logger.ERROR("[#api#] %s resolve get cluster recovery runs request faild, %s", c.ID, err.Error())
+		result.SetError(request.RequestInvalid, request.ErrRequestInvalid, err.Error())
+		return c.JSON(http.StatusBadRequest, result)
+	}
+
+	logger.INFO("[#api#] %s resolve get cluster recovery runs request successed. %+v", c.ID, req)
+	runs := c.Controller.GetClusterRecoveryRuns(req.Count)
+	resp := response.NewClusterRecoveryRunsResponse(runs)
+	result.SetError(request.RequestSuccessed, request.ErrRequestSuccessed, "cluster recovery runs response")
+	result.SetResponse(resp)
+	return c.JSON(http.StatusOK, result)
+}
+
+func getClusterQuarantinedMetas(c *Context) error {
+
+	result := &response.ResponseResult{ResponseID: c.ID}
+	metas, err := c.Controller.GetClusterQuarantinedMetas()
+	if err != nil {
+		logger.ERROR("[#api#] %s get cluster quarantined metas error: %s", c.ID, err.Error())
+		result.SetError(request.RequestFailure, request.ErrRequestFailure, err.Error())
+		return c.JSON(http.StatusInternalServerError, result)
+	}
+
+	logger.INFO("[#api#] %s get cluster quarantined metas %d.", c.ID, len(metas))
+	resp := response.NewClusterQuarantinedMetasResponse(metas)
+	result.SetError(request.RequestSuccessed, request.ErrRequestSuccessed, "cluster quarantined metas response")
+	result.SetResponse(resp)
+	return c.JSON(http.StatusOK, result)
+}
+
+func getClusterMetaDataExport(c *Context) error {
+
+	result := &response.ResponseResult{ResponseID: c.ID}
+	req, err := request.ResolveClusterMetaDataExportRequest(c.Request())
+	if err != nil {
+		logger.ERROR("[#api#] %s resolve cluster metadata export request faild, %s", c.ID, err.Error())
+		result.SetError(request.RequestInvalid, request.ErrRequestInvalid, err.Error())
+		return c.JSON(http.StatusBadRequest, result)
+	}
+
+	logger.INFO("[#api#] %s resolve cluster metadata export request successed. %+v", c.ID, req)
+	//archive is a raw json download, not wrapped in ResponseResult, it is the body of metadata import.
+	filename := "metadata.json"
+	if req.GroupID != "" {
+		filename = "metadata-" + req.GroupID + ".json"
+	}
+	archive := c.Controller.ExportClusterMetaData(req.GroupID)
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	return c.JSON(http.StatusOK, archive)
+}
+
+func postClusterMetaDataImport(c *Context) error {
+
+	result := &response.ResponseResult{ResponseID: c.ID}
+	req, err := request.ResolveClusterMetaDataImportRequest(c.Request())
+	if err != nil {
+		logger.ERROR("[#api#] %s resolve cluster metadata import request faild, %s", c.ID, err.Error())
+		result.SetError(request.RequestInvalid, request.ErrRequestInvalid, err.Error())
+		return c.JSON(http.StatusBadRequest, result)
+	}
+
+	logger.INFO("[#api#] %s resolve cluster metadata import request successed. mode:%s metas:%d", c.ID, req.Mode, len(req.Archive.Metas))
+	record := newAuditRecord(c, cluster.AuditImport, req.Archive.GroupID, "", map[string]interface{}{"Mode": req.Mode, "Metas": len(req.Archive.Metas)})
+	importResult, err := c.Controller.ImportClusterMetaData(req.Archive, req.Mode)
+	writeAudit(c, record, importResult, err)
+	if err != nil {
+		logger.ERROR("[#api#] %s cluster metadata import error: %s", c.ID, err.Error())
+		result.SetError(request.RequestFailure, request.ErrRequestFailure, err.Error())
+		if importError, ret := err.(*cluster.MetaDataImportError); ret {
+			switch importError.Err {
+			case cluster.ErrClusterMetaDataArchiveInvalid:
+				return c.JSON(http.StatusBadRequest, result)
+			case cluster.ErrClusterGroupNotFound:
+				return c.JSON(http.StatusNotFound, result)
+			case cluster.ErrClusterMetaDataImportConflict:
+				return c.JSON(http.StatusConflict, result)
+			}
+		} else if err == cluster.ErrClusterMetaDataArchiveInvalid || err == cluster.ErrClusterMetaDataImportModeInvalid {
+			return c.JSON(http.StatusBadRequest, result)
+		}
+		return c.JSON(http.StatusInternalServerError, result)
+	}
+
+	resp := response.NewClusterMetaDataImportResponse(importResult)
+	result.SetError(request.RequestSuccessed, request.ErrRequestSuccessed, "cluster metadata import response")
+	result.SetResponse(resp)
+	return c.JSON(http.StatusOK, result)
+}
+
+func getClusterAudit(c *Context) error {
+
+	result := &response.ResponseResult{ResponseID: c.ID}
+	req, err := request.ResolveClusterAuditRequest(c.Request())
+	if err != nil {
+		logger.ERROR("[#api#] %s resolve get cluster audit request faild, %s", c.ID, err.Error())
+		result.SetError(request.RequestInvalid, request.ErrRequestInvalid, err.Error())
+		return c.JSON(http.StatusBadRequest, result)
+	}
+
+	logger.INFO("[#api#] %s resolve get cluster audit request successed. %+v", c.ID, req)
+	records, err := c.Controller.QueryClusterAudit(&req.AuditFilter)
+	if err != nil {
+		logger.ERROR("[#api#] %s get cluster audit error: %s", c.ID, err.Error())
+		result.SetError(request.RequestFailure, request.ErrRequestFailure, err.Error())
+		return c.JSON(http.StatusInternalServerError, result)
+	}
+
+	resp := response.NewClusterAuditResponse(records)
+	result.SetError(request.RequestSuccessed, request.ErrRequestSuccessed, "cluster audit response")
+	result.SetResponse(resp)
+	return c.JSON(http.StatusOK, result)
+}
+
+// getClusterEvents, server-sent events stream of cluster changes.
+// stream is closed when client disconnected, subscriber too slow or cluster draining.
+func getClusterEvents(c *Context) error {
+
+	result := &response.ResponseResult{ResponseID: c.ID}
+	req, err := request.ResolveClusterEventsRequest(c.Request())
+	if err != nil {
+		logger.ERROR("[#api#] %s resolve get cluster events request faild, %s", c.ID, err.Error())
+		result.SetError(request.RequestInvalid, request.ErrRequestInvalid, err.Error())
+		return c.JSON(http.StatusBadRequest, result)
+	}
+
+	flusher, ret := c.Response().Writer().(http.Flusher)
+	if !ret {
+		result.SetError(request.RequestFailure, request.ErrRequestFailure, "streaming unsupported")
+		return c.JSON(http.StatusInternalServerError, result)
+	}
+
+	logger.INFO("[#api#] %s resolve get cluster events request successed. %+v", c.ID, req)
+	subscriber, events := c.Controller.SubscribeClusterEvents(req.LastEventID, &req.EventFilter)
+	defer c.Controller.UnsubscribeClusterEvents(subscriber)
+	c.Response().Header().Set("Content-Type", "text/event-stream")
+	c.Response().Header().Set("Cache-Control", "no-cache")
+	c.Response().Header().Set("Connection", "keep-alive")
+	c.Response().Header().Set("X-Accel-Buffering", "no")
+	c.WriteHeader(http.StatusOK)
+	for _, event := range events {
+		if err := writeClusterEvent(c, event); err != nil {
+			return err
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(15 * time.Second)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case event, ret := <-subscriber.C:
+			if !ret {
+				logger.INFO("[#api#] %s cluster events stream closed.", c.ID)
+				return nil
+			}
+			if err := writeClusterEvent(c, event); err != nil {
+				return err
+			}
+		case <-heartbeat.C:
+			if _, err := c.Response().Write([]byte(": heartbeat\n\n")); err != nil {
+				return err
+			}
+		case <-c.Request().Context().Done():
+			return nil
+		}
+		flusher.Flush()
+	}
+}
+
+func writeClusterEvent(c *Context, event *cluster.ClusterEvent) error {
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.Response(), "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+func getGroupContainerLogs(c *Context) error {
+
+	result := &response.ResponseResult{ResponseID: c.ID}
+	req, err := request.ResolveGroupContainerLogsRequest(c.Request())
+	if err != nil {
+		logger.ERROR("[#api#] %s resolve get group container logs request faild, %s", c.ID, err.Error())
+		result.SetError(request.RequestInvalid, request.ErrRequestInvalid, err.Error())
+		return c.JSON(http.StatusBadRequest, result)
+	}
+
+	logger.INFO("[#api#] %s resolve get group container logs request successed. %+v", c.ID, req)
+	reader, err := c.Controller.GetClusterContainerLogs(c.Request().Context(), req.ContainerID, &req.ContainerLogsOptions)
+	if err != nil {
+		return writeLogsError(c, result, err)
+	}
+	return writeLogs(c, reader)
+}
+
+func getGroupContainersLogs(c *Context) error {
+
+	result := &response.ResponseResult{ResponseID: c.ID}
+	req, err := request.ResolveGroupContainersLogsRequest(c.Request())
+	if err != nil {
+		logger.ERROR("[#api#] %s resolve get group containers logs request faild, %s", c.ID, err.Error())
+		result.SetError(request.RequestInvalid, request.ErrRequestInvalid, err.Error())
+		return c.JSON(http.StatusBadRequest, result)
+	}
+
+	logger.INFO("[#api#] %s resolve get group containers logs request successed. %+v", c.ID, req)
+	reader, err := c.Controller.GetClusterMetaLogs(c.Request().Context(), req.MetaID, &req.ContainerLogsOptions)
+	if err != nil {
+		return writeLogsError(c, result, err)
+	}
+	return writeLogs(c, reader)
+}
+
+func writeLogsError(c *Context, result *response.ResponseResult, err error) error {
+
+	logger.ERROR("[#api#] %s get logs error, %s", c.ID, err.Error())
+	result.SetError(request.RequestFailure, request.ErrRequestFailure, err.Error())
+	switch err {
+	case cluster.ErrClusterContainerNotFound, cluster.ErrClusterMetaDataNotFound:
+		return c.JSON(http.StatusNotFound, result)
+	case cluster.ErrClusterNoEngineAvailable:
+		return c.JSON(http.StatusServiceUnavailable, result)
+	}
+	return c.JSON(http.StatusBadGateway, result)
+}
+
+// writeLogs, copy logs stream to response, flush every read for follow stream.
+func writeLogs(c *Context, reader io.ReadCloser) error {
+
+	defer reader.Close()
+	flusher, _ := c.Response().Writer().(http.Flusher)
+	c.Response().Header().Set("Content-Type", "text/plain; charset=utf-8")
+	c.Response().Header().Set("X-Accel-Buffering", "no")
+	c.WriteHeader(http.StatusOK)
+	buf := make([]byte, 32<<10)
+	for {
+		n, err := reader.Read(buf)
+		if n > 0 {
+			if _, werr := c.Response().Write(buf[:n]); werr != nil {
+				return werr
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			logger.WARN("[#api#] %s logs stream closed, %s", c.ID, err.Error())
+			return nil
+		}
+	}
+}
+
+func postClusterRecovery(c *Context) error {
+
+	result := &response.ResponseResult{ResponseID: c.ID}
+	record := newAuditRecord(c, cluster.AuditRecovery, "", "", nil)
+	run, err := c.Controller.RecoveryClusterMetas()
+	writeAudit(c, record, run, err)
+	if err != nil {
+		logger.ERROR("[#api#] %s cluster recovery error: %s", c.ID, err.Error())
+		result.SetError(request.RequestFailure, request.ErrRequestFailure, err.Error())
+		if err == cluster.ErrClusterRecoveryRunning {
+			return c.JSON(http.StatusConflict, result)
+		}
+		return c.JSON(http.StatusInternalServerError, result)
+	}
+
+	logger.INFO("[#api#] %s cluster recovery run %s started.", c.ID, run.ID)
+	resp := response.NewClusterRecoveryResponse(run)
+	result.SetError(request.RequestSuccessed, request.ErrRequestSuccessed, "cluster recovery response")
+	result.SetResponse(resp)
+	return c.JSON(http.StatusAccepted, result)
+}
+
 func postGroupEvent(c *Context) error {
 
 	result := &response.ResponseResult{ResponseID: c.ID}
+	body, err := ioutil.ReadAll(c.Request().Body)
+	if err != nil {
+		logger.ERROR("[#api#] %s resolve group event request faild, %s", c.ID, err.Error())
+		result.SetError(request.RequestInvalid, request.ErrRequestInvalid, err.Error())
+		return c.JSON(http.StatusBadRequest, result)
+	}
+
+	c.Request().Body = ioutil.NopCloser(bytes.NewReader(body))
 	req, err := request.ResolveGroupEventRequest(c.Request())
 	if err != nil {
 		logger.ERROR("[#api#] %s resolve group event request faild, %s", c.ID, err.Error())
@@ -145,8 +514,25 @@ func postGroupEvent(c *Context) error {
 	}
 
 	logger.INFO("[#api#] %s resolve group event request successed. %+v", c.ID, req)
-	c.Controller.SetClusterGroupEvent(req.GroupID, req.Event)
-	resp := response.NewGroupEventResponse("accepted.")
+	record := newAuditRecord(c, cluster.AuditGroupEvent, req.GroupID, "", req)
+	run := func(job *cluster.Job) (interface{}, error) {
+		err := c.Controller.SetClusterGroupEvent(req.GroupID, req.Event, job)
+		writeAudit(c, record, nil, err)
+		return response.NewGroupEventResponse("accepted."), err
+	}
+
+	go notifyLeaderGroupEvent(c, req, c.Request().URL.RequestURI(), leaderForwardHeader(c), body)
+	if req.Async {
+		return submitClusterJob(c, record, run)
+	}
+
+	resp, err := run(nil)
+	if err != nil {
+		logger.ERROR("[#api#] %s group %s event %s error: %s", c.ID, req.GroupID, req.Event, err.Error())
+		result.SetError(request.RequestFailure, request.ErrRequestFailure, err.Error())
+		return c.JSON(http.StatusInternalServerError, result)
+	}
+
 	result.SetError(request.RequestSuccessed, request.ErrRequestSuccessed, "group event response")
 	result.SetResponse(resp)
 	return c.JSON(http.StatusAccepted, result)
@@ -163,7 +549,22 @@ func postGroupCreateContainers(c *Context) error {
 	}
 
 	logger.INFO("[#api#] %s resolve create containers request successed. %+v", c.ID, req)
-	metaid, createdContainers, err := c.Controller.CreateClusterContainers(req.GroupID, req.Instances, req.WebHooks, req.Config)
+	record := newAuditRecord(c, cluster.AuditCreate, req.GroupID, "", req)
+	run := func(job *cluster.Job) (interface{}, error) {
+		metaid, createdContainers, err := c.Controller.CreateClusterContainers(req.GroupID, req.Instances, req.WebHooks, req.Config, job)
+		record.MetaID = metaid
+		writeAudit(c, record, createdContainers, err)
+		if createdContainers == nil {
+			return nil, err
+		}
+		return response.NewGroupCreateContainersResponse(req.GroupID, metaid, req.Instances, createdContainers), err
+	}
+
+	if req.Async {
+		return submitClusterJob(c, record, run)
+	}
+
+	resp, err := run(nil)
 	if err != nil {
 		logger.ERROR("[#api#] %s create containers to group %s error: %s", c.ID, req.GroupID, err.Error())
 		result.SetError(request.RequestFailure, request.ErrRequestFailure, err.Error())
@@ -175,7 +576,6 @@ func postGroupCreateContainers(c *Context) error {
 		return c.JSON(http.StatusInternalServerError, result)
 	}
 
-	resp := response.NewGroupCreateContainersResponse(req.GroupID, metaid, req.Instances, createdContainers)
 	result.SetError(request.RequestSuccessed, request.ErrRequestSuccessed, "create containers response")
 	result.SetResponse(resp)
 	return c.JSON(http.StatusOK, result)
@@ -192,7 +592,9 @@ func putGroupUpdateContainers(c *Context) error {
 	}
 
 	logger.INFO("[#api#] %s resolve update containers request successed. %+v", c.ID, req)
+	record := newAuditRecord(c, cluster.AuditUpdate, "", req.MetaID, req)
 	updatedContainers, err := c.Controller.UpdateClusterContainers(req.MetaID, req.Instances, req.WebHooks)
+	writeAudit(c, record, updatedContainers, err)
 	if err != nil {
 		logger.ERROR("[#api#] %s update containers to meta %s error: %s", c.ID, req.MetaID, err.Error())
 		result.SetError(request.RequestFailure, request.ErrRequestFailure, err.Error())
@@ -219,7 +621,9 @@ func putGroupOperateContainers(c *Context) error {
 	}
 
 	logger.INFO("[#api#] %s resolve operate containers request successed. %+v", c.ID, req)
+	record := newAuditRecord(c, cluster.AuditOperate, "", req.MetaID, req)
 	operatedContainers, err := c.Controller.OperateContainers(req.MetaID, req.Action)
+	writeAudit(c, record, operatedContainers, err)
 	if err != nil {
 		logger.ERROR("[#api#] %s operate %s containers to meta %s error: %s", c.ID, req.Action, req.MetaID, err.Error())
 		result.SetError(request.RequestFailure, request.ErrRequestFailure, err.Error())
@@ -246,7 +650,10 @@ func putGroupOperateContainer(c *Context) error {
 	}
 
 	logger.INFO("[#api#] %s resolve operate container request successed. %+v", c.ID, req)
+	record := newAuditRecord(c, cluster.AuditOperate, "", "", req)
 	metaID, operatedContainers, err := c.Controller.OperateContainer(req.ContainerID, req.Action)
+	record.MetaID = metaID
+	writeAudit(c, record, operatedContainers, err)
 	if err != nil {
 		logger.ERROR("[#api#] %s operate %s container to %s error: %s", c.ID, req.Action, req.ContainerID, err.Error())
 		result.SetError(request.RequestFailure, request.ErrRequestFailure, err.Error())
@@ -273,22 +680,128 @@ func putGroupUpgradeContainers(c *Context) error {
 	}
 
 	logger.INFO("[#api#] %s resolve upgrade containers request successed. %+v", c.ID, req)
-	upgradeContainers, err := c.Controller.UpgradeContainers(req.MetaID, req.ImageTag)
+	record := newAuditRecord(c, cluster.AuditUpgrade, "", req.MetaID, req)
+	run := func(job *cluster.Job) (interface{}, error) {
+		upgradeContainers, pulls, err := c.Controller.UpgradeContainers(req.MetaID, req.ImageTag, req.PrePull, job)
+		writeAudit(c, record, upgradeContainers, err)
+		if err != nil {
+			if pulls != nil {
+				return response.NewGroupUpgradeContainersResponse(req.MetaID, "upgrade containers", nil, pulls), err
+			}
+			return nil, err
+		}
+		return response.NewGroupUpgradeContainersResponse(req.MetaID, "upgrade containers", upgradeContainers, pulls), nil
+	}
+
+	if req.Async {
+		return submitClusterJob(c, record, run)
+	}
+
+	resp, err := run(nil)
 	if err != nil {
 		logger.ERROR("[#api#] %s upgrade containers to meta %s error: %s", c.ID, req.MetaID, err.Error())
 		result.SetError(request.RequestFailure, request.ErrRequestFailure, err.Error())
+		result.SetResponse(resp)
 		if err == cluster.ErrClusterMetaDataNotFound || err == cluster.ErrClusterGroupNotFound {
 			return c.JSON(http.StatusNotFound, result)
 		}
+		if err == cluster.ErrClusterImageNotFound {
+			return c.JSON(http.StatusBadRequest, result)
+		}
 		return c.JSON(http.StatusInternalServerError, result)
 	}
 
-	resp := response.NewGroupUpgradeContainersResponse(req.MetaID, "upgrade containers", upgradeContainers)
 	result.SetError(request.RequestSuccessed, request.ErrRequestSuccessed, "upgrade containers response")
 	result.SetResponse(resp)
 	return c.JSON(http.StatusOK, result)
 }
 
+func putGroupApplyContainers(c *Context) error {
+
+	result := response.ResponseResult{ResponseID: c.ID}
+	req, err := request.ResolveGroupApplyContainersRequest(c.Request())
+	if err != nil {
+		logger.ERROR("[#api#] %s resolve apply containers request faild, %s", c.ID, err.Error())
+		result.SetError(request.RequestInvalid, request.ErrRequestInvalid, err.Error())
+		return c.JSON(http.StatusBadRequest, result)
+	}
+
+	logger.INFO("[#api#] %s resolve apply containers request successed. %+v", c.ID, req)
+	var record *cluster.AuditRecord
+	if !req.DryRun {
+		record = newAuditRecord(c, cluster.AuditApply, req.GroupID, "", req)
+	}
+
+	run := func(job *cluster.Job) (interface{}, error) {
+		plan, err := c.Controller.ApplyContainers(req.GroupID, req.Name, &req.ApplySpec, req.DryRun, job)
+		var containers *types.GroupContainer
+		if plan != nil && plan.Executed && plan.MetaID != "" {
+			containers = c.Controller.GetClusterGroupContainers(plan.MetaID)
+		}
+		if record != nil {
+			if plan != nil {
+				record.MetaID = plan.MetaID
+			}
+			writeAudit(c, record, containers, err)
+		}
+		if plan == nil {
+			return nil, err
+		}
+		return response.NewGroupApplyContainersResponse(plan, containers), err
+	}
+
+	if req.Async && !req.DryRun {
+		return submitClusterJob(c, record, run)
+	}
+
+	resp, err := run(nil)
+	if err != nil {
+		logger.ERROR("[#api#] %s apply containers %s to group %s error: %s", c.ID, req.Name, req.GroupID, err.Error())
+		result.SetError(request.RequestFailure, request.ErrRequestFailure, err.Error())
+		result.SetResponse(resp)
+		if err == cluster.ErrClusterGroupNotFound {
+			return c.JSON(http.StatusNotFound, result)
+		}
+		if err == cluster.ErrClusterContainersInstancesInvalid {
+			return c.JSON(http.StatusBadRequest, result)
+		}
+		return c.JSON(http.StatusInternalServerError, result)
+	}
+
+	result.SetError(request.RequestSuccessed, request.ErrRequestSuccessed, "apply containers response")
+	result.SetResponse(resp)
+	return c.JSON(http.StatusOK, result)
+}
+
+func putGroupContainersMaintenance(c *Context) error {
+
+	result := response.ResponseResult{ResponseID: c.ID}
+	req, err := request.ResolveGroupContainersMaintenanceRequest(c.Request())
+	if err != nil {
+		logger.ERROR("[#api#] %s resolve maintenance containers request faild, %s", c.ID, err.Error())
+		result.SetError(request.RequestInvalid, request.ErrRequestInvalid, err.Error())
+		return c.JSON(http.StatusBadRequest, result)
+	}
+
+	logger.INFO("[#api#] %s resolve maintenance containers request successed. %+v", c.ID, req)
+	record := newAuditRecord(c, cluster.AuditMaintenance, "", req.MetaID, req)
+	metaBase, err := c.Controller.SetContainersMaintenance(req.MetaID, req.Paused, req.Expired)
+	writeAudit(c, record, nil, err)
+	if err != nil {
+		logger.ERROR("[#api#] %s maintenance containers to meta %s error: %s", c.ID, req.MetaID, err.Error())
+		result.SetError(request.RequestFailure, request.ErrRequestFailure, err.Error())
+		if err == cluster.ErrClusterMetaDataNotFound {
+			return c.JSON(http.StatusNotFound, result)
+		}
+		return c.JSON(http.StatusInternalServerError, result)
+	}
+
+	resp := response.NewGroupContainersMetaBaseResponse(metaBase)
+	result.SetError(request.RequestSuccessed, request.ErrRequestSuccessed, "maintenance containers response")
+	result.SetResponse(resp)
+	return c.JSON(http.StatusOK, result)
+}
+
 func deleteGroupRemoveContainers(c *Context) error {
 
 	result := response.ResponseResult{ResponseID: c.ID}
@@ -300,7 +813,21 @@ func deleteGroupRemoveContainers(c *Context) error {
 	}
 
 	logger.INFO("[#api#] %s resolve remove containers request successed. %+v", c.ID, req)
-	removedContainers, err := c.Controller.RemoveContainers(req.MetaID)
+	record := newAuditRecord(c, cluster.AuditRemove, "", req.MetaID, req)
+	run := func(job *cluster.Job) (interface{}, error) {
+		removedContainers, err := c.Controller.RemoveContainers(req.MetaID, job)
+		writeAudit(c, record, removedContainers, err)
+		if removedContainers == nil {
+			return nil, err
+		}
+		return response.NewGroupRemoveContainersResponse(req.MetaID, removedContainers), err
+	}
+
+	if req.Async {
+		return submitClusterJob(c, record, run)
+	}
+
+	resp, err := run(nil)
 	if err != nil {
 		logger.ERROR("[#api#] %s remove containers to meta %s error: %s", c.ID, req.MetaID, err.Error())
 		result.SetError(request.RequestFailure, request.ErrRequestFailure, err.Error())
@@ -310,7 +837,6 @@ func deleteGroupRemoveContainers(c *Context) error {
 		return c.JSON(http.StatusInternalServerError, result)
 	}
 
-	resp := response.NewGroupRemoveContainersResponse(req.MetaID, removedContainers)
 	result.SetError(request.RequestSuccessed, request.ErrRequestSuccessed, "remove containers response")
 	result.SetResponse(resp)
 	return c.JSON(http.StatusOK, result)
@@ -327,7 +853,10 @@ func deleteGroupRemoveContainer(c *Context) error {
 	}
 
 	logger.INFO("[#api#] %s resolve remove container request successed. %+v", c.ID, req)
+	record := newAuditRecord(c, cluster.AuditRemove, "", "", req)
 	metaID, removedContainers, err := c.Controller.RemoveContainer(req.ContainerID)
+	record.MetaID = metaID
+	writeAudit(c, record, removedContainers, err)
 	if err != nil {
 		logger.ERROR("[#api#] %s remove container to %s error: %s", c.ID, req.ContainerID, err.Error())
 		result.SetError(request.RequestFailure, request.ErrRequestFailure, err.Error())
@@ -1,11 +1,13 @@
 package api
 
 import "github.com/humpback/gounits/rand"
+import "humpback-center/api/middleware"
 import "humpback-center/ctrl"
 
 import (
 	"context"
 	"encoding/json"
+	"net"
 	"net/http"
 	"net/url"
 )
@@ -93,6 +95,24 @@ func NewContext(w http.ResponseWriter, r *http.Request, controller *ctrl.Control
 	}
 }
 
+// Caller return the request caller identity, authenticated token name or client certificate name and remote host.
+func (c *Context) Caller() string {
+
+	host := c.request.RemoteAddr
+	if value, _, err := net.SplitHostPort(c.request.RemoteAddr); err == nil {
+		host = value
+	}
+
+	if identity := middleware.GetIdentity(c.request); identity != nil {
+		return identity.Name + "@" + host
+	}
+
+	if c.request.TLS != nil && len(c.request.TLS.PeerCertificates) > 0 {
+		return c.request.TLS.PeerCertificates[0].Subject.CommonName + "@" + host
+	}
+	return host
+}
+
 func (c *Context) Request() *http.Request {
 
 	return c.request
@@ -0,0 +1,139 @@
+package api
+
+import "github.com/humpback/gounits/logger"
+import "humpback-center/api/middleware"
+import "humpback-center/api/request"
+import "humpback-center/api/response"
+import "humpback-center/cluster"
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+const (
+	// request idempotency key header
+	idempotencyKeyHeader = "Idempotency-Key"
+	// response header of a replayed stored response
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+	// idempotency key max length
+	idempotencyKeyMaxLength = 255
+)
+
+// idempotencyHeaders, response headers stored with idempotency response.
+var idempotencyHeaders = []string{"Content-Type", "Location"}
+
+// idempotencyRecorder, capture handler response status and body.
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (recorder *idempotencyRecorder) WriteHeader(code int) {
+
+	if recorder.status == 0 {
+		recorder.status = code
+	}
+	recorder.ResponseWriter.WriteHeader(code)
+}
+
+func (recorder *idempotencyRecorder) Write(b []byte) (int, error) {
+
+	if recorder.status == 0 {
+		recorder.status = http.StatusOK
+	}
+	recorder.body.Write(b)
+	return recorder.ResponseWriter.Write(b)
+}
+
+func (recorder *idempotencyRecorder) Flush() {
+
+	if flusher, ret := recorder.ResponseWriter.(http.Flusher); ret {
+		flusher.Flush()
+	}
+}
+
+// idempotencyFingerprint, hash of request method, uri and body.
+func idempotencyFingerprint(c *Context) string {
+
+	hash := sha256.New()
+	hash.Write([]byte(c.request.Method + " " + c.request.URL.Path + "?" + c.request.URL.Query().Encode() + "\n"))
+	if c.request.Body != nil {
+		buf, err := ioutil.ReadAll(c.request.Body)
+		c.request.Body.Close()
+		c.request.Body = ioutil.NopCloser(bytes.NewReader(buf))
+		if err == nil {
+			hash.Write(buf)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// idempotent, wrap a mutating route handler with Idempotency-Key support.
+// request without key is handled as usual, a key of authenticated request is scoped by token name.
+// stored response of key is replayed instead of handle again, server error responses are not stored.
+func idempotent(routehandler handler) handler {
+
+	return func(c *Context) error {
+
+		key := strings.TrimSpace(c.request.Header.Get(idempotencyKeyHeader))
+		if key == "" {
+			return routehandler(c)
+		}
+
+		result := &response.ResponseResult{ResponseID: c.ID}
+		if len(key) > idempotencyKeyMaxLength {
+			result.SetError(request.RequestInvalid, request.ErrRequestInvalid, "idempotency key length invalid")
+			return c.JSON(http.StatusBadRequest, result)
+		}
+
+		if identity := middleware.GetIdentity(c.request); identity != nil {
+			key = identity.Name + "/" + key
+		}
+
+		record, err := c.Controller.BeginClusterIdempotent(key, idempotencyFingerprint(c))
+		if err != nil {
+			logger.WARN("[#api#] %s idempotency key %s rejected, %s", c.ID, key, err.Error())
+			result.SetError(request.RequestFailure, request.ErrRequestFailure, err.Error())
+			if err == cluster.ErrClusterIdempotencyKeyConflict {
+				return c.JSON(http.StatusUnprocessableEntity, result)
+			}
+			return c.JSON(http.StatusConflict, result)
+		}
+
+		if record != nil {
+			logger.INFO("[#api#] %s idempotency key %s replayed.", c.ID, key)
+			for name, values := range record.Header {
+				for _, value := range values {
+					c.Response().Header().Add(name, value)
+				}
+			}
+			c.Response().Header().Set(idempotencyReplayedHeader, "true")
+			c.WriteHeader(record.StatusCode)
+			_, err := c.Response().Write(record.Body)
+			return err
+		}
+
+		recorder := &idempotencyRecorder{ResponseWriter: c.Response().Writer()}
+		c.Response().SetWriter(recorder)
+		defer func() {
+			if recorder.status == 0 || recorder.status >= http.StatusInternalServerError {
+				c.Controller.AbortClusterIdempotent(key)
+				return
+			}
+			header := map[string][]string{}
+			for _, name := range idempotencyHeaders {
+				if values := recorder.Header()[name]; len(values) > 0 {
+					header[name] = values
+				}
+			}
+			c.Controller.CompleteClusterIdempotent(key, recorder.status, header, recorder.body.Bytes())
+		}()
+		return routehandler(c)
+	}
+}
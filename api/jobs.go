@@ -0,0 +1,75 @@
+package api
+
+import "github.com/humpback/gounits/logger"
+import "humpback-center/api/request"
+import "humpback-center/api/response"
+import "humpback-center/cluster"
+
+import (
+	"net/http"
+)
+
+// submitClusterJob, run the operation as a background job, response accepted with job info.
+// job result is the same response of synchronous request, audit is written when job finished.
+func submitClusterJob(c *Context, record *cluster.AuditRecord, fn cluster.JobFunc) error {
+
+	result := &response.ResponseResult{ResponseID: c.ID}
+	job := cluster.NewJob(record.Operation, c.ID, record.Caller, record.GroupID, record.MetaID)
+	info := c.Controller.SubmitClusterJob(job, fn)
+	logger.INFO("[#api#] %s submit %s job %s.", c.ID, info.Operation, info.ID)
+	resp := response.NewClusterJobResponse(info)
+	result.SetError(request.RequestSuccessed, request.ErrRequestSuccessed, "cluster job accepted")
+	result.SetResponse(resp)
+	c.Response().Header().Set("Location", "/v1/jobs/"+info.ID)
+	return c.JSON(http.StatusAccepted, result)
+}
+
+func getClusterJob(c *Context) error {
+
+	result := &response.ResponseResult{ResponseID: c.ID}
+	req, err := request.ResolveClusterJobRequest(c.Request())
+	if err != nil {
+		logger.ERROR("[#api#] %s resolve get cluster job request faild, %s", c.ID, err.Error())
+		result.SetError(request.RequestInvalid, request.ErrRequestInvalid, err.Error())
+		return c.JSON(http.StatusBadRequest, result)
+	}
+
+	job, err := c.Controller.GetClusterJob(req.JobID)
+	if err != nil {
+		logger.ERROR("[#api#] %s get cluster job %s error: %s", c.ID, req.JobID, err.Error())
+		result.SetError(request.RequestFailure, request.ErrRequestFailure, err.Error())
+		return c.JSON(http.StatusNotFound, result)
+	}
+
+	resp := response.NewClusterJobResponse(job)
+	result.SetError(request.RequestSuccessed, request.ErrRequestSuccessed, "cluster job response")
+	result.SetResponse(resp)
+	return c.JSON(http.StatusOK, result)
+}
+
+func deleteClusterJob(c *Context) error {
+
+	result := &response.ResponseResult{ResponseID: c.ID}
+	req, err := request.ResolveClusterJobRequest(c.Request())
+	if err != nil {
+		logger.ERROR("[#api#] %s resolve cancel cluster job request faild, %s", c.ID, err.Error())
+		result.SetError(request.RequestInvalid, request.ErrRequestInvalid, err.Error())
+		return c.JSON(http.StatusBadRequest, result)
+	}
+
+	logger.INFO("[#api#] %s resolve cancel cluster job request successed. %+v", c.ID, req)
+	job, err := c.Controller.CancelClusterJob(req.JobID)
+	if err != nil {
+		logger.ERROR("[#api#] %s cancel cluster job %s error: %s", c.ID, req.JobID, err.Error())
+		result.SetError(request.RequestFailure, request.ErrRequestFailure, err.Error())
+		if err == cluster.ErrClusterJobNotFound {
+			return c.JSON(http.StatusNotFound, result)
+		}
+		return c.JSON(http.StatusConflict, result)
+	}
+
+	resp := response.NewClusterJobResponse(job)
+	result.SetError(request.RequestSuccessed, request.ErrRequestSuccessed, "cluster job canceling")
+	result.SetResponse(resp)
+	return c.JSON(http.StatusAccepted, result)
+}
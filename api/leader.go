@@ -0,0 +1,143 @@
+package api
+
+import "github.com/gorilla/mux"
+import "github.com/humpback/gounits/logger"
+import "humpback-center/api/middleware"
+import "humpback-center/api/request"
+import "humpback-center/api/response"
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"time"
+)
+
+const (
+	// forward group event to leader request timeout
+	leaderRequestTimeout = 15 * time.Second
+)
+
+// leaderTransport, transport of follower requests to leader, set to a client certificate transport when api tls is enabled.
+var leaderTransport http.RoundTripper = http.DefaultTransport
+
+// SetLeaderTransport is exported
+func SetLeaderTransport(transport http.RoundTripper) {
+
+	leaderTransport = transport
+}
+
+// leaderLocalRoutes, routes handled by every center, not forwarded to leader.
+// group event on a follower only updates groups of cluster, group containers and metas are removed by the leader.
+var leaderLocalRoutes = map[string]bool{
+	"/v1/groups/event": true,
+}
+
+// leaderRoutes, routes only the leader holds state, also forwarded for GET, except follower local jobs.
+var leaderRoutes = map[string]bool{
+	"/v1/jobs/{jobid}": true,
+}
+
+// isLocalJob, job of a follower local route, eg: an async group event, is served by the follower.
+func isLocalJob(c *Context) bool {
+
+	jobid, ret := mux.Vars(c.request)["jobid"]
+	if !ret {
+		return false
+	}
+	_, err := c.Controller.GetClusterJob(jobid)
+	return err == nil
+}
+
+// forwardLeader, center is a follower, redirect or proxy the mutating request to leader.
+// return true if request is handled.
+func forwardLeader(c *Context) bool {
+
+	if c.Controller.IsClusterLeader() {
+		return false
+	}
+
+	result := &response.ResponseResult{ResponseID: c.ID}
+	leader, err := c.Controller.GetClusterLeader()
+	if err != nil {
+		logger.ERROR("[#api#] %s forward to leader error, %s", c.ID, err.Error())
+		result.SetError(request.RequestFailure, request.ErrRequestFailure, err.Error())
+		c.JSON(http.StatusServiceUnavailable, result)
+		return true
+	}
+
+	target, err := url.Parse(leader)
+	if err != nil || target.Host == "" {
+		logger.ERROR("[#api#] %s forward to leader %s invalid.", c.ID, leader)
+		result.SetError(request.RequestFailure, request.ErrRequestFailure, "cluster leader address invalid, "+leader)
+		c.JSON(http.StatusServiceUnavailable, result)
+		return true
+	}
+
+	if c.Controller.Cluster.LeaderForward == "proxy" {
+		logger.INFO("[#api#] %s proxy %s %s to leader %s", c.ID, c.request.Method, c.request.RequestURI, leader)
+		proxy := httputil.NewSingleHostReverseProxy(target)
+		proxy.Transport = leaderTransport
+		proxy.ServeHTTP(c.response.Writer(), c.request)
+		return true
+	}
+
+	logger.INFO("[#api#] %s redirect %s %s to leader %s", c.ID, c.request.Method, c.request.RequestURI, leader)
+	http.Redirect(c.response.Writer(), c.request, leader+c.request.RequestURI, http.StatusTemporaryRedirect)
+	return true
+}
+
+// leaderForwardHeader, credentials of follower requests to leader, forward token is configured use the token,
+// else the caller authorization, hmac signature is valid with the original request uri and body.
+func leaderForwardHeader(c *Context) http.Header {
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json; charset=utf-8")
+	if forwardToken := c.Controller.Configuration.API.Auth.ForwardToken; forwardToken != "" {
+		header.Set("Authorization", "Bearer "+forwardToken)
+		return header
+	}
+
+	for _, key := range []string{"Authorization", middleware.HMACDateHeader} {
+		if value := c.request.Header.Get(key); value != "" {
+			header.Set(key, value)
+		}
+	}
+	return header
+}
+
+// notifyLeaderGroupEvent, center is a follower, send group event to leader also.
+// header and body are the forward credentials and original request body.
+func notifyLeaderGroupEvent(c *Context, req *request.GroupEventRequest, requestURI string, header http.Header, body []byte) {
+
+	if c.Controller.IsClusterLeader() {
+		return
+	}
+
+	leader, err := c.Controller.GetClusterLeader()
+	if err != nil {
+		logger.ERROR("[#api#] %s notify leader group event error, %s", c.ID, err.Error())
+		return
+	}
+
+	leaderRequest, err := http.NewRequest(http.MethodPost, leader+requestURI, bytes.NewReader(body))
+	if err != nil {
+		logger.ERROR("[#api#] %s notify leader %s group event error, %s", c.ID, leader, err.Error())
+		return
+	}
+
+	leaderRequest.Header = header
+	client := &http.Client{Timeout: leaderRequestTimeout, Transport: leaderTransport}
+	resp, err := client.Do(leaderRequest)
+	if err != nil {
+		logger.ERROR("[#api#] %s notify leader %s group event error, %s", c.ID, leader, err.Error())
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		logger.ERROR("[#api#] %s notify leader %s group event %s failure, %d", c.ID, leader, req.Event, resp.StatusCode)
+		return
+	}
+	logger.INFO("[#api#] %s notify leader %s group event %s, %d", c.ID, leader, req.Event, resp.StatusCode)
+}
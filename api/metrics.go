@@ -0,0 +1,60 @@
+package api
+
+import "humpback-center/metrics"
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// getMetrics, prometheus text format of cluster snapshot and center counters.
+func getMetrics(c *Context) error {
+
+	clusterMetrics := c.Controller.GetClusterMetrics()
+	engines := metrics.NewGaugeFamily("humpback_center_engines", "Cluster engines by state.", "state")
+	for _, state := range []string{"Pending", "Healthy", "Unhealthy", "Disconnected"} {
+		engines.Set(float64(clusterMetrics.Engines[state]), state)
+	}
+
+	cpus := metrics.NewGaugeFamily("humpback_center_group_cpus", "Group cpus of healthy engines, overcommit included.", "groupid", "group", "type")
+	memory := metrics.NewGaugeFamily("humpback_center_group_memory_megabytes", "Group memory of healthy engines, overcommit included.", "groupid", "group", "type")
+	metas := metrics.NewGaugeFamily("humpback_center_group_metas", "Group metas.", "groupid", "group")
+	containers := metrics.NewGaugeFamily("humpback_center_group_containers", "Group metas containers.", "groupid", "group")
+	instances := metrics.NewGaugeFamily("humpback_center_group_instances", "Group metas desired and actual running instances.", "groupid", "group", "type")
+	for _, group := range clusterMetrics.Groups {
+		cpus.Set(float64(group.TotalCpus), group.GroupID, group.GroupName, "total")
+		cpus.Set(float64(group.UsedCpus), group.GroupID, group.GroupName, "used")
+		memory.Set(float64(group.TotalMemory), group.GroupID, group.GroupName, "total")
+		memory.Set(float64(group.UsedMemory), group.GroupID, group.GroupName, "used")
+		metas.Set(float64(group.Metas), group.GroupID, group.GroupName)
+		containers.Set(float64(group.Containers), group.GroupID, group.GroupName)
+		instances.Set(float64(group.DesiredInstances), group.GroupID, group.GroupName, "desired")
+		instances.Set(float64(group.ActualInstances), group.GroupID, group.GroupName, "actual")
+	}
+
+	upgraders := metrics.NewGaugeFamily("humpback_center_upgraders", "Active metas upgraders.")
+	upgraders.Set(float64(clusterMetrics.Upgraders))
+	migrators := metrics.NewGaugeFamily("humpback_center_migrators", "Active metas migrators.")
+	migrators.Set(float64(clusterMetrics.Migrators))
+
+	buf := bytes.NewBuffer([]byte{})
+	metrics.Write(buf, engines, cpus, memory, metas, containers, instances, upgraders, migrators)
+	c.Response().Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Response().Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	c.WriteHeader(http.StatusOK)
+	_, err := c.Response().Write(buf.Bytes())
+	return err
+}
+
+// observeRequest, record api request duration of route pattern.
+// forwarded requests write response directly, status is unknown and recorded as 200.
+func observeRequest(c *Context, method string, route string, start time.Time) {
+
+	code := c.Response().Status()
+	if code == 0 {
+		code = http.StatusOK
+	}
+	metrics.ObserveAPIRequest(method, route, code, start)
+}
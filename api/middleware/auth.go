@@ -0,0 +1,287 @@
+package middleware
+
+import "github.com/humpback/gounits/logger"
+import "github.com/humpback/gounits/rand"
+import "humpback-center/api/request"
+import "humpback-center/api/response"
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// AccessReadOnly is exported, token only can call GET routes.
+	AccessReadOnly = "ro"
+	// AccessReadWrite is exported, token can call all routes.
+	AccessReadWrite = "rw"
+	// AllGroups is exported, token scope of all groups.
+	AllGroups = "*"
+	// HMACDateHeader is exported, hmac signed request timestamp header, unix seconds.
+	HMACDateHeader = "X-Humpback-Date"
+)
+
+var (
+	// ErrAuthCredentialsMissing is exported
+	ErrAuthCredentialsMissing = errors.New("authorization credentials missing")
+	// ErrAuthCredentialsInvalid is exported
+	ErrAuthCredentialsInvalid = errors.New("authorization credentials invalid")
+	// ErrAuthSignatureExpired is exported
+	ErrAuthSignatureExpired = errors.New("authorization signature expired")
+	// ErrAuthAccessDenied is exported
+	ErrAuthAccessDenied = errors.New("authorization token is read-only")
+)
+
+// identityKey, request context key of authenticated identity.
+type identityKey struct{}
+
+// AuthToken is exported
+// Token: static bearer token, "Authorization: Bearer <token>".
+// Secret: hmac secret, "Authorization: HMAC <name>:<signature>", signature is
+// hex(hmac-sha256(secret, method + "\n" + requesturi + "\n" + X-Humpback-Date + "\n" + hex(sha256(body)))).
+// Groups: token scope groupids, "*" is all groups.
+// Access: ro or rw, default ro.
+type AuthToken struct {
+	Name   string   `yaml:"name"`
+	Token  string   `yaml:"token"`
+	Secret string   `yaml:"secret"`
+	Groups []string `yaml:"groups"`
+	Access string   `yaml:"access"`
+}
+
+// AuthConfig is exported
+// MaxSkew: hmac signed request timestamp max skew, default 5m.
+// ForwardToken: bearer token of follower requests to leader, a rw token of all groups on the leader,
+// empty forwards the caller credentials.
+type AuthConfig struct {
+	Enabled      bool        `yaml:"enabled"`
+	MaxSkew      string      `yaml:"maxskew"`
+	ForwardToken string      `yaml:"forwardtoken"`
+	Tokens       []AuthToken `yaml:"tokens"`
+}
+
+// Identity is exported
+// an authenticated request caller.
+type Identity struct {
+	Name   string
+	Groups []string
+	Access string
+}
+
+// AllGroups is exported
+func (identity *Identity) AllGroups() bool {
+
+	for _, groupid := range identity.Groups {
+		if groupid == AllGroups {
+			return true
+		}
+	}
+	return false
+}
+
+// InGroup is exported
+func (identity *Identity) InGroup(groupid string) bool {
+
+	for _, value := range identity.Groups {
+		if value == AllGroups || value == groupid {
+			return true
+		}
+	}
+	return false
+}
+
+// CanWrite is exported
+func (identity *Identity) CanWrite() bool {
+
+	return identity.Access == AccessReadWrite
+}
+
+// GetIdentity is exported
+// Return request authenticated identity, authentication disabled return nil.
+func GetIdentity(r *http.Request) *Identity {
+
+	if identity, ret := r.Context().Value(identityKey{}).(*Identity); ret {
+		return identity
+	}
+	return nil
+}
+
+// Authenticator is exported
+type Authenticator struct {
+	maxSkew time.Duration
+	tokens  map[string]*AuthToken
+	secrets map[string]*AuthToken
+	skips   map[string]bool
+}
+
+// NewAuthenticator is exported
+// config disabled return nil, skips are request paths without authentication.
+func NewAuthenticator(config AuthConfig, skips ...string) (*Authenticator, error) {
+
+	if !config.Enabled {
+		return nil, nil
+	}
+
+	maxSkew := 5 * time.Minute
+	if config.MaxSkew != "" {
+		value, err := time.ParseDuration(config.MaxSkew)
+		if err != nil {
+			return nil, fmt.Errorf("api auth maxskew invalid, %s", err.Error())
+		}
+		maxSkew = value
+	}
+
+	authenticator := &Authenticator{
+		maxSkew: maxSkew,
+		tokens:  make(map[string]*AuthToken),
+		secrets: make(map[string]*AuthToken),
+		skips:   make(map[string]bool),
+	}
+
+	for i := range config.Tokens {
+		authToken := &config.Tokens[i]
+		if authToken.Name == "" {
+			return nil, fmt.Errorf("api auth token name can not be empty")
+		}
+		if authToken.Token == "" && authToken.Secret == "" {
+			return nil, fmt.Errorf("api auth token %s, token or secret can not be empty", authToken.Name)
+		}
+		if authToken.Access == "" {
+			authToken.Access = AccessReadOnly
+		}
+		if authToken.Access != AccessReadOnly && authToken.Access != AccessReadWrite {
+			return nil, fmt.Errorf("api auth token %s, access should be ro or rw", authToken.Name)
+		}
+		if authToken.Token != "" {
+			authenticator.tokens[authToken.Token] = authToken
+		}
+		if authToken.Secret != "" {
+			authenticator.secrets[authToken.Name] = authToken
+		}
+	}
+
+	for _, skip := range skips {
+		authenticator.skips[skip] = true
+	}
+	return authenticator, nil
+}
+
+// Handler is exported
+// authenticate request, set identity to request context.
+// credentials missing or invalid is 401, read-only token call mutating route is 403.
+func (authenticator *Authenticator) Handler(inner http.Handler) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions || authenticator.skips[r.URL.Path] {
+			inner.ServeHTTP(w, r)
+			return
+		}
+
+		authToken, err := authenticator.authenticate(r)
+		if err != nil {
+			logger.WARN("[#api#] HTTP %s\t%s\tunauthorized, %s", r.Method, r.RequestURI, err.Error())
+			writeAuthError(w, http.StatusUnauthorized, request.RequestUnauthorized, request.ErrRequestUnauthorized, err)
+			return
+		}
+
+		identity := &Identity{
+			Name:   authToken.Name,
+			Groups: authToken.Groups,
+			Access: authToken.Access,
+		}
+
+		if r.Method != http.MethodGet && r.Method != http.MethodHead && !identity.CanWrite() {
+			logger.WARN("[#api#] HTTP %s\t%s\tforbidden, %s read-only", r.Method, r.RequestURI, identity.Name)
+			writeAuthError(w, http.StatusForbidden, request.RequestForbidden, request.ErrRequestForbidden, ErrAuthAccessDenied)
+			return
+		}
+		inner.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), identityKey{}, identity)))
+	})
+}
+
+func (authenticator *Authenticator) authenticate(r *http.Request) (*AuthToken, error) {
+
+	authorization := strings.TrimSpace(r.Header.Get("Authorization"))
+	parts := strings.SplitN(authorization, " ", 2)
+	if len(parts) != 2 {
+		return nil, ErrAuthCredentialsMissing
+	}
+
+	credentials := strings.TrimSpace(parts[1])
+	switch strings.ToLower(parts[0]) {
+	case "bearer":
+		if authToken, ret := authenticator.tokens[credentials]; ret {
+			return authToken, nil
+		}
+		return nil, ErrAuthCredentialsInvalid
+	case "hmac":
+		return authenticator.verifySignature(r, credentials)
+	}
+	return nil, ErrAuthCredentialsInvalid
+}
+
+func (authenticator *Authenticator) verifySignature(r *http.Request, credentials string) (*AuthToken, error) {
+
+	parts := strings.SplitN(credentials, ":", 2)
+	if len(parts) != 2 {
+		return nil, ErrAuthCredentialsInvalid
+	}
+
+	authToken, ret := authenticator.secrets[parts[0]]
+	if !ret {
+		return nil, ErrAuthCredentialsInvalid
+	}
+
+	date := r.Header.Get(HMACDateHeader)
+	seconds, err := strconv.ParseInt(date, 10, 64)
+	if err != nil {
+		return nil, ErrAuthCredentialsInvalid
+	}
+
+	skew := time.Since(time.Unix(seconds, 0))
+	if skew > authenticator.maxSkew || skew < -authenticator.maxSkew {
+		return nil, ErrAuthSignatureExpired
+	}
+
+	body := []byte{}
+	if r.Body != nil {
+		if body, err = ioutil.ReadAll(r.Body); err != nil {
+			return nil, err
+		}
+		r.Body.Close()
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	bodySum := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte(authToken.Secret))
+	mac.Write([]byte(r.Method + "\n" + r.URL.RequestURI() + "\n" + date + "\n" + hex.EncodeToString(bodySum[:])))
+	signature, err := hex.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, ErrAuthCredentialsInvalid
+	}
+	return authToken, nil
+}
+
+func writeAuthError(w http.ResponseWriter, status int, code int, err error, content error) {
+
+	result := &response.ResponseResult{ResponseID: rand.UUID(true)}
+	result.SetError(code, err, content.Error())
+	data, _ := json.Marshal(result)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+	w.WriteHeader(status)
+	w.Write(data)
+}
@@ -0,0 +1,289 @@
+package api
+
+import "github.com/humpback/gounits/logger"
+import "humpback-center/api/request"
+import "humpback-center/api/response"
+import "humpback-center/cluster"
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// paramSpec is a route query parameter.
+type paramSpec struct {
+	name        string
+	typ         string
+	description string
+}
+
+/*
+routeSpec is a route document of openapi.
+body: request body prototype, body of route is validated before handler.
+response: Data prototype of ResponseResult.
+contentType: response content type, not json response has no schema.
+status: success status code, default 200.
+raw: response is a bare prototype download, not wrapped in ResponseResult, with a Content-Disposition header.
+*/
+type routeSpec struct {
+	summary     string
+	query       []paramSpec
+	body        interface{}
+	response    interface{}
+	contentType string
+	status      string
+	raw         bool
+}
+
+var (
+	asyncParam         = paramSpec{"async", "boolean", "run as a background job, response 202 with job."}
+	listParams         = []paramSpec{{"state", "string", "state filter."}, {"server", "string", "engine ip or hostname."}, {"name", "string", "name prefix."}, {"image", "string", "image, without tag matches all tags."}, {"sort", "string", "sort key."}, {"limit", "integer", "page size."}, {"cursor", "string", "NextCursor of previous page."}}
+	logsParams         = []paramSpec{{"tail", "string", "all or lines of end of logs."}, {"since", "string", "RFC3339 or unix seconds."}, {"follow", "boolean", "keep streaming new logs."}, {"timestamps", "boolean", "add timestamp to every line."}}
+	registryPageParams = []paramSpec{{"limit", "integer", "page size."}, {"cursor", "string", "NextCursor of previous page."}}
+	routeSpecs         = map[string]*routeSpec{
+		"GET /v1/_ping":                                     {summary: "ping center.", contentType: "application/json"},
+		"GET /metrics":                                      {summary: "prometheus metrics.", contentType: "text/plain"},
+		"GET /v1/openapi.json":                              {summary: "openapi document.", contentType: "application/json"},
+		"GET /v1/groups":                                    {summary: "list cluster groups.", response: response.ClusterGroupsResponse{}},
+		"GET /v1/engines":                                   {summary: "list cluster pool engines.", response: response.ClusterEnginesResponse{}},
+		"GET /v1/groups/{groupid}/collections":              {summary: "list group metas and containers.", query: listParams, response: response.GroupAllContainersResponse{}},
+		"GET /v1/groups/{groupid}/engines":                  {summary: "list group engines.", query: listParams, response: response.GroupEnginesResponse{}},
+		"GET /v1/groups/collections/{metaid}":               {summary: "get meta containers.", query: listParams, response: response.GroupContainersResponse{}},
+		"GET /v1/groups/collections/{metaid}/base":          {summary: "get meta base.", response: response.GroupContainersMetaBaseResponse{}},
+		"GET /v1/groups/engines/{server}":                   {summary: "get engine.", response: response.GroupEngineResponse{}},
+		"GET /v1/groups/collections/{metaid}/stats":         {summary: "get meta containers stats.", response: response.GroupContainersStatsResponse{}},
+		"GET /v1/groups/collections/{metaid}/logs":          {summary: "merged logs of meta containers.", query: logsParams, contentType: "text/plain"},
+		"GET /v1/groups/container/{containerid}/logs":       {summary: "container logs.", query: logsParams, contentType: "text/plain"},
+		"GET /v1/cluster/capacity":                          {summary: "cluster capacity of groups and locations.", response: response.ClusterCapacityResponse{}},
+		"GET /v1/cluster/recovery":                          {summary: "recent recovery runs.", query: []paramSpec{{"count", "integer", "runs count."}}, response: response.ClusterRecoveryRunsResponse{}},
+		"GET /v1/cluster/metadata/quarantine":               {summary: "quarantined metas.", response: response.ClusterQuarantinedMetasResponse{}},
+		"GET /v1/cluster/metadata/export":                   {summary: "export metadata archive, raw json download.", query: []paramSpec{{"groupid", "string", "export a group only."}}, response: cluster.MetaDataArchive{}, raw: true},
+		"GET /v1/audit":                                     {summary: "audit records.", query: []paramSpec{{"groupid", "string", ""}, {"metaid", "string", ""}, {"operation", "string", ""}, {"since", "string", "RFC3339 or unix seconds."}, {"until", "string", "RFC3339 or unix seconds."}, {"limit", "integer", ""}}, response: response.ClusterAuditResponse{}},
+		"GET /v1/events":                                    {summary: "cluster events stream.", query: []paramSpec{{"groupid", "string", ""}, {"metaid", "string", ""}, {"type", "string", "comma separated event types."}, {"lastEventId", "string", "resume after event id."}}, contentType: "text/event-stream"},
+		"GET /v1/jobs/{jobid}":                              {summary: "get job.", response: response.ClusterJobResponse{}},
+		"GET /v1/repository/images/catalog":                 {summary: "repository images catalog.", query: registryPageParams, response: response.RepositoryImagesCatalogResponse{}},
+		"GET /v1/repository/images/tags/{name:.*}":          {summary: "repository image tags.", query: registryPageParams, response: response.RepositoryImageTagsResponse{}},
+		"POST /v1/groups/event":                             {summary: "group changed event.", query: []paramSpec{asyncParam}, body: request.GroupEventRequest{}, response: response.GroupEventResponse{}, status: "202"},
+		"POST /v1/groups/collections":                       {summary: "create meta containers.", query: []paramSpec{asyncParam}, body: request.GroupCreateContainersRequest{}, response: response.GroupCreateContainersResponse{}},
+		"POST /v1/cluster/recovery":                         {summary: "start a recovery run.", response: response.ClusterRecoveryResponse{}, status: "202"},
+		"POST /v1/cluster/metadata/import":                  {summary: "import metadata archive.", query: []paramSpec{{"mode", "string", "merge or replace."}}, body: cluster.MetaDataArchive{}, response: response.ClusterMetaDataImportResponse{}},
+		"POST /v1/repository/images/migrate":                {summary: "migrate repository images between registries, always a background job.", body: request.RepositoryImagesMigrateRequest{}, response: response.ClusterJobResponse{}, status: "202"},
+		"PUT /v1/groups/collections":                        {summary: "update meta instances and webhooks.", body: request.GroupUpdateContainersRequest{}, response: response.GroupUpdateContainersResponse{}},
+		"PUT /v1/groups/collections/upgrade":                {summary: "upgrade meta containers image tag.", query: []paramSpec{asyncParam}, body: request.GroupUpgradeContainersRequest{}, response: response.GroupUpgradeContainersResponse{}},
+		"PUT /v1/groups/collections/action":                 {summary: "operate meta containers.", body: request.GroupOperateContainersRequest{}, response: response.GroupOperateContainersResponse{}},
+		"PUT /v1/groups/{groupid}/collections/{name}/apply": {summary: "apply desired meta state.", query: []paramSpec{{"dryRun", "boolean", "returns plan without executing."}, asyncParam}, body: request.GroupApplyContainersRequest{}, response: response.GroupApplyContainersResponse{}},
+		"PUT /v1/groups/collections/{metaid}/maintenance":   {summary: "set meta maintenance mode.", body: request.GroupContainersMaintenanceRequest{}, response: response.GroupContainersMetaBaseResponse{}},
+		"PUT /v1/groups/container/action":                   {summary: "operate a container.", body: request.GroupOperateContainerRequest{}, response: response.GroupOperateContainersResponse{}},
+		"DELETE /v1/groups/collections/{metaid}":            {summary: "remove meta and containers.", query: []paramSpec{asyncParam}, response: response.GroupRemoveContainersResponse{}},
+		"DELETE /v1/groups/container/{containerid}":         {summary: "remove a container.", response: response.GroupRemoveContainersResponse{}},
+		"DELETE /v1/repository/images/{name:.*}":            {summary: "remove repository image manifest, name is image with tag or digest.", response: response.RepositoryRemoveImageResponse{}},
+		"DELETE /v1/jobs/{jobid}":                           {summary: "cancel job.", response: response.ClusterJobResponse{}, status: "202"},
+	}
+)
+
+// pathParamRegexp, route vars, eg: {metaid} {name:.*}
+var pathParamRegexp = regexp.MustCompile(`\{(\w+)(:[^}]*)?\}`)
+
+// openAPI, document and body schemas are built once from routes.
+type openAPI struct {
+	once     sync.Once
+	builder  *schemaBuilder
+	bodies   map[string]*Schema
+	document []byte
+}
+
+var apiDocument = &openAPI{}
+
+func init() {
+
+	// registered at init, openapi document is built from routes.
+	routes["GET"]["/v1/openapi.json"] = getOpenAPI
+}
+
+func (doc *openAPI) init() {
+
+	doc.once.Do(func() {
+		doc.builder = newSchemaBuilder()
+		doc.bodies = map[string]*Schema{}
+		resultSchema := doc.builder.schemaOf(reflect.TypeOf(response.ResponseResult{}))
+		paths := map[string]map[string]interface{}{}
+		for method, mappings := range routes {
+			for route, routehandler := range mappings {
+				path := pathParamRegexp.ReplaceAllString(route, "{$1}")
+				if paths[path] == nil {
+					paths[path] = map[string]interface{}{}
+				}
+				paths[path][strings.ToLower(method)] = doc.operation(method, route, routehandler, resultSchema)
+			}
+		}
+
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"openapi": "3.0.3",
+			"info": map[string]interface{}{
+				"title":   "humpback-center",
+				"version": "v1",
+			},
+			"paths": paths,
+			"components": map[string]interface{}{
+				"schemas": doc.builder.schemas,
+			},
+		}, "", "  ")
+		if err != nil {
+			logger.ERROR("[#api#] openapi document encode error, %s", err.Error())
+		}
+		doc.document = data
+	})
+}
+
+func (doc *openAPI) operation(method string, route string, routehandler handler, resultSchema *Schema) map[string]interface{} {
+
+	spec, ret := routeSpecs[method+" "+route]
+	if !ret {
+		spec = &routeSpec{}
+	}
+
+	operationID := runtime.FuncForPC(reflect.ValueOf(routehandler).Pointer()).Name()
+	operationID = operationID[strings.LastIndex(operationID, ".")+1:]
+	parameters := []map[string]interface{}{}
+	for _, match := range pathParamRegexp.FindAllStringSubmatch(route, -1) {
+		parameters = append(parameters, map[string]interface{}{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   &Schema{Type: "string"},
+		})
+	}
+	for _, param := range spec.query {
+		parameters = append(parameters, map[string]interface{}{
+			"name":        param.name,
+			"in":          "query",
+			"description": param.description,
+			"schema":      &Schema{Type: param.typ},
+		})
+	}
+
+	status := spec.status
+	if status == "" {
+		status = "200"
+	}
+
+	success := map[string]interface{}{"description": "successed."}
+	switch {
+	case spec.contentType != "":
+		success["content"] = map[string]interface{}{spec.contentType: map[string]interface{}{}}
+	case spec.response != nil && spec.raw:
+		success["description"] = "successed, raw download, not wrapped in ResponseResult."
+		success["headers"] = map[string]interface{}{"Content-Disposition": map[string]interface{}{
+			"schema": &Schema{Type: "string"},
+		}}
+		success["content"] = map[string]interface{}{"application/json": map[string]interface{}{
+			"schema": doc.builder.schemaOf(reflect.TypeOf(spec.response)),
+		}}
+	case spec.response != nil:
+		dataSchema := doc.builder.schemaOf(reflect.TypeOf(spec.response))
+		success["content"] = map[string]interface{}{"application/json": map[string]interface{}{
+			"schema": &Schema{AllOf: []*Schema{resultSchema, {Type: "object", Properties: map[string]*Schema{"Data": dataSchema}}}},
+		}}
+	default:
+		success["content"] = map[string]interface{}{"application/json": map[string]interface{}{}}
+	}
+
+	operation := map[string]interface{}{
+		"operationId": operationID,
+		"summary":     spec.summary,
+		"parameters":  parameters,
+		"responses": map[string]interface{}{
+			status: success,
+			"default": map[string]interface{}{
+				"description": "failure.",
+				"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": resultSchema}},
+			},
+		},
+	}
+
+	if spec.body != nil {
+		bodySchema := doc.builder.schemaOf(reflect.TypeOf(spec.body))
+		doc.bodies[method+" "+route] = bodySchema
+		operation["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": bodySchema}},
+		}
+	}
+	return operation
+}
+
+// Document is exported
+// returns openapi json document.
+func (doc *openAPI) Document() []byte {
+
+	doc.init()
+	return doc.document
+}
+
+// Validate is exported
+// validate request body of route, returns nil if route has no body schema.
+func (doc *openAPI) Validate(method string, route string, body []byte) []*response.FieldError {
+
+	doc.init()
+	bodySchema, ret := doc.bodies[method+" "+route]
+	if !ret {
+		return nil
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return []*response.FieldError{{Field: "", Error: "invalid json body, " + err.Error()}}
+	}
+
+	fieldErrors := doc.builder.validate(bodySchema, value, "")
+	sort.SliceStable(fieldErrors, func(i, j int) bool {
+		return fieldErrors[i].Field < fieldErrors[j].Field
+	})
+	return fieldErrors
+}
+
+// validateBody, validate request json body of route with openapi schema.
+// return true if request is rejected.
+func validateBody(c *Context, method string, route string) bool {
+
+	if c.request.Body == nil {
+		return false
+	}
+
+	buf, err := ioutil.ReadAll(c.request.Body)
+	c.request.Body.Close()
+	c.request.Body = ioutil.NopCloser(bytes.NewReader(buf))
+	if err != nil {
+		return false
+	}
+
+	fieldErrors := apiDocument.Validate(method, route, buf)
+	if len(fieldErrors) == 0 {
+		return false
+	}
+
+	logger.WARN("[#api#] %s request body invalid, %d field errors.", c.ID, len(fieldErrors))
+	result := &response.ResponseResult{ResponseID: c.ID}
+	result.SetError(request.RequestInvalid, request.ErrRequestInvalid, "request body invalid")
+	result.SetFields(fieldErrors)
+	c.JSON(http.StatusBadRequest, result)
+	return true
+}
+
+func getOpenAPI(c *Context) error {
+
+	c.Response().Header().Set("Content-Type", "application/json; charset=utf-8")
+	c.WriteHeader(http.StatusOK)
+	_, err := c.Response().Write(apiDocument.Document())
+	return err
+}
@@ -1,23 +1,118 @@
 package api
 
-import "net/http"
+import "github.com/humpback/gounits/logger"
+import "humpback-center/api/request"
+import "humpback-center/api/response"
+import "humpback-center/cluster"
+import "humpback-center/repository"
+
+import (
+	"net/http"
+)
+
+// repositoryErrorStatus, returns http status of repository error.
+func repositoryErrorStatus(err error) int {
+
+	switch err {
+	case repository.ErrRepositoryDisabled:
+		return http.StatusServiceUnavailable
+	case repository.ErrRepositoryNotFound:
+		return http.StatusNotFound
+	case repository.ErrRepositoryUnauthorized:
+		return http.StatusBadGateway
+	case repository.ErrRepositoryDeleteUnsupported:
+		return http.StatusMethodNotAllowed
+	}
+	return http.StatusInternalServerError
+}
 
 func getRepositoryImagesCatalog(c *Context) error {
 
-	return c.JSON(http.StatusOK, "Catalog.")
+	result := &response.ResponseResult{ResponseID: c.ID}
+	req, err := request.ResolveRepositoryImagesCatalogRequest(c.Request())
+	if err != nil {
+		logger.ERROR("[#api#] %s resolve repository catalog request faild, %s", c.ID, err.Error())
+		result.SetError(request.RequestInvalid, request.ErrRequestInvalid, err.Error())
+		return c.JSON(http.StatusBadRequest, result)
+	}
+
+	registry, repositories, nextCursor, err := c.Controller.GetRepositoryCatalog(req.Limit, req.Cursor)
+	if err != nil {
+		logger.ERROR("[#api#] %s get repository catalog error: %s", c.ID, err.Error())
+		result.SetError(request.RequestFailure, request.ErrRequestFailure, err.Error())
+		return c.JSON(repositoryErrorStatus(err), result)
+	}
+
+	resp := response.NewRepositoryImagesCatalogResponse(registry, repositories, nextCursor)
+	result.SetError(request.RequestSuccessed, request.ErrRequestSuccessed, "repository catalog response")
+	result.SetResponse(resp)
+	return c.JSON(http.StatusOK, result)
 }
 
 func getRepositoryImagesTags(c *Context) error {
 
-	return c.JSON(http.StatusOK, "Tags.")
+	result := &response.ResponseResult{ResponseID: c.ID}
+	req, err := request.ResolveRepositoryImageTagsRequest(c.Request())
+	if err != nil {
+		logger.ERROR("[#api#] %s resolve repository tags request faild, %s", c.ID, err.Error())
+		result.SetError(request.RequestInvalid, request.ErrRequestInvalid, err.Error())
+		return c.JSON(http.StatusBadRequest, result)
+	}
+
+	tags, nextCursor, err := c.Controller.GetRepositoryTags(req.Name, req.Limit, req.Cursor)
+	if err != nil {
+		logger.ERROR("[#api#] %s get repository %s tags error: %s", c.ID, req.Name, err.Error())
+		result.SetError(request.RequestFailure, request.ErrRequestFailure, err.Error())
+		return c.JSON(repositoryErrorStatus(err), result)
+	}
+
+	resp := response.NewRepositoryImageTagsResponse(req.Name, tags, nextCursor)
+	result.SetError(request.RequestSuccessed, request.ErrRequestSuccessed, "repository tags response")
+	result.SetResponse(resp)
+	return c.JSON(http.StatusOK, result)
 }
 
 func postRepositoryImagesMigrate(c *Context) error {
 
-	return c.JSON(http.StatusOK, "Migrate...")
+	result := &response.ResponseResult{ResponseID: c.ID}
+	req, err := request.ResolveRepositoryImagesMigrateRequest(c.Request())
+	if err != nil {
+		logger.ERROR("[#api#] %s resolve migrate repository images request faild, %s", c.ID, err.Error())
+		result.SetError(request.RequestInvalid, request.ErrRequestInvalid, err.Error())
+		return c.JSON(http.StatusBadRequest, result)
+	}
+
+	logger.INFO("[#api#] %s resolve migrate repository images request successed. %+v", c.ID, req.AuditParameters())
+	record := newAuditRecord(c, cluster.AuditMigrateImages, "", "", req.AuditParameters())
+	return submitClusterJob(c, record, func(job *cluster.Job) (interface{}, error) {
+		migrateResult, err := c.Controller.MigrateRepositoryImages(req.Source, req.Destination, req.Images, job)
+		writeAudit(c, record, migrateResult, err)
+		return migrateResult, err
+	})
 }
 
 func deleteRepositoryImages(c *Context) error {
 
-	return c.JSON(http.StatusOK, "Remove.")
+	result := &response.ResponseResult{ResponseID: c.ID}
+	req, err := request.ResolveRepositoryRemoveImageRequest(c.Request())
+	if err != nil {
+		logger.ERROR("[#api#] %s resolve remove repository image request faild, %s", c.ID, err.Error())
+		result.SetError(request.RequestInvalid, request.ErrRequestInvalid, err.Error())
+		return c.JSON(http.StatusBadRequest, result)
+	}
+
+	logger.INFO("[#api#] %s resolve remove repository image request successed. %+v", c.ID, req)
+	record := newAuditRecord(c, cluster.AuditRemoveImage, "", "", req)
+	digest, err := c.Controller.RemoveRepositoryImage(req.Name, req.Reference)
+	writeAudit(c, record, digest, err)
+	if err != nil {
+		logger.ERROR("[#api#] %s remove repository image %s:%s error: %s", c.ID, req.Name, req.Reference, err.Error())
+		result.SetError(request.RequestFailure, request.ErrRequestFailure, err.Error())
+		return c.JSON(repositoryErrorStatus(err), result)
+	}
+
+	resp := response.NewRepositoryRemoveImageResponse(req.Name, req.Reference, digest)
+	result.SetError(request.RequestSuccessed, request.ErrRequestSuccessed, "remove repository image response")
+	result.SetResponse(resp)
+	return c.JSON(http.StatusOK, result)
 }
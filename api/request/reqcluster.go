@@ -1,6 +1,7 @@
 package request
 
 import "github.com/gorilla/mux"
+import "humpback-center/cluster"
 import "humpback-center/cluster/types"
 import "common/models"
 
@@ -10,16 +11,20 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
 /*
 GroupAllContainersRequest is exported
 Method:  GET
 Route:   /v1/groups/{groupid}/collections
+Query:   state, server, name, image, sort(index|created), limit, cursor
 */
 type GroupAllContainersRequest struct {
 	GroupID string `json:"GroupId"`
+	cluster.ListOptions
 }
 
 // ResolveGroupAllContainersRequest is exported
@@ -31,8 +36,14 @@ func ResolveGroupAllContainersRequest(r *http.Request) (*GroupAllContainersReque
 		return nil, fmt.Errorf("groupid invalid, can not be empty")
 	}
 
+	listOptions, err := parseQueryListOptions(r, cluster.IsContainerState, cluster.SortByIndex, cluster.SortByCreated)
+	if err != nil {
+		return nil, err
+	}
+
 	return &GroupAllContainersRequest{
-		GroupID: groupid,
+		GroupID:     groupid,
+		ListOptions: *listOptions,
 	}, nil
 }
 
@@ -40,9 +51,11 @@ func ResolveGroupAllContainersRequest(r *http.Request) (*GroupAllContainersReque
 GroupContainersRequest is exported
 Method:  GET
 Route:   /v1/groups/collections/{metaid}
+Query:   state, server, name, image, sort(index|created), limit, cursor
 */
 type GroupContainersRequest struct {
 	MetaID string `json:"MetaId"`
+	cluster.ListOptions
 }
 
 // ResolveGroupContainersRequest is exported
@@ -54,8 +67,14 @@ func ResolveGroupContainersRequest(r *http.Request) (*GroupContainersRequest, er
 		return nil, fmt.Errorf("metaid invalid, can not be empty")
 	}
 
+	listOptions, err := parseQueryListOptions(r, cluster.IsContainerState, cluster.SortByIndex, cluster.SortByCreated)
+	if err != nil {
+		return nil, err
+	}
+
 	request := &GroupContainersRequest{
-		MetaID: metaid,
+		MetaID:      metaid,
+		ListOptions: *listOptions,
 	}
 	return request, nil
 }
@@ -84,13 +103,39 @@ func ResolveGroupContainersMetaBaseRequest(r *http.Request) (*GroupContainersMet
 	return request, nil
 }
 
+/*
+GroupContainersStatsRequest is exported
+Method:  GET
+Route:   /v1/groups/collections/{metaid}/stats
+*/
+type GroupContainersStatsRequest struct {
+	MetaID string `json:"MetaId"`
+}
+
+// ResolveGroupContainersStatsRequest is exported
+func ResolveGroupContainersStatsRequest(r *http.Request) (*GroupContainersStatsRequest, error) {
+
+	vars := mux.Vars(r)
+	metaid := strings.TrimSpace(vars["metaid"])
+	if len(strings.TrimSpace(metaid)) == 0 {
+		return nil, fmt.Errorf("metaid invalid, can not be empty")
+	}
+
+	request := &GroupContainersStatsRequest{
+		MetaID: metaid,
+	}
+	return request, nil
+}
+
 /*
 GroupEnginesRequest is exported
 Method:  GET
 Route:   /v1/groups/{groupid}/engines
+Query:   state, server, name, image, sort(name|ip), limit, cursor
 */
 type GroupEnginesRequest struct {
 	GroupID string `json:"GroupId"`
+	cluster.ListOptions
 }
 
 // ResolveGroupEnginesRequest is exported
@@ -102,8 +147,14 @@ func ResolveGroupEnginesRequest(r *http.Request) (*GroupEnginesRequest, error) {
 		return nil, fmt.Errorf("groupid invalid, can not be empty")
 	}
 
+	listOptions, err := parseQueryListOptions(r, cluster.IsEngineState, cluster.SortByName, cluster.SortByIP)
+	if err != nil {
+		return nil, err
+	}
+
 	return &GroupEnginesRequest{
-		GroupID: groupid,
+		GroupID:     groupid,
+		ListOptions: *listOptions,
 	}, nil
 }
 
@@ -130,6 +181,378 @@ func ResolveGroupEngineRequest(r *http.Request) (*GroupEngineRequest, error) {
 	}, nil
 }
 
+/*
+ClusterRecoveryRunsRequest is exported
+Method:  GET
+Route:   /v1/cluster/recovery
+Count:   optional, query last count runs.
+*/
+type ClusterRecoveryRunsRequest struct {
+	Count int `json:"Count"`
+}
+
+// ResolveClusterRecoveryRunsRequest is exported
+func ResolveClusterRecoveryRunsRequest(r *http.Request) (*ClusterRecoveryRunsRequest, error) {
+
+	request := &ClusterRecoveryRunsRequest{}
+	count := strings.TrimSpace(r.URL.Query().Get("count"))
+	if count != "" {
+		value, err := strconv.Atoi(count)
+		if err != nil {
+			return nil, fmt.Errorf("recovery runs count invalid, %s", err.Error())
+		}
+		if value < 0 {
+			return nil, fmt.Errorf("recovery runs count invalid, should be larger than or equal to 0")
+		}
+		request.Count = value
+	}
+	return request, nil
+}
+
+/*
+ClusterMetaDataExportRequest is exported
+Method:  GET
+Route:   /v1/cluster/metadata/export
+GroupID: optional, query groupid, only export the group metas.
+Response is the bare archive, posted to import as it is.
+*/
+type ClusterMetaDataExportRequest struct {
+	GroupID string `json:"GroupId"`
+}
+
+// ResolveClusterMetaDataExportRequest is exported
+func ResolveClusterMetaDataExportRequest(r *http.Request) (*ClusterMetaDataExportRequest, error) {
+
+	return &ClusterMetaDataExportRequest{
+		GroupID: strings.TrimSpace(r.URL.Query().Get("groupid")),
+	}, nil
+}
+
+/*
+ClusterMetaDataImportRequest is exported
+Method:  POST
+Route:   /v1/cluster/metadata/import
+Mode:    optional, query mode, merge or replace, default merge.
+Archive: body, a metadata archive of export.
+*/
+type ClusterMetaDataImportRequest struct {
+	Mode    string                   `json:"Mode"`
+	Archive *cluster.MetaDataArchive `json:"Archive"`
+}
+
+// ResolveClusterMetaDataImportRequest is exported
+func ResolveClusterMetaDataImportRequest(r *http.Request) (*ClusterMetaDataImportRequest, error) {
+
+	mode := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("mode")))
+	if mode == "" {
+		mode = cluster.MetaDataImportMerge
+	}
+
+	if mode != cluster.MetaDataImportMerge && mode != cluster.MetaDataImportReplace {
+		return nil, fmt.Errorf("import mode invalid, should be merge or replace")
+	}
+
+	buf, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := &cluster.MetaDataArchive{}
+	if err := json.NewDecoder(bytes.NewReader(buf)).Decode(archive); err != nil {
+		return nil, err
+	}
+
+	if archive.Version <= 0 {
+		return nil, fmt.Errorf("import archive version invalid")
+	}
+
+	return &ClusterMetaDataImportRequest{
+		Mode:    mode,
+		Archive: archive,
+	}, nil
+}
+
+/*
+ClusterAuditRequest is exported
+Method:  GET
+Route:   /v1/audit
+Query:   optional, groupid, metaid, operation, since, until(RFC3339 or unix seconds), limit.
+*/
+type ClusterAuditRequest struct {
+	cluster.AuditFilter
+}
+
+// ResolveClusterAuditRequest is exported
+func ResolveClusterAuditRequest(r *http.Request) (*ClusterAuditRequest, error) {
+
+	query := r.URL.Query()
+	request := &ClusterAuditRequest{}
+	request.GroupID = strings.TrimSpace(query.Get("groupid"))
+	request.MetaID = strings.TrimSpace(query.Get("metaid"))
+	request.Operation = strings.ToLower(strings.TrimSpace(query.Get("operation")))
+	since, err := parseQueryTime(query.Get("since"))
+	if err != nil {
+		return nil, fmt.Errorf("audit since invalid, %s", err.Error())
+	}
+	request.Since = since
+
+	until, err := parseQueryTime(query.Get("until"))
+	if err != nil {
+		return nil, fmt.Errorf("audit until invalid, %s", err.Error())
+	}
+	request.Until = until
+
+	if limit := strings.TrimSpace(query.Get("limit")); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil {
+			return nil, fmt.Errorf("audit limit invalid, %s", err.Error())
+		}
+		if value < 0 {
+			return nil, fmt.Errorf("audit limit invalid, should be larger than or equal to 0")
+		}
+		request.Limit = value
+	}
+	return request, nil
+}
+
+/*
+ClusterEventsRequest is exported
+Method:  GET
+Route:   /v1/events
+Query:   optional, groupid, metaid, type(comma separated event types), lastEventId.
+Header:  optional, Last-Event-ID, resume stream after the event id, override lastEventId query.
+*/
+type ClusterEventsRequest struct {
+	cluster.EventFilter
+	LastEventID uint64
+}
+
+// ResolveClusterEventsRequest is exported
+func ResolveClusterEventsRequest(r *http.Request) (*ClusterEventsRequest, error) {
+
+	query := r.URL.Query()
+	request := &ClusterEventsRequest{}
+	request.GroupID = strings.TrimSpace(query.Get("groupid"))
+	request.MetaID = strings.TrimSpace(query.Get("metaid"))
+	request.Types = []string{}
+	for _, eventType := range strings.Split(query.Get("type"), ",") {
+		if eventType = strings.TrimSpace(eventType); eventType != "" {
+			if !cluster.IsEventType(eventType) {
+				return nil, fmt.Errorf("event type %s invalid", eventType)
+			}
+			request.Types = append(request.Types, eventType)
+		}
+	}
+
+	lastEventID := strings.TrimSpace(query.Get("lastEventId"))
+	if value := strings.TrimSpace(r.Header.Get("Last-Event-ID")); value != "" {
+		lastEventID = value
+	}
+
+	if lastEventID != "" {
+		value, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("last event id invalid, %s", err.Error())
+		}
+		request.LastEventID = value
+	}
+	return request, nil
+}
+
+/*
+GroupContainerLogsRequest is exported
+Method:  GET
+Route:   /v1/groups/container/{containerid}/logs
+Query:   optional, tail(all or lines), since(RFC3339 or unix seconds), follow, timestamps.
+*/
+type GroupContainerLogsRequest struct {
+	ContainerID string `json:"ContainerId"`
+	cluster.ContainerLogsOptions
+}
+
+// ResolveGroupContainerLogsRequest is exported
+func ResolveGroupContainerLogsRequest(r *http.Request) (*GroupContainerLogsRequest, error) {
+
+	vars := mux.Vars(r)
+	containerid := strings.TrimSpace(vars["containerid"])
+	if len(containerid) == 0 {
+		return nil, fmt.Errorf("container logs containerid invalid, can not be empty")
+	}
+
+	logsOptions, err := parseQueryLogsOptions(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GroupContainerLogsRequest{
+		ContainerID:          containerid,
+		ContainerLogsOptions: *logsOptions,
+	}, nil
+}
+
+/*
+GroupContainersLogsRequest is exported
+Method:  GET
+Route:   /v1/groups/collections/{metaid}/logs
+Query:   optional, tail(all or lines), since(RFC3339 or unix seconds), follow, timestamps.
+*/
+type GroupContainersLogsRequest struct {
+	MetaID string `json:"MetaId"`
+	cluster.ContainerLogsOptions
+}
+
+// ResolveGroupContainersLogsRequest is exported
+func ResolveGroupContainersLogsRequest(r *http.Request) (*GroupContainersLogsRequest, error) {
+
+	vars := mux.Vars(r)
+	metaid := strings.TrimSpace(vars["metaid"])
+	if len(metaid) == 0 {
+		return nil, fmt.Errorf("metaid invalid, can not be empty")
+	}
+
+	logsOptions, err := parseQueryLogsOptions(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GroupContainersLogsRequest{
+		MetaID:               metaid,
+		ContainerLogsOptions: *logsOptions,
+	}, nil
+}
+
+/*
+ClusterJobRequest is exported
+Method:  GET | DELETE
+Route:   /v1/jobs/{jobid}
+*/
+type ClusterJobRequest struct {
+	JobID string `json:"JobId"`
+}
+
+// ResolveClusterJobRequest is exported
+func ResolveClusterJobRequest(r *http.Request) (*ClusterJobRequest, error) {
+
+	vars := mux.Vars(r)
+	jobid := strings.TrimSpace(vars["jobid"])
+	if len(jobid) == 0 {
+		return nil, fmt.Errorf("cluster job id invalid, can not be empty")
+	}
+
+	request := &ClusterJobRequest{
+		JobID: jobid,
+	}
+	return request, nil
+}
+
+// parseQueryAsync, async query value, empty is false.
+func parseQueryAsync(r *http.Request) (bool, error) {
+
+	value := strings.TrimSpace(r.URL.Query().Get("async"))
+	if value == "" {
+		return false, nil
+	}
+
+	async, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("async invalid, %s", err.Error())
+	}
+	return async, nil
+}
+
+// parseQueryListOptions, parse listing filter, sort and paging query, state and sort values are validated.
+func parseQueryListOptions(r *http.Request, isState func(string) bool, sorts ...string) (*cluster.ListOptions, error) {
+
+	query := r.URL.Query()
+	listOptions := &cluster.ListOptions{
+		State:  strings.TrimSpace(query.Get("state")),
+		Server: strings.TrimSpace(query.Get("server")),
+		Name:   strings.TrimSpace(query.Get("name")),
+		Image:  strings.TrimSpace(query.Get("image")),
+		Sort:   strings.ToLower(strings.TrimSpace(query.Get("sort"))),
+	}
+
+	if listOptions.State != "" && !isState(listOptions.State) {
+		return nil, fmt.Errorf("state invalid, %s not supported", listOptions.State)
+	}
+
+	if listOptions.Sort != "" {
+		supported := false
+		for _, value := range sorts {
+			if listOptions.Sort == value {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return nil, fmt.Errorf("sort invalid, must be one of %s", strings.Join(sorts, ","))
+		}
+	}
+
+	if value := strings.TrimSpace(query.Get("limit")); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("limit invalid, must be a positive integer")
+		}
+		listOptions.Limit = limit
+	}
+
+	if value := strings.TrimSpace(query.Get("cursor")); value != "" {
+		cursor, err := strconv.Atoi(value)
+		if err != nil || cursor < 0 {
+			return nil, fmt.Errorf("cursor invalid, %s", value)
+		}
+		listOptions.Cursor = cursor
+	}
+	return listOptions, nil
+}
+
+// parseQueryLogsOptions, parse container logs query, since is converted to unix seconds.
+func parseQueryLogsOptions(r *http.Request) (*cluster.ContainerLogsOptions, error) {
+
+	query := r.URL.Query()
+	logsOptions := &cluster.ContainerLogsOptions{}
+	if tail := strings.TrimSpace(query.Get("tail")); tail != "" && tail != "all" {
+		if value, err := strconv.Atoi(tail); err != nil || value < 0 {
+			return nil, fmt.Errorf("tail invalid, must be all or a positive integer")
+		}
+		logsOptions.Tail = tail
+	}
+
+	since, err := parseQueryTime(query.Get("since"))
+	if err != nil {
+		return nil, fmt.Errorf("since invalid, %s", err.Error())
+	}
+	if !since.IsZero() {
+		logsOptions.Since = strconv.FormatInt(since.Unix(), 10)
+	}
+
+	for name, value := range map[string]*bool{"follow": &logsOptions.Follow, "timestamps": &logsOptions.Timestamps} {
+		if text := strings.TrimSpace(query.Get(name)); text != "" {
+			ret, err := strconv.ParseBool(text)
+			if err != nil {
+				return nil, fmt.Errorf("%s invalid, %s", name, err.Error())
+			}
+			*value = ret
+		}
+	}
+	return logsOptions, nil
+}
+
+// parseQueryTime, value is RFC3339 or unix seconds, empty return zero time.
+func parseQueryTime(value string) (time.Time, error) {
+
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
 const (
 	GROUP_CREATE_EVENT = "create"
 	GROUP_REMOVE_EVENT = "remove"
@@ -140,10 +563,12 @@ const (
 GroupEventRequest is exported
 Method:  POST
 Route:   /v1/groups/event
+Query:   optional, async=true run as a background job, group remove can take long.
 */
 type GroupEventRequest struct {
-	GroupID string `json:"GroupId"`
-	Event   string `json:"Event"`
+	GroupID string `json:"GroupId" required:"true"`
+	Event   string `json:"Event" required:"true"`
+	Async   bool   `json:"-"`
 }
 
 // ResolveGroupEventRequest is exported
@@ -166,6 +591,10 @@ func ResolveGroupEventRequest(r *http.Request) (*GroupEventRequest, error) {
 		request.Event != GROUP_CHANGE_EVENT {
 		return nil, fmt.Errorf("event type invalid.")
 	}
+
+	if request.Async, err = parseQueryAsync(r); err != nil {
+		return nil, err
+	}
 	return request, nil
 }
 
@@ -173,12 +602,14 @@ func ResolveGroupEventRequest(r *http.Request) (*GroupEventRequest, error) {
 GroupCreateContainersRequest is exported
 Method:  POST
 Route:   /v1/groups/collections
+Query:   optional, async=true run as a background job.
 */
 type GroupCreateContainersRequest struct {
-	GroupID   string           `json:"GroupId"`
-	Instances int              `json:"Instances"`
+	GroupID   string           `json:"GroupId" required:"true"`
+	Instances int              `json:"Instances" required:"true"`
 	WebHooks  types.WebHooks   `json:"WebHooks"`
-	Config    models.Container `json:"Config"`
+	Config    models.Container `json:"Config" required:"true"`
+	Async     bool             `json:"-"`
 }
 
 // ResolveGroupCreateContainersRequest is exported
@@ -205,6 +636,10 @@ func ResolveGroupCreateContainersRequest(r *http.Request) (*GroupCreateContainer
 	if len(strings.TrimSpace(request.Config.Name)) == 0 {
 		return nil, fmt.Errorf("create containers name can not be empty")
 	}
+
+	if request.Async, err = parseQueryAsync(r); err != nil {
+		return nil, err
+	}
 	return request, nil
 }
 
@@ -214,8 +649,8 @@ Method:  PUT
 Route:   /v1/groups/collections
 */
 type GroupUpdateContainersRequest struct {
-	MetaID    string         `json:"MetaId"`
-	Instances int            `json:"Instances"`
+	MetaID    string         `json:"MetaId" required:"true"`
+	Instances int            `json:"Instances" required:"true"`
 	WebHooks  types.WebHooks `json:"WebHooks"`
 }
 
@@ -248,8 +683,8 @@ Method:  PUT
 Route:   /v1/groups/collections/action
 */
 type GroupOperateContainersRequest struct {
-	MetaID string `json:"MetaId"`
-	Action string `json:"Action"`
+	MetaID string `json:"MetaId" required:"true"`
+	Action string `json:"Action" required:"true"`
 }
 
 // ResolveGroupOperateContainersRequest is exported
@@ -277,8 +712,8 @@ Method:  PUT
 Route:   /v1/groups/container/action
 */
 type GroupOperateContainerRequest struct {
-	ContainerID string `json:"ContainerId"`
-	Action      string `json:"Action"`
+	ContainerID string `json:"ContainerId" required:"true"`
+	Action      string `json:"Action" required:"true"`
 }
 
 // ResolveGroupOperateContainerRequest is exported
@@ -304,10 +739,14 @@ func ResolveGroupOperateContainerRequest(r *http.Request) (*GroupOperateContaine
 GroupUpgradeContainersRequest is exported
 Method:  PUT
 Route:   /v1/groups/collections/upgrade
+Query:   optional, async=true run as a background job.
+PrePull: optional, engines pull image before upgrade.
 */
 type GroupUpgradeContainersRequest struct {
-	MetaID   string `json:"MetaId"`
-	ImageTag string `json:"ImageTag"`
+	MetaID   string `json:"MetaId" required:"true"`
+	ImageTag string `json:"ImageTag" required:"true"`
+	PrePull  bool   `json:"PrePull"`
+	Async    bool   `json:"-"`
 }
 
 // ResolveGroupUpgradeContainersRequest is exported
@@ -326,6 +765,119 @@ func ResolveGroupUpgradeContainersRequest(r *http.Request) (*GroupUpgradeContain
 	if len(strings.TrimSpace(request.MetaID)) == 0 {
 		return nil, fmt.Errorf("upgrade containers metaid invalid, can not be empty")
 	}
+
+	if request.Async, err = parseQueryAsync(r); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+/*
+GroupApplyContainersRequest is exported
+Method:  PUT
+Route:   /v1/groups/{groupid}/collections/{name}/apply
+Query:   optional, dryRun=true returns plan without executing.
+
+	optional, async=true run as a background job.
+*/
+type GroupApplyContainersRequest struct {
+	GroupID string `json:"-"`
+	Name    string `json:"-"`
+	cluster.ApplySpec
+	DryRun bool `json:"-"`
+	Async  bool `json:"-"`
+}
+
+// ResolveGroupApplyContainersRequest is exported
+func ResolveGroupApplyContainersRequest(r *http.Request) (*GroupApplyContainersRequest, error) {
+
+	vars := mux.Vars(r)
+	groupid := strings.TrimSpace(vars["groupid"])
+	if len(groupid) == 0 {
+		return nil, fmt.Errorf("apply containers groupid invalid, can not be empty")
+	}
+
+	name := strings.TrimSpace(vars["name"])
+	if len(name) == 0 {
+		return nil, fmt.Errorf("apply containers name invalid, can not be empty")
+	}
+
+	buf, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &GroupApplyContainersRequest{}
+	if err := json.NewDecoder(bytes.NewReader(buf)).Decode(request); err != nil {
+		return nil, err
+	}
+
+	if request.Instances <= 0 {
+		return nil, fmt.Errorf("apply containers instances invalid, should be larger than 0")
+	}
+
+	if len(strings.TrimSpace(request.Config.Image)) == 0 {
+		return nil, fmt.Errorf("apply containers image can not be empty")
+	}
+
+	request.GroupID = groupid
+	request.Name = name
+	if value := strings.TrimSpace(r.URL.Query().Get("dryRun")); value != "" {
+		if request.DryRun, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("dryRun invalid, %s", err.Error())
+		}
+	}
+
+	if request.Async, err = parseQueryAsync(r); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+/*
+GroupContainersMaintenanceRequest is exported
+Method:  PUT
+Route:   /v1/groups/collections/{metaid}/maintenance
+Expires: optional, maintenance mode duration. eg: 30m, 2h
+*/
+type GroupContainersMaintenanceRequest struct {
+	MetaID  string        `json:"MetaId"`
+	Paused  bool          `json:"Paused"`
+	Expires string        `json:"Expires"`
+	Expired time.Duration `json:"-"`
+}
+
+// ResolveGroupContainersMaintenanceRequest is exported
+func ResolveGroupContainersMaintenanceRequest(r *http.Request) (*GroupContainersMaintenanceRequest, error) {
+
+	vars := mux.Vars(r)
+	metaid := strings.TrimSpace(vars["metaid"])
+	if len(strings.TrimSpace(metaid)) == 0 {
+		return nil, fmt.Errorf("maintenance containers metaid invalid, can not be empty")
+	}
+
+	buf, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &GroupContainersMaintenanceRequest{}
+	if err := json.NewDecoder(bytes.NewReader(buf)).Decode(request); err != nil {
+		return nil, err
+	}
+
+	request.MetaID = metaid
+	request.Expires = strings.TrimSpace(request.Expires)
+	if request.Expires != "" {
+		expired, err := time.ParseDuration(request.Expires)
+		if err != nil {
+			return nil, fmt.Errorf("maintenance containers expires invalid, %s", err.Error())
+		}
+		if expired <= 0 {
+			return nil, fmt.Errorf("maintenance containers expires invalid, should be larger than 0")
+		}
+		request.Expired = expired
+	}
 	return request, nil
 }
 
@@ -333,9 +885,11 @@ func ResolveGroupUpgradeContainersRequest(r *http.Request) (*GroupUpgradeContain
 GroupRemoveContainersRequest is exported
 Method:  DELETE
 Route:   /v1/groups/collections/{metaid}
+Query:   optional, async=true run as a background job.
 */
 type GroupRemoveContainersRequest struct {
 	MetaID string `json:"MetaId"`
+	Async  bool   `json:"-"`
 }
 
 // ResolveGroupRemoveContainersRequest is exported
@@ -346,8 +900,14 @@ func ResolveGroupRemoveContainersRequest(r *http.Request) (*GroupRemoveContainer
 	if len(strings.TrimSpace(metaid)) == 0 {
 		return nil, fmt.Errorf("remove containers metaid invalid, can not be empty")
 	}
+	async, err := parseQueryAsync(r)
+	if err != nil {
+		return nil, err
+	}
+
 	request := &GroupRemoveContainersRequest{
 		MetaID: metaid,
+		Async:  async,
 	}
 	return request, nil
 }
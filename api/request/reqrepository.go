@@ -0,0 +1,181 @@
+package request
+
+import "github.com/gorilla/mux"
+import "humpback-center/repository"
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+/*
+RepositoryImagesCatalogRequest is exported
+Method:  GET
+Route:   /v1/repository/images/catalog
+Query:   limit, cursor(NextCursor of previous page)
+*/
+type RepositoryImagesCatalogRequest struct {
+	Limit  int    `json:"Limit"`
+	Cursor string `json:"Cursor"`
+}
+
+// ResolveRepositoryImagesCatalogRequest is exported
+func ResolveRepositoryImagesCatalogRequest(r *http.Request) (*RepositoryImagesCatalogRequest, error) {
+
+	limit, cursor, err := parseQueryRegistryPage(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RepositoryImagesCatalogRequest{
+		Limit:  limit,
+		Cursor: cursor,
+	}, nil
+}
+
+/*
+RepositoryImageTagsRequest is exported
+Method:  GET
+Route:   /v1/repository/images/tags/{name}
+Query:   limit, cursor(NextCursor of previous page)
+*/
+type RepositoryImageTagsRequest struct {
+	Name   string `json:"Name"`
+	Limit  int    `json:"Limit"`
+	Cursor string `json:"Cursor"`
+}
+
+// ResolveRepositoryImageTagsRequest is exported
+func ResolveRepositoryImageTagsRequest(r *http.Request) (*RepositoryImageTagsRequest, error) {
+
+	vars := mux.Vars(r)
+	name := strings.Trim(strings.TrimSpace(vars["name"]), "/")
+	if len(name) == 0 {
+		return nil, fmt.Errorf("repository image tags name invalid, can not be empty")
+	}
+
+	limit, cursor, err := parseQueryRegistryPage(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RepositoryImageTagsRequest{
+		Name:   name,
+		Limit:  limit,
+		Cursor: cursor,
+	}, nil
+}
+
+/*
+RepositoryRemoveImageRequest is exported
+Method:  DELETE
+Route:   /v1/repository/images/{name}
+Name:    image name with tag or digest, eg: app/web:1.0, app/web@sha256:...
+*/
+type RepositoryRemoveImageRequest struct {
+	Name      string `json:"Name"`
+	Reference string `json:"Reference"`
+}
+
+// ResolveRepositoryRemoveImageRequest is exported
+func ResolveRepositoryRemoveImageRequest(r *http.Request) (*RepositoryRemoveImageRequest, error) {
+
+	vars := mux.Vars(r)
+	image := strings.Trim(strings.TrimSpace(vars["name"]), "/")
+	request := &RepositoryRemoveImageRequest{}
+	if nPos := strings.Index(image, "@"); nPos >= 0 {
+		request.Name, request.Reference = image[:nPos], image[nPos+1:]
+	} else if nPos := strings.LastIndex(image, ":"); nPos >= 0 && !strings.Contains(image[nPos+1:], "/") {
+		request.Name, request.Reference = image[:nPos], image[nPos+1:]
+	}
+
+	if len(request.Name) == 0 {
+		return nil, fmt.Errorf("remove repository image name invalid, can not be empty")
+	}
+
+	if len(request.Reference) == 0 {
+		return nil, fmt.Errorf("remove repository image tag or digest invalid, can not be empty")
+	}
+	return request, nil
+}
+
+/*
+RepositoryImagesMigrateRequest is exported
+Method:  POST
+Route:   /v1/repository/images/migrate
+Destination: optional, empty is the configured repository.
+Migrate is always a background job.
+*/
+type RepositoryImagesMigrateRequest struct {
+	Source      repository.Config         `json:"Source" required:"true"`
+	Destination *repository.Config        `json:"Destination"`
+	Images      []repository.MigrateImage `json:"Images" required:"true"`
+}
+
+// ResolveRepositoryImagesMigrateRequest is exported
+func ResolveRepositoryImagesMigrateRequest(r *http.Request) (*RepositoryImagesMigrateRequest, error) {
+
+	buf, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &RepositoryImagesMigrateRequest{}
+	if err := json.NewDecoder(bytes.NewReader(buf)).Decode(request); err != nil {
+		return nil, err
+	}
+
+	if len(strings.TrimSpace(request.Source.Host)) == 0 {
+		return nil, fmt.Errorf("migrate images source host invalid, can not be empty")
+	}
+
+	if request.Destination != nil && len(strings.TrimSpace(request.Destination.Host)) == 0 {
+		return nil, fmt.Errorf("migrate images destination host invalid, can not be empty")
+	}
+
+	if len(request.Images) == 0 {
+		return nil, fmt.Errorf("migrate images invalid, can not be empty")
+	}
+
+	for _, image := range request.Images {
+		if len(strings.TrimSpace(image.Name)) == 0 {
+			return nil, fmt.Errorf("migrate images name invalid, can not be empty")
+		}
+	}
+	return request, nil
+}
+
+// AuditParameters is exported
+// migrate audit parameters, registry credentials are not recorded.
+func (request *RepositoryImagesMigrateRequest) AuditParameters() interface{} {
+
+	destination := ""
+	if request.Destination != nil {
+		destination = request.Destination.Host
+	}
+
+	return map[string]interface{}{
+		"Source":      request.Source.Host,
+		"Destination": destination,
+		"Images":      request.Images,
+	}
+}
+
+// parseQueryRegistryPage, parse registry paging query, cursor is last name of previous page.
+func parseQueryRegistryPage(r *http.Request) (int, string, error) {
+
+	query := r.URL.Query()
+	limit := 0
+	if value := strings.TrimSpace(query.Get("limit")); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 0 {
+			return 0, "", fmt.Errorf("limit invalid, must be a positive integer")
+		}
+	}
+	return limit, strings.TrimSpace(query.Get("cursor")), nil
+}
@@ -8,10 +8,16 @@ const (
 	RequestSuccessed int = 0
 	RequestInvalid   int = -1001
 	RequestFailure   int = -1002
+	//authentication and authorization
+	RequestUnauthorized int = -1003
+	RequestForbidden    int = -1004
 )
 
 var (
 	ErrRequestSuccessed = errors.New("request successed")
 	ErrRequestInvalid   = errors.New("request resolve error")
 	ErrRequestFailure   = errors.New("request failure error")
+	//authentication and authorization
+	ErrRequestUnauthorized = errors.New("request unauthorized")
+	ErrRequestForbidden    = errors.New("request forbidden")
 )
@@ -11,14 +11,18 @@ Route:   /v1/groups/{groupid}/collections
 */
 type GroupAllContainersResponse struct {
 	GroupID    string                 `json:"GroupId"`
+	Total      int                    `json:"Total"`
+	NextCursor string                 `json:"NextCursor,omitempty"`
 	Containers *types.GroupContainers `json:"Containers"`
 }
 
 // NewGroupAllContainersResponse is exported
-func NewGroupAllContainersResponse(groupid string, containers *types.GroupContainers) *GroupAllContainersResponse {
+func NewGroupAllContainersResponse(groupid string, total int, nextCursor string, containers *types.GroupContainers) *GroupAllContainersResponse {
 
 	return &GroupAllContainersResponse{
 		GroupID:    groupid,
+		Total:      total,
+		NextCursor: nextCursor,
 		Containers: containers,
 	}
 }
@@ -29,24 +33,30 @@ Method:  GET
 Route:   /v1/groups/collections/{metaid}
 */
 type GroupContainersResponse struct {
-	Container *types.GroupContainer `json:"Container"`
+	Total      int                   `json:"Total"`
+	NextCursor string                `json:"NextCursor,omitempty"`
+	Container  *types.GroupContainer `json:"Container"`
 }
 
 // NewGroupContainersResponse is exported
-func NewGroupContainersResponse(container *types.GroupContainer) *GroupContainersResponse {
+func NewGroupContainersResponse(total int, nextCursor string, container *types.GroupContainer) *GroupContainersResponse {
 
 	return &GroupContainersResponse{
-		Container: container,
+		Total:      total,
+		NextCursor: nextCursor,
+		Container:  container,
 	}
 }
 
 // ContainersMetaBase is exported
 type ContainersMetaBase struct {
-	GroupID   string         `json:"GroupId"`
-	MetaID    string         `json:"MetaId"`
-	Instances int            `json:"Instances"`
-	WebHooks  types.WebHooks `json:"WebHooks"`
-	ImageTag  string         `json:"ImageTag"`
+	GroupID      string         `json:"GroupId"`
+	MetaID       string         `json:"MetaId"`
+	Instances    int            `json:"Instances"`
+	WebHooks     types.WebHooks `json:"WebHooks"`
+	ImageTag     string         `json:"ImageTag"`
+	Paused       bool           `json:"Paused"`
+	PauseExpires int64          `json:"PauseExpires"`
 	models.Container
 }
 
@@ -63,12 +73,14 @@ type GroupContainersMetaBaseResponse struct {
 func NewGroupContainersMetaBaseResponse(metaBase *cluster.MetaBase) *GroupContainersMetaBaseResponse {
 
 	containersMetaBase := &ContainersMetaBase{
-		GroupID:   metaBase.GroupID,
-		MetaID:    metaBase.MetaID,
-		Instances: metaBase.Instances,
-		WebHooks:  metaBase.WebHooks,
-		ImageTag:  metaBase.ImageTag,
-		Container: metaBase.Config,
+		GroupID:      metaBase.GroupID,
+		MetaID:       metaBase.MetaID,
+		Instances:    metaBase.Instances,
+		WebHooks:     metaBase.WebHooks,
+		ImageTag:     metaBase.ImageTag,
+		Paused:       metaBase.IsPaused(),
+		PauseExpires: metaBase.PauseExpires,
+		Container:    metaBase.Config,
 	}
 
 	return &GroupContainersMetaBaseResponse{
@@ -76,22 +88,43 @@ func NewGroupContainersMetaBaseResponse(metaBase *cluster.MetaBase) *GroupContai
 	}
 }
 
+/*
+GroupContainersStatsResponse is exported
+Method:  GET
+Route:   /v1/groups/collections/{metaid}/stats
+*/
+type GroupContainersStatsResponse struct {
+	Stats *cluster.MetaStats `json:"Stats"`
+}
+
+// NewGroupContainersStatsResponse is exported
+func NewGroupContainersStatsResponse(stats *cluster.MetaStats) *GroupContainersStatsResponse {
+
+	return &GroupContainersStatsResponse{
+		Stats: stats,
+	}
+}
+
 /*
 GroupEnginesResponse is exported
 Method:  GET
 Route:   /v1/groups/{groupid}/engines
 */
 type GroupEnginesResponse struct {
-	GroupID string            `json:"GroupId"`
-	Engines []*cluster.Engine `json:"Engines"`
+	GroupID    string            `json:"GroupId"`
+	Total      int               `json:"Total"`
+	NextCursor string            `json:"NextCursor,omitempty"`
+	Engines    []*cluster.Engine `json:"Engines"`
 }
 
 // NewGroupEnginesResponse is exported
-func NewGroupEnginesResponse(groupid string, engines []*cluster.Engine) *GroupEnginesResponse {
+func NewGroupEnginesResponse(groupid string, total int, nextCursor string, engines []*cluster.Engine) *GroupEnginesResponse {
 
 	return &GroupEnginesResponse{
-		GroupID: groupid,
-		Engines: engines,
+		GroupID:    groupid,
+		Total:      total,
+		NextCursor: nextCursor,
+		Engines:    engines,
 	}
 }
 
@@ -112,6 +145,160 @@ func NewGroupEngineResponse(engine *cluster.Engine) *GroupEngineResponse {
 	}
 }
 
+/*
+ClusterGroupsResponse is exported
+Method:  GET
+Route:   /v1/groups
+*/
+type ClusterGroupsResponse struct {
+	Groups []*cluster.GroupSummary `json:"Groups"`
+}
+
+// NewClusterGroupsResponse is exported
+func NewClusterGroupsResponse(groups []*cluster.GroupSummary) *ClusterGroupsResponse {
+
+	return &ClusterGroupsResponse{
+		Groups: groups,
+	}
+}
+
+/*
+ClusterEnginesResponse is exported
+Method:  GET
+Route:   /v1/engines
+*/
+type ClusterEnginesResponse struct {
+	Engines []*cluster.Engine `json:"Engines"`
+}
+
+// NewClusterEnginesResponse is exported
+func NewClusterEnginesResponse(engines []*cluster.Engine) *ClusterEnginesResponse {
+
+	return &ClusterEnginesResponse{
+		Engines: engines,
+	}
+}
+
+/*
+ClusterCapacityResponse is exported
+Method:  GET
+Route:   /v1/cluster/capacity
+*/
+type ClusterCapacityResponse struct {
+	Capacity *cluster.ClusterCapacity `json:"Capacity"`
+}
+
+// NewClusterCapacityResponse is exported
+func NewClusterCapacityResponse(capacity *cluster.ClusterCapacity) *ClusterCapacityResponse {
+
+	return &ClusterCapacityResponse{
+		Capacity: capacity,
+	}
+}
+
+/*
+ClusterRecoveryRunsResponse is exported
+Method:  GET
+Route:   /v1/cluster/recovery
+*/
+type ClusterRecoveryRunsResponse struct {
+	Runs []*cluster.RecoveryRun `json:"Runs"`
+}
+
+// NewClusterRecoveryRunsResponse is exported
+func NewClusterRecoveryRunsResponse(runs []*cluster.RecoveryRun) *ClusterRecoveryRunsResponse {
+
+	return &ClusterRecoveryRunsResponse{
+		Runs: runs,
+	}
+}
+
+/*
+ClusterRecoveryResponse is exported
+Method:  POST
+Route:   /v1/cluster/recovery
+*/
+type ClusterRecoveryResponse struct {
+	Run *cluster.RecoveryRun `json:"Run"`
+}
+
+// NewClusterRecoveryResponse is exported
+func NewClusterRecoveryResponse(run *cluster.RecoveryRun) *ClusterRecoveryResponse {
+
+	return &ClusterRecoveryResponse{
+		Run: run,
+	}
+}
+
+/*
+ClusterQuarantinedMetasResponse is exported
+Method:  GET
+Route:   /v1/cluster/metadata/quarantine
+*/
+type ClusterQuarantinedMetasResponse struct {
+	Metas []*cluster.QuarantinedMeta `json:"Metas"`
+}
+
+// NewClusterQuarantinedMetasResponse is exported
+func NewClusterQuarantinedMetasResponse(metas []*cluster.QuarantinedMeta) *ClusterQuarantinedMetasResponse {
+
+	return &ClusterQuarantinedMetasResponse{
+		Metas: metas,
+	}
+}
+
+/*
+ClusterMetaDataImportResponse is exported
+Method:  POST
+Route:   /v1/cluster/metadata/import
+*/
+type ClusterMetaDataImportResponse struct {
+	Result *cluster.MetaDataImportResult `json:"Result"`
+}
+
+// NewClusterMetaDataImportResponse is exported
+func NewClusterMetaDataImportResponse(result *cluster.MetaDataImportResult) *ClusterMetaDataImportResponse {
+
+	return &ClusterMetaDataImportResponse{
+		Result: result,
+	}
+}
+
+/*
+ClusterAuditResponse is exported
+Method:  GET
+Route:   /v1/audit
+*/
+type ClusterAuditResponse struct {
+	Records []*cluster.AuditRecord `json:"Records"`
+}
+
+// NewClusterAuditResponse is exported
+func NewClusterAuditResponse(records []*cluster.AuditRecord) *ClusterAuditResponse {
+
+	return &ClusterAuditResponse{
+		Records: records,
+	}
+}
+
+/*
+ClusterJobResponse is exported
+Method:  GET | DELETE
+Route:   /v1/jobs/{jobid}
+also the accepted response of async requests.
+*/
+type ClusterJobResponse struct {
+	Job *cluster.JobInfo `json:"Job"`
+}
+
+// NewClusterJobResponse is exported
+func NewClusterJobResponse(job *cluster.JobInfo) *ClusterJobResponse {
+
+	return &ClusterJobResponse{
+		Job: job,
+	}
+}
+
 /*
 GroupEventResponse is exported
 Method:  POST
@@ -211,18 +398,39 @@ Method:  PUT
 Route:   /v1/groups/collections/upgrade
 */
 type GroupUpgradeContainersResponse struct {
-	MetaID     string                   `json:"MetaId"`
-	Upgrade    string                   `json:"Upgrade"`
-	Containers *types.UpgradeContainers `json:"Containers"`
+	MetaID     string                     `json:"MetaId"`
+	Upgrade    string                     `json:"Upgrade"`
+	Containers *types.UpgradeContainers   `json:"Containers"`
+	Pulls      []*cluster.ImagePullResult `json:"Pulls,omitempty"`
 }
 
 // NewGroupUpgradeContainersResponse is exported
-func NewGroupUpgradeContainersResponse(metaid string, upgrade string, containers *types.UpgradeContainers) *GroupUpgradeContainersResponse {
+func NewGroupUpgradeContainersResponse(metaid string, upgrade string, containers *types.UpgradeContainers, pulls []*cluster.ImagePullResult) *GroupUpgradeContainersResponse {
 
 	return &GroupUpgradeContainersResponse{
 		MetaID:     metaid,
 		Upgrade:    upgrade,
 		Containers: containers,
+		Pulls:      pulls,
+	}
+}
+
+/*
+GroupApplyContainersResponse is exported
+Method:  PUT
+Route:   /v1/groups/{groupid}/collections/{name}/apply
+*/
+type GroupApplyContainersResponse struct {
+	Plan       *cluster.ApplyPlan    `json:"Plan"`
+	Containers *types.GroupContainer `json:"Containers,omitempty"`
+}
+
+// NewGroupApplyContainersResponse is exported
+func NewGroupApplyContainersResponse(plan *cluster.ApplyPlan, containers *types.GroupContainer) *GroupApplyContainersResponse {
+
+	return &GroupApplyContainersResponse{
+		Plan:       plan,
+		Containers: containers,
 	}
 }
 
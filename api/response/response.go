@@ -5,21 +5,33 @@ type Response interface {
 	SetResponse(data interface{})
 }
 
+/*
+字段校验错误
+Field: 字段路径, eg: Config.Env[0]
+Error: 错误描述
+*/
+type FieldError struct {
+	Field string `json:"Field"`
+	Error string `json:"Error"`
+}
+
 /*
 消息返回响应结构体
 Code: 响应码, == 0 成功, < 0 失败
 Error: 失败名称
 Content: 成功/失败描述
 ResponseID:
+Fields: 请求字段校验错误
 Data: 响应数据
 */
 type ResponseResult struct {
 	Response   `json:"-,omitempty"`
-	Code       int         `json:"Code"`
-	Error      string      `json:"Error"`
-	Content    string      `json:"Contnet"`
-	ResponseID string      `json:"ResponseID"`
-	Data       interface{} `json:"Data,omitpty"`
+	Code       int           `json:"Code"`
+	Error      string        `json:"Error"`
+	Content    string        `json:"Contnet"`
+	ResponseID string        `json:"ResponseID"`
+	Fields     []*FieldError `json:"Fields,omitempty"`
+	Data       interface{}   `json:"Data,omitpty"`
 }
 
 func (r *ResponseResult) SetError(code int, err error, content string) {
@@ -33,3 +45,8 @@ func (r *ResponseResult) SetResponse(data interface{}) {
 
 	r.Data = data
 }
+
+func (r *ResponseResult) SetFields(fields []*FieldError) {
+
+	r.Fields = fields
+}
@@ -0,0 +1,64 @@
+package response
+
+/*
+RepositoryImagesCatalogResponse is exported
+Method:  GET
+Route:   /v1/repository/images/catalog
+*/
+type RepositoryImagesCatalogResponse struct {
+	Registry     string   `json:"Registry"`
+	Repositories []string `json:"Repositories"`
+	NextCursor   string   `json:"NextCursor,omitempty"`
+}
+
+// NewRepositoryImagesCatalogResponse is exported
+func NewRepositoryImagesCatalogResponse(registry string, repositories []string, nextCursor string) *RepositoryImagesCatalogResponse {
+
+	return &RepositoryImagesCatalogResponse{
+		Registry:     registry,
+		Repositories: repositories,
+		NextCursor:   nextCursor,
+	}
+}
+
+/*
+RepositoryImageTagsResponse is exported
+Method:  GET
+Route:   /v1/repository/images/tags/{name}
+*/
+type RepositoryImageTagsResponse struct {
+	Name       string   `json:"Name"`
+	Tags       []string `json:"Tags"`
+	NextCursor string   `json:"NextCursor,omitempty"`
+}
+
+// NewRepositoryImageTagsResponse is exported
+func NewRepositoryImageTagsResponse(name string, tags []string, nextCursor string) *RepositoryImageTagsResponse {
+
+	return &RepositoryImageTagsResponse{
+		Name:       name,
+		Tags:       tags,
+		NextCursor: nextCursor,
+	}
+}
+
+/*
+RepositoryRemoveImageResponse is exported
+Method:  DELETE
+Route:   /v1/repository/images/{name}
+*/
+type RepositoryRemoveImageResponse struct {
+	Name      string `json:"Name"`
+	Reference string `json:"Reference"`
+	Digest    string `json:"Digest"`
+}
+
+// NewRepositoryRemoveImageResponse is exported
+func NewRepositoryRemoveImageResponse(name string, reference string, digest string) *RepositoryRemoveImageResponse {
+
+	return &RepositoryRemoveImageResponse{
+		Name:      name,
+		Reference: reference,
+		Digest:    digest,
+	}
+}
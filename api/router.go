@@ -5,36 +5,55 @@ import "humpback-center/ctrl"
 
 import (
 	"net/http"
+	"time"
 )
 
 type handler func(c *Context) error
 
 var routes = map[string]map[string]handler{
 	"GET": {
-		"/v1/_ping":                            ping,
-		"/v1/groups/{groupid}/collections":     getGroupAllContainers,
-		"/v1/groups/{groupid}/engines":         getGroupEngines,
-		"/v1/groups/collections/{metaid}":      getGroupContainers,
-		"/v1/groups/collections/{metaid}/base": getGroupContainersMetaBase,
-		"/v1/groups/engines/{server}":          getGroupEngine,
-		"/v1/repository/images/catalog":        getRepositoryImagesCatalog,
-		"/v1/repository/images/tags/*":         getRepositoryImagesTags,
+		"/v1/_ping":                               ping,
+		"/metrics":                                getMetrics,
+		"/v1/groups":                              getClusterGroups,
+		"/v1/engines":                             getClusterEngines,
+		"/v1/groups/{groupid}/collections":        getGroupAllContainers,
+		"/v1/groups/{groupid}/engines":            getGroupEngines,
+		"/v1/groups/collections/{metaid}":         getGroupContainers,
+		"/v1/groups/collections/{metaid}/base":    getGroupContainersMetaBase,
+		"/v1/groups/engines/{server}":             getGroupEngine,
+		"/v1/groups/collections/{metaid}/stats":   getGroupContainersStats,
+		"/v1/groups/collections/{metaid}/logs":    getGroupContainersLogs,
+		"/v1/groups/container/{containerid}/logs": getGroupContainerLogs,
+		"/v1/cluster/capacity":                    getClusterCapacity,
+		"/v1/cluster/recovery":                    getClusterRecoveryRuns,
+		"/v1/cluster/metadata/quarantine":         getClusterQuarantinedMetas,
+		"/v1/cluster/metadata/export":             getClusterMetaDataExport,
+		"/v1/audit":                               getClusterAudit,
+		"/v1/events":                              getClusterEvents,
+		"/v1/jobs/{jobid}":                        getClusterJob,
+		"/v1/repository/images/catalog":           getRepositoryImagesCatalog,
+		"/v1/repository/images/tags/{name:.*}":    getRepositoryImagesTags,
 	},
 	"POST": {
 		"/v1/groups/event":              postGroupEvent,
 		"/v1/groups/collections":        postGroupCreateContainers,
+		"/v1/cluster/recovery":          postClusterRecovery,
+		"/v1/cluster/metadata/import":   postClusterMetaDataImport,
 		"/v1/repository/images/migrate": postRepositoryImagesMigrate,
 	},
 	"PUT": {
-		"/v1/groups/collections":         putGroupUpdateContainers,
-		"/v1/groups/collections/upgrade": putGroupUpgradeContainers,
-		"/v1/groups/collections/action":  putGroupOperateContainers,
-		"/v1/groups/container/action":    putGroupOperateContainer,
+		"/v1/groups/collections":                        putGroupUpdateContainers,
+		"/v1/groups/collections/upgrade":                putGroupUpgradeContainers,
+		"/v1/groups/collections/action":                 putGroupOperateContainers,
+		"/v1/groups/collections/{metaid}/maintenance":   putGroupContainersMaintenance,
+		"/v1/groups/{groupid}/collections/{name}/apply": putGroupApplyContainers,
+		"/v1/groups/container/action":                   putGroupOperateContainer,
 	},
 	"DELETE": {
 		"/v1/groups/collections/{metaid}":    deleteGroupRemoveContainers,
 		"/v1/groups/container/{containerid}": deleteGroupRemoveContainer,
 		"/v1/repository/images/{name:.*}":    deleteRepositoryImages,
+		"/v1/jobs/{jobid}":                   deleteClusterJob,
 	},
 }
 
@@ -46,11 +65,27 @@ func NewRouter(controller *ctrl.Controller, enableCors bool) *mux.Router {
 			routemethod := method
 			routepattern := route
 			routehandler := handler
+			if routemethod != "GET" {
+				routehandler = idempotent(handler)
+			}
 			wrap := func(w http.ResponseWriter, r *http.Request) {
 				if enableCors {
 					writeCorsHeaders(w, r)
 				}
 				c := NewContext(w, r, controller)
+				defer observeRequest(c, routemethod, routepattern, time.Now())
+				if routemethod != "GET" && rejectDraining(c) {
+					return
+				}
+				if (routemethod != "GET" && !leaderLocalRoutes[routepattern] || leaderRoutes[routepattern] && !isLocalJob(c)) && forwardLeader(c) {
+					return
+				}
+				if !anyGroupRoutes[routepattern] && authorizeGroups(c) {
+					return
+				}
+				if validateBody(c, routemethod, routepattern) {
+					return
+				}
 				routehandler(c)
 			}
 			router.Path(routepattern).Methods(routemethod).HandlerFunc(wrap)
@@ -0,0 +1,273 @@
+package api
+
+import "humpback-center/api/response"
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Schema is exported
+// OpenAPI 3 schema object subset, request bodies are validated against it.
+// AdditionalProperties: false for struct objects, a *Schema for map objects.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	durationType  = reflect.TypeOf(time.Duration(0))
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textType      = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schemaBuilder, build schemas of go types by reflection, named structs are components.
+type schemaBuilder struct {
+	schemas map[string]*Schema
+}
+
+func newSchemaBuilder() *schemaBuilder {
+
+	return &schemaBuilder{
+		schemas: map[string]*Schema{},
+	}
+}
+
+// schemaName, component name of a named type, eg: cluster.Engine
+func schemaName(t reflect.Type) string {
+
+	pkgPath := t.PkgPath()
+	if index := strings.LastIndex(pkgPath, "/"); index >= 0 {
+		pkgPath = pkgPath[index+1:]
+	}
+	return pkgPath + "." + t.Name()
+}
+
+func (builder *schemaBuilder) schemaOf(t reflect.Type) *Schema {
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &Schema{Type: "integer", Format: "int64"}
+	}
+
+	if t.Kind() != reflect.Struct && (t.Implements(marshalerType) || reflect.PtrTo(t).Implements(marshalerType) ||
+		t.Implements(textType) || reflect.PtrTo(t).Implements(textType)) {
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: builder.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: builder.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return builder.structSchema(t)
+		}
+		name := schemaName(t)
+		if _, ret := builder.schemas[name]; !ret {
+			builder.schemas[name] = &Schema{} // placeholder of recursive types.
+			builder.schemas[name] = builder.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	return &Schema{}
+}
+
+// structSchema, struct fields as encoding/json, embedded struct fields are promoted.
+func (builder *schemaBuilder) structSchema(t reflect.Type) *Schema {
+
+	schema := &Schema{
+		Type:                 "object",
+		Properties:           map[string]*Schema{},
+		AdditionalProperties: false,
+	}
+	builder.appendFields(schema, t)
+	sort.Strings(schema.Required)
+	return schema
+}
+
+func (builder *schemaBuilder) appendFields(schema *Schema, t reflect.Type) {
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		if field.Anonymous && name == "" {
+			if fieldType.Kind() == reflect.Struct {
+				builder.appendFields(schema, fieldType)
+			}
+			continue
+		}
+
+		if field.PkgPath != "" || name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = builder.schemaOf(field.Type)
+		if field.Tag.Get("required") == "true" {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// resolve, returns component schema of a reference schema.
+func (builder *schemaBuilder) resolve(schema *Schema) *Schema {
+
+	for schema != nil && schema.Ref != "" {
+		schema = builder.schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+	}
+	return schema
+}
+
+// validate, validate a json decoded value (decoded with UseNumber) against schema, returns field errors.
+func (builder *schemaBuilder) validate(schema *Schema, value interface{}, path string) []*response.FieldError {
+
+	schema = builder.resolve(schema)
+	if schema == nil || schema.Type == "" || value == nil {
+		return nil
+	}
+
+	fieldErrors := []*response.FieldError{}
+	invalidType := func() []*response.FieldError {
+		return append(fieldErrors, &response.FieldError{Field: path, Error: fmt.Sprintf("invalid type, expected %s", schema.Type)})
+	}
+
+	switch schema.Type {
+	case "boolean":
+		if _, ret := value.(bool); !ret {
+			return invalidType()
+		}
+	case "string":
+		if _, ret := value.(string); !ret {
+			return invalidType()
+		}
+	case "integer":
+		number, ret := value.(json.Number)
+		if !ret {
+			return invalidType()
+		}
+		if _, err := number.Int64(); err != nil {
+			return invalidType()
+		}
+	case "number":
+		if _, ret := value.(json.Number); !ret {
+			return invalidType()
+		}
+	case "array":
+		values, ret := value.([]interface{})
+		if !ret {
+			return invalidType()
+		}
+		for i, item := range values {
+			fieldErrors = append(fieldErrors, builder.validate(schema.Items, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case "object":
+		values, ret := value.(map[string]interface{})
+		if !ret {
+			return invalidType()
+		}
+		fieldErrors = append(fieldErrors, builder.validateObject(schema, values, path)...)
+	}
+	return fieldErrors
+}
+
+// validateObject, property names match case-insensitively as encoding/json.
+func (builder *schemaBuilder) validateObject(schema *Schema, values map[string]interface{}, path string) []*response.FieldError {
+
+	fieldErrors := []*response.FieldError{}
+	fieldPath := func(name string) string {
+		if path == "" {
+			return name
+		}
+		return path + "." + name
+	}
+
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	present := map[string]bool{}
+	for _, key := range keys {
+		name, propSchema := schemaProperty(schema, key)
+		if propSchema == nil {
+			if additional, ret := schema.AdditionalProperties.(*Schema); ret {
+				fieldErrors = append(fieldErrors, builder.validate(additional, values[key], fieldPath(key))...)
+			} else if schema.AdditionalProperties == false {
+				fieldErrors = append(fieldErrors, &response.FieldError{Field: fieldPath(key), Error: "unknown field"})
+			}
+			continue
+		}
+		if values[key] != nil {
+			present[name] = true
+		}
+		fieldErrors = append(fieldErrors, builder.validate(propSchema, values[key], fieldPath(name))...)
+	}
+
+	for _, name := range schema.Required {
+		if !present[name] {
+			fieldErrors = append(fieldErrors, &response.FieldError{Field: fieldPath(name), Error: "required"})
+		}
+	}
+	return fieldErrors
+}
+
+// schemaProperty, exact property name first, else case-insensitive.
+func schemaProperty(schema *Schema, key string) (string, *Schema) {
+
+	if propSchema, ret := schema.Properties[key]; ret {
+		return key, propSchema
+	}
+
+	for name, propSchema := range schema.Properties {
+		if strings.EqualFold(name, key) {
+			return name, propSchema
+		}
+	}
+	return "", nil
+}
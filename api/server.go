@@ -4,15 +4,18 @@ import "humpback-center/api/middleware"
 import "humpback-center/ctrl"
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
 	"strings"
+	"sync"
 )
 
 type Dispatcher struct {
-	handler http.Handler
+	handler       http.Handler
+	authenticator *middleware.Authenticator
 }
 
 func (dispatcher *Dispatcher) SetHandler(handler http.Handler) {
@@ -26,24 +29,31 @@ func (dispatcher *Dispatcher) ServeHTTP(w http.ResponseWriter, r *http.Request)
 		httpError(w, "API Dispatcher Invalid.", http.StatusInternalServerError)
 		return
 	}
-	handler := middleware.Logger(dispatcher.handler)
+	handler := dispatcher.handler
+	if dispatcher.authenticator != nil {
+		handler = dispatcher.authenticator.Handler(handler)
+	}
+	handler = middleware.Logger(handler)
 	handler.ServeHTTP(w, r)
 }
 
 type Server struct {
-	hosts      []string
-	tlsConfig  *tls.Config
-	dispatcher *Dispatcher
+	sync.Mutex
+	hosts       []string
+	tlsConfig   *tls.Config
+	dispatcher  *Dispatcher
+	httpServers []*http.Server
 }
 
-func NewServer(hosts []string, tlsConfig *tls.Config, controller *ctrl.Controller, enablecors bool) *Server {
+func NewServer(hosts []string, tlsConfig *tls.Config, authenticator *middleware.Authenticator, controller *ctrl.Controller, enablecors bool) *Server {
 
 	router := NewRouter(controller, enablecors)
 	return &Server{
 		hosts:     hosts,
 		tlsConfig: tlsConfig,
 		dispatcher: &Dispatcher{
-			handler: router,
+			handler:       router,
+			authenticator: authenticator,
 		},
 	}
 }
@@ -67,14 +77,18 @@ func (server *Server) Startup() error {
 			protoAddrParts = append([]string{"tcp"}, protoAddrParts...)
 		}
 
+		s := &http.Server{
+			Addr:    protoAddrParts[1],
+			Handler: server.dispatcher,
+		}
+		server.Lock()
+		server.httpServers = append(server.httpServers, s)
+		server.Unlock()
+
 		go func() {
 			var (
 				err error
 				l   net.Listener
-				s   = http.Server{
-					Addr:    protoAddrParts[1],
-					Handler: server.dispatcher,
-				}
 			)
 
 			switch protoAddrParts[0] {
@@ -87,8 +101,10 @@ func (server *Server) Startup() error {
 			}
 			if err != nil {
 				errorsCh <- err
+			} else if err = s.Serve(l); err == http.ErrServerClosed {
+				errorsCh <- nil
 			} else {
-				errorsCh <- s.Serve(l)
+				errorsCh <- err
 			}
 		}()
 	}
@@ -102,6 +118,23 @@ func (server *Server) Startup() error {
 	return nil
 }
 
+// Shutdown gracefully shutdown all listeners, wait active requests done or ctx done.
+func (server *Server) Shutdown(ctx context.Context) error {
+
+	server.Lock()
+	httpServers := server.httpServers
+	server.httpServers = nil
+	server.Unlock()
+
+	var err error
+	for _, s := range httpServers {
+		if e := s.Shutdown(ctx); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
 func newListener(proto string, addr string, tlsConfig *tls.Config) (net.Listener, error) {
 
 	l, err := net.Listen(proto, addr)
@@ -0,0 +1,237 @@
+package api
+
+import "github.com/humpback/gounits/logger"
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tls minimum versions
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+/*
+TLSOptions is exported
+CertFile & KeyFile: server certificate and private key, tls is disabled if CertFile is empty.
+ClientCAFile: client certificates ca bundle.
+VerifyClient: require and verify client certificate signed by ClientCAFile.
+MinVersion: tls minimum version 1.0, 1.1, 1.2 or 1.3, default 1.2.
+*/
+type TLSOptions struct {
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string
+	VerifyClient bool
+	MinVersion   string
+}
+
+// tlsLoader, reload certificate and client ca files when files modified, checked at handshake.
+type tlsLoader struct {
+	sync.RWMutex
+	options    TLSOptions
+	minVersion uint16
+	modTimes   map[string]time.Time
+	config     *tls.Config
+}
+
+// NewTLSConfig is exported
+// return nil if options CertFile is empty.
+// certificate and client ca are reloaded without restart after files changed.
+func NewTLSConfig(options TLSOptions) (*tls.Config, error) {
+
+	loader, err := newTLSLoader(options)
+	if loader == nil || err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		MinVersion:         loader.minVersion,
+		GetConfigForClient: loader.getConfigForClient,
+	}, nil
+}
+
+// NewLeaderTransport is exported
+// transport of follower requests to leader, return nil if options CertFile is empty.
+// center certificate is the client certificate of mutual tls, reloaded after files changed,
+// leader certificate is verified by system roots and client ca.
+func NewLeaderTransport(options TLSOptions) (*http.Transport, error) {
+
+	loader, err := newTLSLoader(options)
+	if loader == nil || err != nil {
+		return nil, err
+	}
+
+	rootCAs, err := x509.SystemCertPool()
+	if err != nil {
+		rootCAs = x509.NewCertPool()
+	}
+
+	if options.ClientCAFile != "" {
+		data, err := ioutil.ReadFile(options.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		rootCAs.AppendCertsFromPEM(data)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		MinVersion:           loader.minVersion,
+		RootCAs:              rootCAs,
+		GetClientCertificate: loader.getClientCertificate,
+	}
+	return transport, nil
+}
+
+// newTLSLoader, validate options and load files, return nil if options CertFile is empty.
+func newTLSLoader(options TLSOptions) (*tlsLoader, error) {
+
+	if strings.TrimSpace(options.CertFile) == "" {
+		return nil, nil
+	}
+
+	if options.KeyFile == "" {
+		return nil, fmt.Errorf("tls key file required")
+	}
+
+	if options.VerifyClient && options.ClientCAFile == "" {
+		return nil, fmt.Errorf("tls client ca file required when verify client")
+	}
+
+	if options.MinVersion == "" {
+		options.MinVersion = "1.2"
+	}
+
+	minVersion, ret := tlsVersions[options.MinVersion]
+	if !ret {
+		return nil, fmt.Errorf("tls min version %s invalid", options.MinVersion)
+	}
+
+	loader := &tlsLoader{
+		options:    options,
+		minVersion: minVersion,
+		modTimes:   map[string]time.Time{},
+	}
+
+	if err := loader.load(); err != nil {
+		return nil, err
+	}
+	return loader, nil
+}
+
+func (loader *tlsLoader) getConfigForClient(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+
+	if loader.modified() {
+		if err := loader.load(); err != nil {
+			logger.ERROR("[#api#] tls reload error, %s, continue using previous certificate.", err.Error())
+		} else {
+			logger.INFO("[#api#] tls certificate reloaded.")
+		}
+	}
+
+	loader.RLock()
+	defer loader.RUnlock()
+	return loader.config, nil
+}
+
+func (loader *tlsLoader) getClientCertificate(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+
+	if loader.modified() {
+		if err := loader.load(); err != nil {
+			logger.ERROR("[#api#] tls reload error, %s, continue using previous certificate.", err.Error())
+		}
+	}
+
+	loader.RLock()
+	defer loader.RUnlock()
+	return &loader.config.Certificates[0], nil
+}
+
+// files return watched cert, key and client ca files.
+func (loader *tlsLoader) files() []string {
+
+	files := []string{loader.options.CertFile, loader.options.KeyFile}
+	if loader.options.ClientCAFile != "" {
+		files = append(files, loader.options.ClientCAFile)
+	}
+	return files
+}
+
+// modified return true if any watched file modify time changed.
+func (loader *tlsLoader) modified() bool {
+
+	loader.RLock()
+	defer loader.RUnlock()
+	for _, file := range loader.files() {
+		fi, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		if !fi.ModTime().Equal(loader.modTimes[file]) {
+			return true
+		}
+	}
+	return false
+}
+
+// load read certificate and client ca, build a new tls config.
+// modify times are recorded before read, a failed load keeps previous config until files modified again.
+func (loader *tlsLoader) load() error {
+
+	modTimes := map[string]time.Time{}
+	for _, file := range loader.files() {
+		fi, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		modTimes[file] = fi.ModTime()
+	}
+
+	loader.Lock()
+	loader.modTimes = modTimes
+	loader.Unlock()
+
+	certificate, err := tls.LoadX509KeyPair(loader.options.CertFile, loader.options.KeyFile)
+	if err != nil {
+		return err
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   loader.minVersion,
+		NextProtos:   []string{"http/1.1"},
+	}
+
+	if loader.options.ClientCAFile != "" {
+		data, err := ioutil.ReadFile(loader.options.ClientCAFile)
+		if err != nil {
+			return err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return fmt.Errorf("tls client ca file %s invalid", loader.options.ClientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+		if loader.options.VerifyClient {
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	loader.Lock()
+	loader.config = config
+	loader.Unlock()
+	return nil
+}
@@ -1,6 +1,12 @@
 package api
 
-import "net/http"
+import "humpback-center/api/request"
+import "humpback-center/api/response"
+import "humpback-center/cluster"
+
+import (
+	"net/http"
+)
 
 func httpError(w http.ResponseWriter, err string, code int) {
 	http.Error(w, err, code)
@@ -8,6 +14,46 @@ func httpError(w http.ResponseWriter, err string, code int) {
 
 func writeCorsHeaders(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("Access-Control-Allow-Origin", "*")
-	w.Header().Add("Access-Control-Allow-Headers", "Origin, X-Requested-With, Content-Type, Accept")
+	w.Header().Add("Access-Control-Allow-Headers", "Origin, X-Requested-With, Content-Type, Accept, Idempotency-Key")
 	w.Header().Add("Access-Control-Allow-Methods", "GET, POST, DELETE, PUT, OPTIONS, HEAD")
 }
+
+// newAuditRecord, create an operation audit record of request, metaid is not empty resolve the meta groupid.
+func newAuditRecord(c *Context, operation string, groupid string, metaid string, parameters interface{}) *cluster.AuditRecord {
+
+	if groupid == "" && metaid != "" {
+		if metaBase := c.Controller.GetClusterGroupContainersMetaBase(metaid); metaBase != nil {
+			groupid = metaBase.GroupID
+		}
+	}
+
+	return &cluster.AuditRecord{
+		RequestID:  c.ID,
+		Caller:     c.Caller(),
+		Operation:  operation,
+		GroupID:    groupid,
+		MetaID:     metaid,
+		Parameters: parameters,
+	}
+}
+
+// writeAudit, set audit record result and write.
+func writeAudit(c *Context, record *cluster.AuditRecord, containers interface{}, err error) {
+
+	record.SetResult(containers, err)
+	c.Controller.WriteClusterAudit(record)
+}
+
+// rejectDraining, center is shutting down, reject mutating request.
+// return true if request is rejected.
+func rejectDraining(c *Context) bool {
+
+	if !c.Controller.IsClusterDraining() {
+		return false
+	}
+
+	result := &response.ResponseResult{ResponseID: c.ID}
+	result.SetError(request.RequestFailure, request.ErrRequestFailure, cluster.ErrClusterDraining.Error())
+	c.JSON(http.StatusServiceUnavailable, result)
+	return true
+}
@@ -0,0 +1,384 @@
+package cluster
+
+import "github.com/humpback/gounits/logger"
+import "humpback-center/cluster/types"
+import "common/models"
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// apply plan step actions
+const (
+	ApplyActionNone     = "none"
+	ApplyActionCreate   = "create"
+	ApplyActionScale    = "scale"
+	ApplyActionWebHooks = "webhooks"
+	ApplyActionUpgrade  = "upgrade"
+	ApplyActionRoll     = "rollconfig"
+)
+
+/*
+ApplySpec is exported
+desired state of a meta, the meta is found by group and config name.
+ImageTag: optional, overrides tag of Config.Image.
+*/
+type ApplySpec struct {
+	Instances int              `json:"Instances" required:"true"`
+	WebHooks  types.WebHooks   `json:"WebHooks"`
+	ImageTag  string           `json:"ImageTag"`
+	Config    models.Container `json:"Config" required:"true"`
+}
+
+// ApplyStep is exported
+// Done: step is executed successfully, a failure apply returns plan of done steps.
+type ApplyStep struct {
+	Action string `json:"Action"`
+	Detail string `json:"Detail"`
+	Done   bool   `json:"Done"`
+}
+
+// ApplyPlan is exported
+// MetaID: empty when meta is not exists and dryrun.
+// Executed: plan steps are executed, false when dryrun or nothing to do.
+type ApplyPlan struct {
+	GroupID  string       `json:"GroupId"`
+	Name     string       `json:"Name"`
+	MetaID   string       `json:"MetaId"`
+	DryRun   bool         `json:"DryRun"`
+	Executed bool         `json:"Executed"`
+	Steps    []*ApplyStep `json:"Steps"`
+	config   models.Container
+}
+
+func (plan *ApplyPlan) addStep(action string, format string, args ...interface{}) {
+
+	plan.Steps = append(plan.Steps, &ApplyStep{Action: action, Detail: fmt.Sprintf(format, args...)})
+}
+
+func (plan *ApplyPlan) doneSteps(actions ...string) {
+
+	for _, step := range plan.Steps {
+		for _, action := range actions {
+			if step.Action == action {
+				step.Done = true
+			}
+		}
+	}
+}
+
+func (plan *ApplyPlan) hasStep(action string) bool {
+
+	for _, step := range plan.Steps {
+		if step.Action == action {
+			return true
+		}
+	}
+	return false
+}
+
+// splitImageTag, returns image repository and tag, tag is latest if not set.
+func splitImageTag(image string) (string, string) {
+
+	nPos := strings.LastIndex(image, ":")
+	if nPos == -1 || strings.Contains(image[nPos+1:], "/") {
+		return image, "latest"
+	}
+	return image[:nPos], image[nPos+1:]
+}
+
+// normalizeJSON, json form of value, null and zero values are dropped.
+// a desired state without default fields equals to the stored state.
+func normalizeJSON(value interface{}) interface{} {
+
+	var out interface{}
+	buf, err := json.Marshal(value)
+	if err != nil || json.Unmarshal(buf, &out) != nil {
+		return nil
+	}
+	return dropZeroJSON(out)
+}
+
+func dropZeroJSON(value interface{}) interface{} {
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if item = dropZeroJSON(item); item == nil {
+				delete(v, key)
+			} else {
+				v[key] = item
+			}
+		}
+		if len(v) == 0 {
+			return nil
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+	case string:
+		if v == "" {
+			return nil
+		}
+	case bool:
+		if !v {
+			return nil
+		}
+	case float64:
+		if v == 0 {
+			return nil
+		}
+	}
+	return value
+}
+
+// configChangedFields, returns sorted changed fields of container config, name and image are ignored.
+func configChangedFields(current models.Container, desired models.Container) []string {
+
+	current.Name, current.Image = "", ""
+	desired.Name, desired.Image = "", ""
+	currentFields, _ := normalizeJSON(current).(map[string]interface{})
+	desiredFields, _ := normalizeJSON(desired).(map[string]interface{})
+	keys := map[string]bool{}
+	for key := range currentFields {
+		keys[key] = true
+	}
+	for key := range desiredFields {
+		keys[key] = true
+	}
+
+	fields := []string{}
+	for key := range keys {
+		if !reflect.DeepEqual(currentFields[key], desiredFields[key]) {
+			fields = append(fields, key)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// PlanApply is exported
+// diff desired state against the existing meta of name, returns the steps to converge.
+func (cluster *Cluster) PlanApply(groupid string, name string, spec *ApplySpec) (*ApplyPlan, error) {
+
+	if spec.Instances <= 0 {
+		return nil, ErrClusterContainersInstancesInvalid
+	}
+
+	if spec.Config.Name != "" && spec.Config.Name != name {
+		return nil, fmt.Errorf("apply config name %s is not match %s", spec.Config.Name, name)
+	}
+
+	if group := cluster.GetGroup(groupid); group == nil {
+		return nil, ErrClusterGroupNotFound
+	}
+
+	config := spec.Config
+	config.Name = name
+	desiredRepo, desiredTag := splitImageTag(config.Image)
+	if spec.ImageTag != "" {
+		desiredTag = spec.ImageTag
+	}
+	config.Image = desiredRepo + ":" + desiredTag
+
+	plan := &ApplyPlan{
+		GroupID: groupid,
+		Name:    name,
+		Steps:   []*ApplyStep{},
+		config:  config,
+	}
+
+	metaData := cluster.configCache.GetMetaDataOfName(groupid, name)
+	if metaData == nil {
+		plan.addStep(ApplyActionCreate, "create %d instances of %s", spec.Instances, config.Image)
+		return plan, nil
+	}
+
+	plan.MetaID = metaData.MetaID
+	currentRepo, currentTag := splitImageTag(metaData.Config.Image)
+	changedFields := configChangedFields(metaData.Config, config)
+	if currentRepo != desiredRepo {
+		changedFields = append([]string{"Image"}, changedFields...)
+	}
+
+	if len(changedFields) > 0 {
+		plan.addStep(ApplyActionRoll, "roll %d containers to %s, changed %s", len(metaData.BaseConfigs), config.Image, strings.Join(changedFields, ","))
+	} else if currentTag != desiredTag {
+		plan.addStep(ApplyActionUpgrade, "upgrade image tag %s to %s", currentTag, desiredTag)
+	}
+
+	if metaData.Instances != spec.Instances {
+		plan.addStep(ApplyActionScale, "scale instances %d to %d", metaData.Instances, spec.Instances)
+	}
+
+	if !reflect.DeepEqual(normalizeJSON(metaData.WebHooks), normalizeJSON(spec.WebHooks)) {
+		plan.addStep(ApplyActionWebHooks, "update %d webhooks to %d", len(metaData.WebHooks), len(spec.WebHooks))
+	}
+
+	if len(plan.Steps) == 0 {
+		plan.addStep(ApplyActionNone, "meta is up to date")
+	}
+	return plan, nil
+}
+
+/*
+ApplyMeta is exported
+plan and execute desired state of meta, dryrun returns plan only.
+scaling down is executed before rolling config, so removed instances are not rolled.
+a step failure stops apply, returns the plan of done steps and the error.
+*/
+func (cluster *Cluster) ApplyMeta(groupid string, name string, spec *ApplySpec, dryRun bool, job *Job) (*ApplyPlan, error) {
+
+	plan, err := cluster.PlanApply(groupid, name, spec)
+	if err != nil {
+		logger.ERROR("[#cluster#] apply %s %s error, %s", groupid, name, err.Error())
+		return nil, err
+	}
+
+	plan.DryRun = dryRun
+	if dryRun || plan.hasStep(ApplyActionNone) {
+		return plan, nil
+	}
+
+	if cluster.IsDraining() {
+		return nil, ErrClusterDraining
+	}
+
+	logger.INFO("[#cluster#] apply %s %s, %d steps.", groupid, name, len(plan.Steps))
+	plan.Executed = true
+	if plan.hasStep(ApplyActionCreate) {
+		metaid, _, err := cluster.CreateContainers(groupid, spec.Instances, spec.WebHooks, plan.config, job)
+		plan.MetaID = metaid
+		if err == nil {
+			plan.doneSteps(ApplyActionCreate)
+		}
+		return plan, err
+	}
+
+	job.SetMetaID(plan.MetaID)
+	updated := false
+	update := plan.hasStep(ApplyActionScale) || plan.hasStep(ApplyActionWebHooks)
+	if metaData := cluster.configCache.GetMetaData(plan.MetaID); update && metaData != nil && spec.Instances < metaData.Instances {
+		if _, err := cluster.UpdateContainers(plan.MetaID, spec.Instances, spec.WebHooks); err != nil {
+			return plan, err
+		}
+		plan.doneSteps(ApplyActionScale, ApplyActionWebHooks)
+		updated = true
+	}
+
+	if plan.hasStep(ApplyActionRoll) {
+		if err := cluster.RollContainers(plan.MetaID, plan.config, job); err != nil {
+			return plan, err
+		}
+		plan.doneSteps(ApplyActionRoll)
+	}
+
+	if plan.hasStep(ApplyActionUpgrade) {
+		_, desiredTag := splitImageTag(plan.config.Image)
+		if _, _, err := cluster.UpgradeContainers(plan.MetaID, desiredTag, false, job); err != nil {
+			return plan, err
+		}
+		plan.doneSteps(ApplyActionUpgrade)
+	}
+
+	if update && !updated {
+		if _, err := cluster.UpdateContainers(plan.MetaID, spec.Instances, spec.WebHooks); err != nil {
+			return plan, err
+		}
+		plan.doneSteps(ApplyActionScale, ApplyActionWebHooks)
+	}
+	return plan, nil
+}
+
+// RollContainers is exported
+// replace meta containers one by one with the new config, meta config is set after all containers are rolled.
+// job is canceled or a container roll failure, meta keeps the original config, not rolled containers keep the original config.
+func (cluster *Cluster) RollContainers(metaid string, config models.Container, job *Job) error {
+
+	metaData, engines, err := cluster.validateMetaData(metaid)
+	if err != nil {
+		logger.ERROR("[#cluster#] roll containers %s error, %s", metaid, err.Error())
+		return err
+	}
+
+	type rollContainer struct {
+		engine    *Engine
+		container *Container
+	}
+
+	rollContainers := []*rollContainer{}
+	for _, engine := range engines {
+		for _, container := range engine.Containers(metaData.MetaID) {
+			rollContainers = append(rollContainers, &rollContainer{engine: engine, container: container})
+		}
+	}
+
+	cluster.Lock()
+	cluster.pendingContainers[config.Name] = &pendingContainer{
+		GroupID: metaData.GroupID,
+		Name:    config.Name,
+		Config:  config,
+	}
+	cluster.Unlock()
+
+	var resultErr error
+	filter := NewEnginesFilter()
+	completed, total := 0, len(rollContainers)
+	for _, roll := range rollContainers {
+		job.SetProgress(completed, total)
+		if job.IsCanceled() {
+			resultErr = ErrClusterJobCanceled
+			break
+		}
+
+		if !roll.engine.IsHealthy() {
+			resultErr = fmt.Errorf("engine %s state is %s", roll.engine.IP, roll.engine.State())
+			continue
+		}
+
+		if err := roll.engine.RemoveContainer(roll.container.Info.ID); err != nil {
+			logger.ERROR("[#cluster#] engine %s, roll remove container error:%s", roll.engine.IP, err.Error())
+			resultErr = err
+			continue
+		}
+
+		index := cluster.configCache.MakeContainerIdleIndex(metaData.MetaID)
+		if index < 0 {
+			continue
+		}
+
+		containerConfig := newContainerConfig(metaData, config, index)
+		_, _, err := cluster.createContainer(metaData, filter, containerConfig)
+		var retries int64
+		for ; retries < cluster.createRetry && err != nil; retries++ {
+			_, _, err = cluster.createContainer(metaData, filter, containerConfig)
+		}
+		if err != nil {
+			logger.ERROR("[#cluster#] roll create container %s, error:%s", containerConfig.Name, err.Error())
+			resultErr = err
+		}
+		completed++
+	}
+	job.SetProgress(completed, total)
+
+	cluster.Lock()
+	delete(cluster.pendingContainers, config.Name)
+	cluster.Unlock()
+	if resultErr == nil {
+		if ret := cluster.configCache.SetMetaDataConfig(metaData.MetaID, config); !ret {
+			resultErr = fmt.Errorf("roll containers %s, set meta config failure", metaData.MetaID)
+		}
+	} else {
+		logger.WARN("[#cluster#] roll containers %s stopped, %d of %d rolled, meta keeps the original config.", metaData.MetaID, completed, total)
+	}
+	cluster.hooksProcessor.Hook(metaData, UpdateMetaEvent)
+	if resultErr != nil && resultErr != ErrClusterJobCanceled {
+		return fmt.Errorf("roll containers failure, %s", resultErr.Error())
+	}
+	return resultErr
+}
@@ -0,0 +1,348 @@
+package cluster
+
+import "github.com/humpback/gounits/rand"
+import "github.com/humpback/gounits/system"
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// audit operations
+const (
+	AuditCreate        = "create"
+	AuditUpdate        = "update"
+	AuditOperate       = "operate"
+	AuditUpgrade       = "upgrade"
+	AuditRemove        = "remove"
+	AuditMaintenance   = "maintenance"
+	AuditGroupEvent    = "groupevent"
+	AuditImport        = "import"
+	AuditRecovery      = "recovery"
+	AuditMigrate       = "migrate"
+	AuditApply         = "apply"
+	AuditRemoveImage   = "removeimage"
+	AuditMigrateImages = "migrateimages"
+)
+
+const (
+	// audit record result
+	auditResultSuccess = "success"
+	auditResultFailure = "failure"
+	// audit record result of operation interrupted by shutdown
+	auditResultInterrupted = "interrupted"
+	// audit caller of cluster automatic operations
+	auditCallerCluster = "cluster"
+	// audit query default limit
+	auditQueryLimit = 100
+	// audit env value replacement
+	auditRedacted = "******"
+)
+
+// AuditRecord is exported
+// RequestID: api request id or recovery run id.
+// Caller: api caller identity, cluster automatic operations is "cluster".
+type AuditRecord struct {
+	ID         string      `json:"ID"`
+	Time       time.Time   `json:"Time"`
+	RequestID  string      `json:"RequestId"`
+	Caller     string      `json:"Caller"`
+	Operation  string      `json:"Operation"`
+	GroupID    string      `json:"GroupId"`
+	MetaID     string      `json:"MetaId"`
+	Parameters interface{} `json:"Parameters"`
+	Containers interface{} `json:"Containers"`
+	Result     string      `json:"Result"`
+	Error      string      `json:"Error"`
+}
+
+// SetResult is exported
+func (record *AuditRecord) SetResult(containers interface{}, err error) {
+
+	record.Containers = containers
+	record.Result = auditResultSuccess
+	if err != nil {
+		record.Result = auditResultFailure
+		record.Error = err.Error()
+	}
+}
+
+// AuditFilter is exported
+// empty field is not filtered, Limit <= 0 is default limit.
+type AuditFilter struct {
+	GroupID   string
+	MetaID    string
+	Operation string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+}
+
+func (filter *AuditFilter) match(record *AuditRecord) bool {
+
+	if filter.GroupID != "" && filter.GroupID != record.GroupID {
+		return false
+	}
+
+	if filter.MetaID != "" && filter.MetaID != record.MetaID {
+		return false
+	}
+
+	if filter.Operation != "" && filter.Operation != record.Operation {
+		return false
+	}
+
+	if !filter.Since.IsZero() && record.Time.Before(filter.Since) {
+		return false
+	}
+
+	if !filter.Until.IsZero() && record.Time.After(filter.Until) {
+		return false
+	}
+	return true
+}
+
+// AuditLog is exported
+// append-only audit records file under cache root, one json record per line.
+// file is rotated when larger than maxSize, rotated files are audit.log.1 (newest) to audit.log.<maxFiles>.
+type AuditLog struct {
+	sync.Mutex
+	path     string
+	file     *os.File
+	size     int64
+	maxSize  int64
+	maxFiles int
+}
+
+// NewAuditLog is exported
+// maxSize: rotate size bytes, maxFiles: rotated files kept.
+func NewAuditLog(root string, maxSize int64, maxFiles int) (*AuditLog, error) {
+
+	auditRoot := filepath.Join(root, "audit")
+	if err := system.MakeDirectory(auditRoot); err != nil {
+		return nil, fmt.Errorf("audit directory init error:%s", err.Error())
+	}
+
+	path := filepath.Join(auditRoot, "audit.log")
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0777)
+	if err != nil {
+		return nil, fmt.Errorf("audit file open error:%s", err.Error())
+	}
+
+	fi, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("audit file stat error:%s", err.Error())
+	}
+
+	return &AuditLog{
+		path:     path,
+		file:     file,
+		size:     fi.Size(),
+		maxSize:  maxSize,
+		maxFiles: maxFiles,
+	}, nil
+}
+
+// Close is exported
+func (auditLog *AuditLog) Close() {
+
+	auditLog.Lock()
+	defer auditLog.Unlock()
+	if auditLog.file != nil {
+		auditLog.file.Close()
+		auditLog.file = nil
+	}
+}
+
+// Append is exported
+// Env values of record parameters and containers are redacted before written.
+func (auditLog *AuditLog) Append(record *AuditRecord) error {
+
+	if record.ID == "" {
+		record.ID = rand.UUID(true)
+	}
+
+	if record.Time.IsZero() {
+		record.Time = time.Now()
+	}
+
+	written := *record
+	written.Parameters = redactAuditValue(record.Parameters)
+	written.Containers = redactAuditValue(record.Containers)
+	buf, err := json.Marshal(&written)
+	if err != nil {
+		return err
+	}
+
+	auditLog.Lock()
+	defer auditLog.Unlock()
+	if auditLog.file == nil {
+		return fmt.Errorf("audit file closed")
+	}
+
+	if auditLog.size > 0 && auditLog.size+int64(len(buf))+1 > auditLog.maxSize {
+		if err := auditLog.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := auditLog.file.Write(append(buf, '\n'))
+	auditLog.size += int64(n)
+	if err != nil {
+		return err
+	}
+	return auditLog.file.Sync()
+}
+
+// rotate, shift audit.log.N to audit.log.N+1, the oldest is removed, audit.log is reopened empty.
+func (auditLog *AuditLog) rotate() error {
+
+	auditLog.file.Close()
+	auditLog.file = nil
+	os.Remove(auditLog.rotatedPath(auditLog.maxFiles))
+	for index := auditLog.maxFiles - 1; index >= 1; index-- {
+		os.Rename(auditLog.rotatedPath(index), auditLog.rotatedPath(index+1))
+	}
+
+	if auditLog.maxFiles > 0 {
+		if err := os.Rename(auditLog.path, auditLog.rotatedPath(1)); err != nil {
+			return fmt.Errorf("audit file rotate error:%s", err.Error())
+		}
+	}
+
+	file, err := os.OpenFile(auditLog.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC|os.O_APPEND, 0777)
+	if err != nil {
+		return fmt.Errorf("audit file open error:%s", err.Error())
+	}
+	auditLog.file = file
+	auditLog.size = 0
+	return nil
+}
+
+func (auditLog *AuditLog) rotatedPath(index int) string {
+
+	return fmt.Sprintf("%s.%d", auditLog.path, index)
+}
+
+// redactAuditValue, json form of value, Env entries KEY=VALUE of any object are written KEY=******.
+func redactAuditValue(value interface{}) interface{} {
+
+	if value == nil {
+		return nil
+	}
+
+	buf, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+
+	var out interface{}
+	decoder := json.NewDecoder(bytes.NewReader(buf))
+	decoder.UseNumber()
+	if err := decoder.Decode(&out); err != nil {
+		return value
+	}
+	return redactEnv(out)
+}
+
+func redactEnv(value interface{}) interface{} {
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if env, ret := item.([]interface{}); ret && strings.EqualFold(key, "Env") {
+				for i, entry := range env {
+					if str, ret := entry.(string); ret && strings.Contains(str, "=") {
+						env[i] = strings.SplitN(str, "=", 2)[0] + "=" + auditRedacted
+					}
+				}
+				continue
+			}
+			v[key] = redactEnv(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactEnv(item)
+		}
+	}
+	return value
+}
+
+// Query is exported
+// Return filter matched records, newest first.
+// files are read from the newest, older rotated files are not read once limit records matched.
+func (auditLog *AuditLog) Query(filter *AuditFilter) ([]*AuditRecord, error) {
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = auditQueryLimit
+	}
+
+	auditLog.Lock()
+	paths := []string{auditLog.path}
+	for index := 1; index <= auditLog.maxFiles; index++ {
+		paths = append(paths, auditLog.rotatedPath(index))
+	}
+	auditLog.Unlock()
+
+	records := []*AuditRecord{}
+	for _, path := range paths {
+		fileRecords, err := queryAuditFile(path, filter, limit-len(records))
+		if err != nil {
+			if os.IsNotExist(err) {
+				break
+			}
+			return nil, err
+		}
+		records = append(records, fileRecords...)
+		if len(records) >= limit {
+			break
+		}
+	}
+	return records, nil
+}
+
+// queryAuditFile, return the last limit matched records of file, newest first.
+func queryAuditFile(path string, filter *AuditFilter, limit int) ([]*AuditRecord, error) {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+	records := []*AuditRecord{}
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			record := &AuditRecord{}
+			if json.Unmarshal(line, record) == nil && filter.match(record) {
+				records = append(records, record)
+				if len(records) > limit {
+					records = records[1:]
+				}
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+	}
+
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+	return records, nil
+}
@@ -0,0 +1,85 @@
+package cluster
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuditLogRotate(t *testing.T) {
+
+	root, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	auditLog, err := NewAuditLog(root, 1024, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer auditLog.Close()
+
+	for i := 0; i < 40; i++ {
+		if err := auditLog.Append(&AuditRecord{Operation: AuditCreate, MetaID: fmt.Sprintf("meta%d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"audit.log", "audit.log.1", "audit.log.2"} {
+		fi, err := os.Stat(filepath.Join(root, "audit", name))
+		if err != nil {
+			t.Fatalf("%s not exists, %v", name, err)
+		}
+		if fi.Size() > 1024 {
+			t.Fatalf("%s size %d larger than max size", name, fi.Size())
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "audit", "audit.log.3")); !os.IsNotExist(err) {
+		t.Fatal("audit.log.3 should be removed")
+	}
+
+	records, err := auditLog.Query(&AuditFilter{Limit: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 5 || records[0].MetaID != "meta39" || records[4].MetaID != "meta35" {
+		t.Fatalf("query newest records %+v", records)
+	}
+}
+
+func TestAuditLogRedactEnv(t *testing.T) {
+
+	root, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	auditLog, err := NewAuditLog(root, 1024*1024, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer auditLog.Close()
+
+	parameters := map[string]interface{}{
+		"Config": map[string]interface{}{"Image": "app:1", "Env": []string{"DB_PASSWORD=secret", "MODE"}},
+	}
+	if err := auditLog.Append(&AuditRecord{Operation: AuditCreate, Parameters: parameters}); err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := ioutil.ReadFile(filepath.Join(root, "audit", "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(buf), "secret") || !strings.Contains(string(buf), `"DB_PASSWORD=******"`) || !strings.Contains(string(buf), `"app:1"`) {
+		t.Fatalf("audit env not redacted, %s", buf)
+	}
+}
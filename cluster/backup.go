@@ -0,0 +1,164 @@
+package cluster
+
+import "github.com/humpback/gounits/logger"
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// metadata archive format version
+	metaDataArchiveVersion = 1
+)
+
+const (
+	// MetaDataImportMerge is exported
+	// import archive metas, keep the existing metas.
+	MetaDataImportMerge = "merge"
+	// MetaDataImportReplace is exported
+	// import archive metas, remove the existing metas not in archive.
+	MetaDataImportReplace = "replace"
+)
+
+// MetaDataArchive is exported
+// GroupID: export group filter, empty is all groups.
+type MetaDataArchive struct {
+	Version  int         `json:"Version" required:"true"`
+	Location string      `json:"Location"`
+	GroupID  string      `json:"GroupId"`
+	Created  time.Time   `json:"Created"`
+	Metas    []*MetaData `json:"Metas"`
+}
+
+// MetaDataImportResult is exported
+type MetaDataImportResult struct {
+	Mode     string   `json:"Mode"`
+	Imported []string `json:"Imported"`
+	Removed  []string `json:"Removed"`
+}
+
+// MetaDataImportError is exported
+// Err: import error, MetaIDs: metas of caused the error.
+type MetaDataImportError struct {
+	Err     error
+	MetaIDs []string
+}
+
+func (importError *MetaDataImportError) Error() string {
+
+	if len(importError.MetaIDs) == 0 {
+		return importError.Err.Error()
+	}
+	return importError.Err.Error() + ", " + strings.Join(importError.MetaIDs, ",")
+}
+
+// ExportMetaData is exported
+// Return an archive of all metas, groupid is not empty only export the group metas.
+func (cluster *Cluster) ExportMetaData(groupid string) *MetaDataArchive {
+
+	metas := cluster.configCache.ExportMetaData(groupid)
+	logger.INFO("[#cluster#] export metadata group:%s metas:%d", groupid, len(metas))
+	return &MetaDataArchive{
+		Version:  metaDataArchiveVersion,
+		Location: cluster.Location,
+		GroupID:  groupid,
+		Created:  time.Now(),
+		Metas:    metas,
+	}
+}
+
+// ImportMetaData is exported
+// Validate archive and rebuild containers config cache.
+// merge mode, archive metaid or group name collide with existing metas is conflict.
+// replace mode, existing metas not in archive are removed.
+func (cluster *Cluster) ImportMetaData(archive *MetaDataArchive, mode string) (*MetaDataImportResult, error) {
+
+	if mode != MetaDataImportMerge && mode != MetaDataImportReplace {
+		return nil, ErrClusterMetaDataImportModeInvalid
+	}
+
+	if err := cluster.validateMetaDataArchive(archive, mode); err != nil {
+		logger.ERROR("[#cluster#] import metadata %s error, %s", mode, err.Error())
+		return nil, err
+	}
+
+	imported, removed, err := cluster.configCache.ImportMetaData(archive.Metas, mode == MetaDataImportReplace)
+	if err != nil {
+		logger.ERROR("[#cluster#] import metadata %s error, %s, imported:%d removed:%d", mode, err.Error(), len(imported), len(removed))
+		return nil, fmt.Errorf("%s, %s", ErrClusterMetaDataImportFailure, err.Error())
+	}
+
+	logger.INFO("[#cluster#] import metadata %s, imported:%d removed:%d", mode, len(imported), len(removed))
+	return &MetaDataImportResult{
+		Mode:     mode,
+		Imported: imported,
+		Removed:  removed,
+	}, nil
+}
+
+func (cluster *Cluster) validateMetaDataArchive(archive *MetaDataArchive, mode string) error {
+
+	if archive == nil || archive.Version <= 0 || archive.Version > metaDataArchiveVersion {
+		return ErrClusterMetaDataArchiveInvalid
+	}
+
+	invalid := []string{}
+	notfound := []string{}
+	conflict := []string{}
+	metaids := make(map[string]bool)
+	names := make(map[string]bool)
+	for _, metaData := range archive.Metas {
+		if metaData == nil || len(strings.TrimSpace(metaData.MetaID)) == 0 {
+			invalid = append(invalid, "<empty>")
+			continue
+		}
+
+		if len(strings.TrimSpace(metaData.Config.Name)) == 0 || len(strings.TrimSpace(metaData.Config.Image)) == 0 || metaData.Instances < 0 {
+			invalid = append(invalid, metaData.MetaID)
+			continue
+		}
+
+		if cluster.GetGroup(metaData.GroupID) == nil {
+			notfound = append(notfound, metaData.MetaID)
+		}
+
+		name := metaData.GroupID + "/" + metaData.Config.Name
+		if metaids[metaData.MetaID] || names[name] {
+			conflict = append(conflict, metaData.MetaID)
+		}
+		metaids[metaData.MetaID] = true
+		names[name] = true
+		if mode == MetaDataImportMerge {
+			if cluster.configCache.GetMetaData(metaData.MetaID) != nil || cluster.configCache.ContainsMetaData(metaData.GroupID, metaData.Config.Name) {
+				conflict = append(conflict, metaData.MetaID)
+			}
+		}
+	}
+
+	if len(invalid) > 0 {
+		return &MetaDataImportError{Err: ErrClusterMetaDataArchiveInvalid, MetaIDs: invalid}
+	}
+
+	if len(notfound) > 0 {
+		return &MetaDataImportError{Err: ErrClusterGroupNotFound, MetaIDs: notfound}
+	}
+
+	if len(conflict) > 0 {
+		return &MetaDataImportError{Err: ErrClusterMetaDataImportConflict, MetaIDs: conflict}
+	}
+
+	if mode == MetaDataImportReplace {
+		busy := []string{}
+		for _, metaid := range cluster.configCache.GetMetaIDs() {
+			if cluster.upgraderCache.Contains(metaid) || cluster.migtatorCache.Contains(metaid) {
+				busy = append(busy, metaid)
+			}
+		}
+		if len(busy) > 0 {
+			return &MetaDataImportError{Err: ErrClusterMetaDataImportConflict, MetaIDs: busy}
+		}
+	}
+	return nil
+}
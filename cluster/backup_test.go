@@ -0,0 +1,104 @@
+package cluster
+
+import "humpback-center/cluster/storage"
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// failWriteMetaStore, memory metastore of a failure write of metaid.
+type failWriteMetaStore struct {
+	*storage.MemoryMetaStore
+	failMetaID string
+}
+
+func (store *failWriteMetaStore) Write(metaid string, data []byte) error {
+
+	if metaid == store.failMetaID {
+		return errors.New("write failure")
+	}
+	return store.MemoryMetaStore.Write(metaid, data)
+}
+
+func newImportTestCache(t *testing.T, failMetaID string) (*ContainersConfigCache, *failWriteMetaStore) {
+
+	root, err := ioutil.TempDir("", "configcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(root) })
+
+	metaStore := &failWriteMetaStore{MemoryMetaStore: storage.NewMemoryMetaStore(), failMetaID: failMetaID}
+	cache, err := NewContainersConfigCache(root, metaStore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cache, metaStore
+}
+
+func newImportTestMeta(metaid string, image string) *MetaData {
+
+	metaData := &MetaData{}
+	metaData.MetaID = metaid
+	metaData.GroupID = "group1"
+	metaData.Instances = 1
+	metaData.Config.Name = metaid
+	metaData.Config.Image = image
+	return metaData
+}
+
+func TestImportMetaDataRollback(t *testing.T) {
+
+	cache, metaStore := newImportTestCache(t, "meta3")
+	if _, _, err := cache.ImportMetaData([]*MetaData{newImportTestMeta("meta1", "app:1")}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	metas := []*MetaData{newImportTestMeta("meta1", "app:2"), newImportTestMeta("meta2", "app:1"), newImportTestMeta("meta3", "app:1")}
+	if _, _, err := cache.ImportMetaData(metas, true); err == nil {
+		t.Fatal("import with a failure write should fail")
+	}
+
+	keys, _ := metaStore.Keys()
+	if strings.Join(keys, ",") != "meta1" {
+		t.Fatalf("metastore keys after rollback %v, want meta1", keys)
+	}
+
+	data, _ := metaStore.Read("meta1")
+	metaData, _, err := decodeMetaData(data)
+	if err != nil || metaData.Config.Image != "app:1" {
+		t.Fatalf("meta1 not restored, %+v, %v", metaData, err)
+	}
+
+	if cache.GetMetaData("meta2") != nil || cache.GetMetaData("meta1").Config.Image != "app:1" {
+		t.Fatal("cache changed by a failure import")
+	}
+}
+
+func TestImportMetaDataReplace(t *testing.T) {
+
+	cache, metaStore := newImportTestCache(t, "")
+	if _, _, err := cache.ImportMetaData([]*MetaData{newImportTestMeta("meta1", "app:1"), newImportTestMeta("meta2", "app:1")}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	imported, removed, err := cache.ImportMetaData([]*MetaData{newImportTestMeta("meta2", "app:2"), newImportTestMeta("meta3", "app:1")}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(imported, ",") != "meta2,meta3" || strings.Join(removed, ",") != "meta1" {
+		t.Fatalf("imported %v removed %v", imported, removed)
+	}
+
+	keys, _ := metaStore.Keys()
+	sort.Strings(keys)
+	if strings.Join(keys, ",") != "meta2,meta3" {
+		t.Fatalf("metastore keys %v, want meta2,meta3", keys)
+	}
+}
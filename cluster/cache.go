@@ -1,20 +1,18 @@
 package cluster
 
+import "github.com/humpback/gounits/logger"
 import "github.com/humpback/gounits/system"
 import "github.com/humpback/gounits/rand"
+import "humpback-center/cluster/storage"
 import "humpback-center/cluster/types"
 import "common/models"
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"os"
-	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 )
 
 // ContainerBaseConfig is exported
@@ -43,31 +41,59 @@ func (containers SortContainerBaseConfigs) Less(i, j int) bool {
 }
 
 // MetaBase is exported
+// Paused: meta maintenance mode, recovery and migration skip this meta.
+// PauseExpires: maintenance mode expire time(unixnano), 0 is never expires.
 type MetaBase struct {
-	GroupID   string           `json:"GroupId"`
-	MetaID    string           `json:"MetaId"`
-	Instances int              `json:"Instances"`
-	WebHooks  types.WebHooks   `json:"WebHooks"`
-	ImageTag  string           `json:"ImageTag"`
-	Config    models.Container `json:"Config"`
+	GroupID      string           `json:"GroupId"`
+	MetaID       string           `json:"MetaId"`
+	Instances    int              `json:"Instances"`
+	WebHooks     types.WebHooks   `json:"WebHooks"`
+	ImageTag     string           `json:"ImageTag"`
+	Paused       bool             `json:"Paused"`
+	PauseExpires int64            `json:"PauseExpires"`
+	Config       models.Container `json:"Config"`
+}
+
+// IsPaused is exported
+// Determine if the meta is in maintenance mode and not expired
+func (metaBase *MetaBase) IsPaused() bool {
+
+	if !metaBase.Paused {
+		return false
+	}
+	return metaBase.PauseExpires == 0 || time.Now().UnixNano() < metaBase.PauseExpires
 }
 
 // MetaData is exported
+// SchemaVersion: stored schema version, upgraded when loaded.
 type MetaData struct {
+	SchemaVersion int `json:"SchemaVersion"`
 	MetaBase
 	BaseConfigs []*ContainerBaseConfig `json:"BaseConfigs"`
 }
 
+// QuarantinedMeta is exported
+// Reason: quarantined reason, empty if quarantined by a previous run.
+// Data: quarantined raw data, for repair.
+type QuarantinedMeta struct {
+	MetaID        string    `json:"MetaId"`
+	Reason        string    `json:"Reason"`
+	QuarantinedAt time.Time `json:"QuarantinedAt"`
+	Data          string    `json:"Data"`
+}
+
 // ContainersConfigCache is exported
 type ContainersConfigCache struct {
 	sync.RWMutex
-	Root string
-	data map[string]*MetaData
+	Root        string
+	store       storage.MetaStore
+	data        map[string]*MetaData
+	quarantined map[string]*QuarantinedMeta
 }
 
 // NewContainersConfigCache is exported
-// Structure ContainersCache
-func NewContainersConfigCache(root string) (*ContainersConfigCache, error) {
+// Structure ContainersCache, metaStore is nil use file metastore of root.
+func NewContainersConfigCache(root string, metaStore storage.MetaStore) (*ContainersConfigCache, error) {
 
 	if len(strings.TrimSpace(root)) == 0 {
 		root = "./cache"
@@ -77,39 +103,119 @@ func NewContainersConfigCache(root string) (*ContainersConfigCache, error) {
 		return nil, fmt.Errorf("containers cache directory init error:%s", err.Error())
 	}
 
+	if metaStore == nil {
+		fileMetaStore, err := storage.NewFileMetaStore(root)
+		if err != nil {
+			return nil, err
+		}
+		metaStore = fileMetaStore
+	}
+
 	return &ContainersConfigCache{
-		Root: root,
-		data: make(map[string]*MetaData),
+		Root:        root,
+		store:       metaStore,
+		data:        make(map[string]*MetaData),
+		quarantined: make(map[string]*QuarantinedMeta),
 	}, nil
 }
 
 // Init is exported
-// Initialize containers baseConfig, load cache directory's metaData
-// First clear containers cache
+// Initialize containers baseConfig, load metastore's metaData
+// Loaded metaData replaces containers cache, cache is kept if metastore keys can not be read.
+// MetaData can not be decoded is moved to quarantine, not silently dropped.
 func (cache *ContainersConfigCache) Init() {
 
-	if len(cache.data) > 0 {
-		cache.data = make(map[string]*MetaData)
+	if ret := cache.load(true); !ret {
+		return
+	}
+
+	if quarantineids, err := cache.store.QuarantineKeys(); err == nil && len(quarantineids) > 0 {
+		logger.WARN("[#cluster#] containers cache init, %d quarantined metas need repair, %s", len(quarantineids), strings.Join(quarantineids, ","))
 	}
+}
+
+// Reload is exported
+// Reload metastore's metaData, follower refresh metas written by the leader.
+// read only, metaData is not upgraded or quarantined.
+func (cache *ContainersConfigCache) Reload() {
+
+	cache.load(false)
+}
+
+// load, read metastore's metaData into a new map and swap it to containers cache.
+// writable is true, upgrade metaData schema version and quarantine metaData can not be decoded.
+// return false if metastore keys can not be read, containers cache is kept.
+func (cache *ContainersConfigCache) load(writable bool) bool {
 
-	fis, err := ioutil.ReadDir(cache.Root)
+	metaids, err := cache.store.Keys()
 	if err != nil {
-		return
+		logger.ERROR("[#cluster#] containers cache load, read metastore keys error, %s", err.Error())
+		return false
 	}
 
-	cache.Lock()
-	for _, fi := range fis {
-		if !fi.IsDir() {
-			metaData, err := cache.readMetaData(fi.Name())
-			if err == nil {
-				for _, baseConfig := range metaData.BaseConfigs {
-					baseConfig.MetaData = metaData
-				}
-				cache.data[metaData.MetaID] = metaData
+	data := make(map[string]*MetaData)
+	corrupted := map[string]string{}
+	for _, metaid := range metaids {
+		buf, err := cache.store.Read(metaid)
+		if err != nil {
+			logger.ERROR("[#cluster#] containers cache load, read meta %s error, %s", metaid, err.Error())
+			continue
+		}
+		metaData, fromVersion, err := decodeMetaData(buf)
+		if err != nil {
+			corrupted[metaid] = err.Error()
+			continue
+		}
+		if writable && fromVersion < metaSchemaVersion {
+			if err := cache.writeMetaData(metaData); err != nil {
+				logger.ERROR("[#cluster#] containers cache load, upgrade meta %s schema version %d error, %s", metaid, fromVersion, err.Error())
+			} else {
+				logger.INFO("[#cluster#] containers cache load, upgrade meta %s schema version %d to %d", metaid, fromVersion, metaSchemaVersion)
 			}
 		}
+		for _, baseConfig := range metaData.BaseConfigs {
+			baseConfig.MetaData = metaData
+		}
+		data[metaData.MetaID] = metaData
 	}
+
+	cache.Lock()
+	for metaid, reason := range corrupted {
+		if writable {
+			cache.quarantineMetaData(metaid, reason)
+		} else {
+			logger.WARN("[#cluster#] containers cache load, skip meta %s, %s", metaid, reason)
+		}
+	}
+	cache.data = data
 	cache.Unlock()
+	return true
+}
+
+// GetQuarantinedMetas is exported
+// Return all quarantined metas of metastore.
+func (cache *ContainersConfigCache) GetQuarantinedMetas() ([]*QuarantinedMeta, error) {
+
+	quarantineids, err := cache.store.QuarantineKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	cache.RLock()
+	defer cache.RUnlock()
+	quarantinedMetas := []*QuarantinedMeta{}
+	for _, metaid := range quarantineids {
+		quarantinedMeta := &QuarantinedMeta{MetaID: metaid}
+		if value, ret := cache.quarantined[metaid]; ret {
+			quarantinedMeta.Reason = value.Reason
+			quarantinedMeta.QuarantinedAt = value.QuarantinedAt
+		}
+		if data, err := cache.store.ReadQuarantine(metaid); err == nil {
+			quarantinedMeta.Data = string(data)
+		}
+		quarantinedMetas = append(quarantinedMetas, quarantinedMeta)
+	}
+	return quarantinedMetas, nil
 }
 
 // MakeUniqueMetaID is exported
@@ -289,6 +395,52 @@ func (cache *ContainersConfigCache) SetMetaData(metaid string, instances int, we
 	}
 }
 
+// SetMetaDataConfig is exported
+// Set meta container config, image tag is set by config image.
+// Return set config result
+func (cache *ContainersConfigCache) SetMetaDataConfig(metaid string, config models.Container) bool {
+
+	cache.Lock()
+	defer cache.Unlock()
+	if metaData, ret := cache.data[metaid]; ret {
+		originalTag := metaData.ImageTag
+		originalConfig := metaData.Config
+		_, metaData.ImageTag = splitImageTag(config.Image)
+		metaData.Config = config
+		if err := cache.writeMetaData(metaData); err != nil {
+			metaData.ImageTag = originalTag
+			metaData.Config = originalConfig
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// SetMetaDataPaused is exported
+// Set meta maintenance mode, expires is unixnano, 0 is never expires.
+func (cache *ContainersConfigCache) SetMetaDataPaused(metaid string, paused bool, expires int64) bool {
+
+	cache.Lock()
+	defer cache.Unlock()
+	if metaData, ret := cache.data[metaid]; ret {
+		originalPaused := metaData.Paused
+		originalExpires := metaData.PauseExpires
+		metaData.Paused = paused
+		metaData.PauseExpires = 0
+		if paused {
+			metaData.PauseExpires = expires
+		}
+		if err := cache.writeMetaData(metaData); err != nil {
+			metaData.Paused = originalPaused
+			metaData.PauseExpires = originalExpires
+			return false
+		}
+		return true
+	}
+	return false
+}
+
 // RemoveMetaData is exported
 // Remove metaid of a metadata
 func (cache *ContainersConfigCache) RemoveMetaData(metaid string) bool {
@@ -334,6 +486,7 @@ func (cache *ContainersConfigCache) CreateMetaData(groupid string, instances int
 	}
 
 	metaData := &MetaData{
+		SchemaVersion: metaSchemaVersion,
 		MetaBase: MetaBase{
 			GroupID:   groupid,
 			MetaID:    metaid,
@@ -352,6 +505,119 @@ func (cache *ContainersConfigCache) CreateMetaData(groupid string, instances int
 	return metaData, nil
 }
 
+// GetMetaIDs is exported
+func (cache *ContainersConfigCache) GetMetaIDs() []string {
+
+	cache.RLock()
+	defer cache.RUnlock()
+	metaids := []string{}
+	for metaid := range cache.data {
+		metaids = append(metaids, metaid)
+	}
+	return metaids
+}
+
+// ExportMetaData is exported
+// Return a copy of metas, groupid is empty return all metas.
+func (cache *ContainersConfigCache) ExportMetaData(groupid string) []*MetaData {
+
+	cache.RLock()
+	defer cache.RUnlock()
+	metas := []*MetaData{}
+	for _, metaData := range cache.data {
+		if groupid != "" && metaData.GroupID != groupid {
+			continue
+		}
+		buf, err := encodeMetaData(metaData)
+		if err != nil {
+			continue
+		}
+		if copyData, _, err := decodeMetaData(buf); err == nil {
+			metas = append(metas, copyData)
+		}
+	}
+	return metas
+}
+
+// ImportMetaData is exported
+// Write metas to metastore and cache, replace is true remove the existing metas not in metas.
+// every meta is encoded before any write, a write failure restores the metas already written.
+// Return imported and removed metaids.
+func (cache *ContainersConfigCache) ImportMetaData(metas []*MetaData, replace bool) ([]string, []string, error) {
+
+	cache.Lock()
+	defer cache.Unlock()
+	encoded := make(map[string][]byte)
+	importedids := make(map[string]bool)
+	for _, metaData := range metas {
+		if metaData.BaseConfigs == nil {
+			metaData.BaseConfigs = []*ContainerBaseConfig{}
+		}
+		for _, baseConfig := range metaData.BaseConfigs {
+			baseConfig.MetaData = metaData
+		}
+		buf, err := encodeMetaData(metaData)
+		if err != nil {
+			return []string{}, []string{}, fmt.Errorf("%s, %s", metaData.MetaID, err.Error())
+		}
+		encoded[metaData.MetaID] = buf
+		importedids[metaData.MetaID] = true
+	}
+
+	removeids := []string{}
+	if replace {
+		for metaid := range cache.data {
+			if !importedids[metaid] {
+				removeids = append(removeids, metaid)
+			}
+		}
+	}
+
+	written := []string{}
+	removed := []string{}
+	for _, metaData := range metas {
+		if err := cache.store.Write(metaData.MetaID, encoded[metaData.MetaID]); err != nil {
+			cache.rollbackImport(written, removed)
+			return []string{}, []string{}, fmt.Errorf("%s, %s", metaData.MetaID, err.Error())
+		}
+		written = append(written, metaData.MetaID)
+	}
+
+	for _, metaid := range removeids {
+		if err := cache.removeMeteData(metaid); err != nil {
+			cache.rollbackImport(written, removed)
+			return []string{}, []string{}, fmt.Errorf("%s, %s", metaid, err.Error())
+		}
+		removed = append(removed, metaid)
+	}
+
+	for _, metaData := range metas {
+		cache.data[metaData.MetaID] = metaData
+	}
+
+	for _, metaid := range removed {
+		delete(cache.data, metaid)
+	}
+	return written, removed, nil
+}
+
+// rollbackImport, restore metastore of a failure import, cache data is not changed before import completed.
+// written metas are restored to cache data or removed if not exists before, removed metas are written back.
+func (cache *ContainersConfigCache) rollbackImport(written []string, removed []string) {
+
+	for _, metaid := range append(written, removed...) {
+		var err error
+		if metaData, ret := cache.data[metaid]; ret {
+			err = cache.writeMetaData(metaData)
+		} else {
+			err = cache.removeMeteData(metaid)
+		}
+		if err != nil {
+			logger.ERROR("[#cluster#] containers cache rollback import meta %s error, %s", metaid, err.Error())
+		}
+	}
+}
+
 // GetContainerBaseConfig is exported
 func (cache *ContainersConfigCache) GetContainerBaseConfig(metaid string, containerid string) *ContainerBaseConfig {
 
@@ -417,48 +683,43 @@ func (cache *ContainersConfigCache) ClearContainerBaseConfig(metaid string) {
 // readMetaData is exported
 func (cache *ContainersConfigCache) readMetaData(metaid string) (*MetaData, error) {
 
-	metaPath, err := filepath.Abs(cache.Root + "/" + metaid)
-	if err != nil {
-		return nil, err
-	}
-
-	buf, err := ioutil.ReadFile(metaPath)
+	buf, err := cache.store.Read(metaid)
 	if err != nil {
 		return nil, err
 	}
-
-	metaData := &MetaData{}
-	if err := json.NewDecoder(bytes.NewReader(buf)).Decode(metaData); err != nil {
-		return nil, nil
-	}
-	return metaData, nil
+	metaData, _, err := decodeMetaData(buf)
+	return metaData, err
 }
 
 // writeMetaData is exported
 func (cache *ContainersConfigCache) writeMetaData(metaData *MetaData) error {
 
-	metaPath, err := filepath.Abs(cache.Root + "/" + metaData.MetaID)
+	buf, err := encodeMetaData(metaData)
 	if err != nil {
 		return err
 	}
+	return cache.store.Write(metaData.MetaID, buf)
+}
 
-	buffer := bytes.NewBuffer([]byte{})
-	if err := json.NewEncoder(buffer).Encode(metaData); err != nil {
-		return err
+// quarantineMetaData is exported
+// move a corrupted meta to metastore quarantine.
+func (cache *ContainersConfigCache) quarantineMetaData(metaid string, reason string) {
+
+	if err := cache.store.Quarantine(metaid); err != nil {
+		logger.ERROR("[#cluster#] containers cache quarantine meta %s error, %s", metaid, err.Error())
+		return
 	}
-	return ioutil.WriteFile(metaPath, buffer.Bytes(), 0777)
+
+	cache.quarantined[metaid] = &QuarantinedMeta{
+		MetaID:        metaid,
+		Reason:        reason,
+		QuarantinedAt: time.Now(),
+	}
+	logger.WARN("[#cluster#] containers cache quarantined meta %s, %s", metaid, reason)
 }
 
 // removeMeteData is exported
 func (cache *ContainersConfigCache) removeMeteData(metaid string) error {
 
-	metaPath, err := filepath.Abs(cache.Root + "/" + metaid)
-	if err != nil {
-		return err
-	}
-
-	if err := os.Remove(metaPath); err != nil {
-		return err
-	}
-	return nil
+	return cache.store.Remove(metaid)
 }
@@ -0,0 +1,67 @@
+package cluster
+
+import "humpback-center/cluster/storage"
+
+import (
+	"errors"
+	"testing"
+)
+
+// failKeysMetaStore, memory metastore of a failure keys read.
+type failKeysMetaStore struct {
+	*storage.MemoryMetaStore
+	failKeys bool
+}
+
+func (store *failKeysMetaStore) Keys() ([]string, error) {
+
+	if store.failKeys {
+		return nil, errors.New("keys failure")
+	}
+	return store.MemoryMetaStore.Keys()
+}
+
+func writeTestMeta(t *testing.T, metaStore storage.MetaStore, metaData *MetaData) {
+
+	buf, err := encodeMetaData(metaData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := metaStore.Write(metaData.MetaID, buf); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConfigCacheReload(t *testing.T) {
+
+	metaStore := &failKeysMetaStore{MemoryMetaStore: storage.NewMemoryMetaStore()}
+	cache, err := NewContainersConfigCache(t.TempDir(), metaStore)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeTestMeta(t, metaStore, newImportTestMeta("meta1", "app:1"))
+	cache.Init()
+	if cache.GetMetaData("meta1") == nil {
+		t.Fatal("meta1 not loaded")
+	}
+
+	metaStore.failKeys = true
+	cache.Init()
+	if cache.GetMetaData("meta1") == nil {
+		t.Fatal("cache cleared by a failure keys read")
+	}
+
+	metaStore.failKeys = false
+	writeTestMeta(t, metaStore, newImportTestMeta("meta2", "app:1"))
+	metaStore.Remove("meta1")
+	metaStore.Write("meta3", []byte("corrupted"))
+	cache.Reload()
+	if cache.GetMetaData("meta1") != nil || cache.GetMetaData("meta2") == nil {
+		t.Fatal("reload not refresh metas of metastore")
+	}
+
+	if quarantineids, _ := metaStore.QuarantineKeys(); len(quarantineids) != 0 {
+		t.Fatalf("reload quarantined metas %v", quarantineids)
+	}
+}
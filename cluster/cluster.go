@@ -5,6 +5,8 @@ import "github.com/humpback/discovery/backends"
 import "github.com/humpback/gounits/json"
 import "github.com/humpback/gounits/logger"
 import "github.com/humpback/gounits/system"
+import "humpback-center/cluster/election"
+import "humpback-center/cluster/storage"
 import "humpback-center/cluster/types"
 import "humpback-center/notify"
 import "common/models"
@@ -45,11 +47,13 @@ type Group struct {
 }
 
 // Cluster is exported
+// LeaderForward: follower forward mutating requests to leader mode, redirect or proxy.
 type Cluster struct {
 	sync.RWMutex
-	Location     string
-	NotifySender *notify.NotifySender
-	Discovery    *discovery.Discovery
+	Location      string
+	LeaderForward string
+	NotifySender  *notify.NotifySender
+	Discovery     *discovery.Discovery
 
 	overcommitRatio   float64
 	createRetry       int64
@@ -61,6 +65,15 @@ type Cluster struct {
 	enginesPool       *EnginesPool
 	metaRestorer      *MetaRestorer
 	hooksProcessor    *HooksProcessor
+	auditLog          *AuditLog
+	idempotencyCache  *IdempotencyCache
+	imageChecker      ImageChecker
+	events            *EventStream
+	jobsCache         *JobsCache
+	elector           *election.Elector
+	refreshInterval   time.Duration
+	draining          bool
+	drainCh           chan struct{}
 	pendingContainers map[string]*pendingContainer
 	engines           map[string]*Engine
 	groups            map[string]*Group
@@ -68,7 +81,9 @@ type Cluster struct {
 }
 
 // NewCluster is exported
-func NewCluster(driverOpts system.DriverOpts, notifySender *notify.NotifySender, discovery *discovery.Discovery) (*Cluster, error) {
+// metaStore is nil, use file metastore of cacheroot.
+// electionBackend is nil, cluster is a standalone leader, else campaign leader with advertise address.
+func NewCluster(driverOpts system.DriverOpts, metaStore storage.MetaStore, electionBackend election.Backend, notifySender *notify.NotifySender, discovery *discovery.Discovery) (*Cluster, error) {
 
 	if discovery == nil {
 		return nil, ErrClusterDiscoveryInvalid
@@ -116,6 +131,15 @@ func NewCluster(driverOpts system.DriverOpts, notifySender *notify.NotifySender,
 		}
 	}
 
+	recoveryConcurrency := 1
+	if val, ret := driverOpts.Int("recoveryconcurrency", ""); ret {
+		if val <= 0 {
+			logger.WARN("[#cluster#] set recoveryconcurrency should be larger than 0, %d is invalid.", val)
+		} else {
+			recoveryConcurrency = int(val)
+		}
+	}
+
 	clusterLocation := ""
 	if val, ret := driverOpts.String("location", ""); ret {
 		clusterLocation = strings.TrimSpace(val)
@@ -126,18 +150,92 @@ func NewCluster(driverOpts system.DriverOpts, notifySender *notify.NotifySender,
 		cacheRoot = val
 	}
 
+	advertise := ""
+	if val, ret := driverOpts.String("advertise", ""); ret {
+		advertise = strings.TrimSuffix(strings.TrimSpace(val), "/")
+	}
+
+	if electionBackend != nil && advertise == "" {
+		return nil, ErrClusterAdvertiseInvalid
+	}
+
+	leaderForward := "redirect"
+	if val, ret := driverOpts.String("electionforward", ""); ret {
+		if val != "redirect" && val != "proxy" {
+			logger.WARN("[#cluster#] set electionforward should be redirect or proxy, %s is invalid.", val)
+		} else {
+			leaderForward = val
+		}
+	}
+
+	refreshInterval := 30 * time.Second
+	if val, ret := driverOpts.String("electionrefresh", ""); ret {
+		if dur, err := time.ParseDuration(val); err == nil && dur > 0 {
+			refreshInterval = dur
+		} else {
+			logger.WARN("[#cluster#] set electionrefresh should be a positive duration, %s is invalid.", val)
+		}
+	}
+
+	eventsBuffer := 1024
+	if val, ret := driverOpts.Int("eventsbuffer", ""); ret {
+		if val <= 0 {
+			logger.WARN("[#cluster#] set eventsbuffer should be larger than 0, %d is invalid.", val)
+		} else {
+			eventsBuffer = int(val)
+		}
+	}
+
+	idempotencyTTL := 24 * time.Hour
+	if val, ret := driverOpts.String("idempotencyttl", ""); ret {
+		if dur, err := time.ParseDuration(val); err == nil && dur > 0 {
+			idempotencyTTL = dur
+		} else {
+			logger.WARN("[#cluster#] set idempotencyttl should be a positive duration, %s is invalid.", val)
+		}
+	}
+
+	auditMaxSize := int64(64)
+	if val, ret := driverOpts.Int("auditmaxsize", ""); ret {
+		if val <= 0 {
+			logger.WARN("[#cluster#] set auditmaxsize should be larger than 0, %d is invalid.", val)
+		} else {
+			auditMaxSize = val
+		}
+	}
+
+	auditMaxFiles := 5
+	if val, ret := driverOpts.Int("auditmaxfiles", ""); ret {
+		if val < 0 {
+			logger.WARN("[#cluster#] set auditmaxfiles should not be less than 0, %d is invalid.", val)
+		} else {
+			auditMaxFiles = int(val)
+		}
+	}
+
 	hooksProcessor := NewHooksProcessor()
 	enginesPool := NewEnginesPool()
-	metaRestorer := NewMetaRestorer(recoveryInterval)
+	metaRestorer := NewMetaRestorer(recoveryInterval, recoveryConcurrency)
 	migrateContainersCache := NewMigrateContainersCache(migratedelay)
 	upgraderContainersCache := NewUpgradeContainersCache(upgradedelay)
-	configCache, err := NewContainersConfigCache(cacheRoot)
+	configCache, err := NewContainersConfigCache(cacheRoot, metaStore)
+	if err != nil {
+		return nil, err
+	}
+
+	auditLog, err := NewAuditLog(configCache.Root, auditMaxSize*1024*1024, auditMaxFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	idempotencyCache, err := NewIdempotencyCache(configCache.Root, idempotencyTTL)
 	if err != nil {
 		return nil, err
 	}
 
 	cluster := &Cluster{
 		Location:          clusterLocation,
+		LeaderForward:     leaderForward,
 		NotifySender:      notifySender,
 		Discovery:         discovery,
 		overcommitRatio:   overcommitratio,
@@ -150,12 +248,22 @@ func NewCluster(driverOpts system.DriverOpts, notifySender *notify.NotifySender,
 		enginesPool:       enginesPool,
 		metaRestorer:      metaRestorer,
 		hooksProcessor:    hooksProcessor,
+		auditLog:          auditLog,
+		idempotencyCache:  idempotencyCache,
+		events:            NewEventStream(eventsBuffer),
+		jobsCache:         NewJobsCache(),
+		refreshInterval:   refreshInterval,
+		drainCh:           make(chan struct{}),
 		pendingContainers: make(map[string]*pendingContainer),
 		engines:           make(map[string]*Engine),
 		groups:            make(map[string]*Group),
 		stopCh:            make(chan struct{}),
 	}
 
+	if electionBackend != nil {
+		cluster.elector = election.NewElector(electionBackend, advertise, cluster.onLeaderChangedHandleFunc)
+	}
+
 	hooksProcessor.SetCluster(cluster)
 	metaRestorer.SetCluster(cluster)
 	enginesPool.SetCluster(cluster)
@@ -175,7 +283,13 @@ func (cluster *Cluster) Start() error {
 		}
 		logger.INFO("[#cluster#] discovery service watching...")
 		cluster.Discovery.Watch(cluster.stopCh, cluster.watchDiscoveryHandleFunc)
-		cluster.metaRestorer.Start()
+		if cluster.elector != nil {
+			logger.INFO("[#cluster#] election campaign %s", cluster.elector.Candidate)
+			cluster.elector.Start()
+			go cluster.refreshMetasLoop()
+		} else {
+			cluster.metaRestorer.Start()
+		}
 		return nil
 	}
 	return ErrClusterDiscoveryInvalid
@@ -189,10 +303,222 @@ func (cluster *Cluster) Stop() {
 
 	close(cluster.stopCh)
 	cluster.enginesPool.Release()
+	if cluster.elector != nil {
+		cluster.elector.Stop()
+	}
 	cluster.metaRestorer.Stop()
+	cluster.auditLog.Close()
 	logger.INFO("[#cluster#] discovery service closed.")
 }
 
+// Drain is exported
+// Graceful shutdown, stop accepting mutating operations and recovery,
+// drain channel is closed, upgraders, creating loops and migrators stop at a safe point and persist progress,
+// timeout cancel running jobs, wait them stop at a safe point and write the interrupted to audit.
+func (cluster *Cluster) Drain(timeout time.Duration) error {
+
+	cluster.Lock()
+	if cluster.draining {
+		cluster.Unlock()
+		return nil
+	}
+	cluster.draining = true
+	close(cluster.drainCh)
+	cluster.Unlock()
+
+	logger.INFO("[#cluster#] cluster draining, timeout %s", timeout)
+	cluster.events.Close()
+	cluster.metaRestorer.Stop()
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+	deadline := time.After(timeout)
+	for {
+		upgrading := cluster.upgraderCache.MetaIDs()
+		migrating := cluster.migtatorCache.MetaIDs()
+		jobs := cluster.jobsCache.Running()
+		if len(upgrading) == 0 && len(migrating) == 0 && len(jobs) == 0 {
+			logger.INFO("[#cluster#] cluster drained.")
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			for _, job := range jobs {
+				cluster.jobsCache.Cancel(job.Info().ID)
+			}
+			//wait canceled jobs stop at a safe point, jobs write their results.
+			cluster.waitJobsStopped(drainCancelTimeout)
+			upgrading = cluster.upgraderCache.MetaIDs()
+			migrating = cluster.migtatorCache.MetaIDs()
+			for _, metaid := range upgrading {
+				cluster.writeInterruptedAudit(AuditUpgrade, metaid, nil, ErrClusterDrainTimeout)
+			}
+			for _, metaid := range migrating {
+				cluster.writeInterruptedAudit(AuditMigrate, metaid, nil, ErrClusterDrainTimeout)
+			}
+			logger.WARN("[#cluster#] cluster drain timeout, upgrading:%s migrating:%s", strings.Join(upgrading, ","), strings.Join(migrating, ","))
+			return ErrClusterDrainTimeout
+		}
+	}
+}
+
+// waitJobsStopped, wait running jobs finished, return false if timeout.
+func (cluster *Cluster) waitJobsStopped(timeout time.Duration) bool {
+
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+	deadline := time.After(timeout)
+	for len(cluster.jobsCache.Running()) > 0 {
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			logger.WARN("[#cluster#] cluster drain, %d canceled jobs not stopped.", len(cluster.jobsCache.Running()))
+			return false
+		}
+	}
+	return true
+}
+
+// IsDraining is exported
+func (cluster *Cluster) IsDraining() bool {
+
+	cluster.RLock()
+	defer cluster.RUnlock()
+	return cluster.draining
+}
+
+// writeInterruptedAudit, persist an operation interrupted by shutdown, progress is the operation progress of the meta.
+func (cluster *Cluster) writeInterruptedAudit(operation string, metaid string, progress map[string]interface{}, err error) {
+
+	record := &AuditRecord{
+		Caller:    auditCallerCluster,
+		Operation: operation,
+		MetaID:    metaid,
+	}
+	parameters := map[string]interface{}{}
+	if metaData := cluster.configCache.GetMetaData(metaid); metaData != nil {
+		record.GroupID = metaData.GroupID
+		parameters["ImageTag"] = metaData.ImageTag
+		parameters["Instances"] = metaData.Instances
+	}
+	for key, value := range progress {
+		parameters[key] = value
+	}
+	record.Parameters = parameters
+	record.SetResult(nil, err)
+	record.Result = auditResultInterrupted
+	cluster.WriteAudit(record)
+}
+
+// drainSignaled, return true if drain channel is closed, long running loops stop at a safe point.
+func (cluster *Cluster) drainSignaled() bool {
+
+	select {
+	case <-cluster.drainCh:
+		return true
+	default:
+	}
+	return false
+}
+
+// IsLeader is exported
+// Return true if cluster is the leader, a standalone cluster always is the leader.
+// only the leader runs recovery, migration and mutating operations.
+func (cluster *Cluster) IsLeader() bool {
+
+	return cluster.elector == nil || cluster.elector.IsLeader()
+}
+
+// GetLeader is exported
+// Return the leader advertise address.
+func (cluster *Cluster) GetLeader() (string, error) {
+
+	if cluster.elector == nil {
+		return "", nil
+	}
+	return cluster.elector.Leader()
+}
+
+// onLeaderChangedHandleFunc, leader reload metas and start recovery, follower stop recovery.
+func (cluster *Cluster) onLeaderChangedHandleFunc(leading bool) {
+
+	if leading {
+		logger.INFO("[#cluster#] cluster became leader, reload metas and start recovery.")
+		cluster.configCache.Init()
+		cluster.metaRestorer.Start()
+		return
+	}
+	logger.INFO("[#cluster#] cluster became follower, stop recovery.")
+	cluster.metaRestorer.Stop()
+}
+
+// refreshMetasLoop, follower reload metas of metastore every refresh interval, leader metas are kept in memory.
+func (cluster *Cluster) refreshMetasLoop() {
+
+	ticker := time.NewTicker(cluster.refreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if !cluster.IsLeader() {
+				cluster.configCache.Reload()
+			}
+		case <-cluster.stopCh:
+			return
+		}
+	}
+}
+
+// SubmitJob is exported
+// run a long-running operation in background, return job info immediately.
+func (cluster *Cluster) SubmitJob(job *Job, fn JobFunc) *JobInfo {
+
+	return cluster.jobsCache.Submit(job, fn)
+}
+
+// GetJob is exported
+func (cluster *Cluster) GetJob(jobid string) (*JobInfo, error) {
+
+	return cluster.jobsCache.Get(jobid)
+}
+
+// CancelJob is exported
+func (cluster *Cluster) CancelJob(jobid string) (*JobInfo, error) {
+
+	return cluster.jobsCache.Cancel(jobid)
+}
+
+// SubscribeEvents is exported
+// subscribe cluster event stream, return buffered events after lastID.
+func (cluster *Cluster) SubscribeEvents(lastID uint64, filter *EventFilter) (*EventSubscriber, []*ClusterEvent) {
+
+	return cluster.events.Subscribe(lastID, filter)
+}
+
+// UnsubscribeEvents is exported
+func (cluster *Cluster) UnsubscribeEvents(subscriber *EventSubscriber) {
+
+	cluster.events.Unsubscribe(subscriber)
+}
+
+// publishEngineState publish engine state with all groups of engine.
+func (cluster *Cluster) publishEngineState(engine *Engine) {
+
+	groupids := []string{}
+	for _, group := range cluster.GetEngineGroups(engine) {
+		groupids = append(groupids, group.ID)
+	}
+
+	cluster.events.Publish(&ClusterEvent{
+		Type:     EngineStateEvent,
+		GroupIDs: groupids,
+		IP:       engine.IP,
+		Name:     engine.Name,
+		State:    engine.State(),
+	})
+}
+
 // GetMetaDataEngines is exported
 func (cluster *Cluster) GetMetaDataEngines(metaid string) (*MetaData, []*Engine, error) {
 
@@ -223,6 +549,16 @@ func (cluster *Cluster) GetMetaBase(metaid string) *MetaBase {
 	return nil
 }
 
+// GetContainerMetaBase is exported
+// Return the metabase of containerid.
+func (cluster *Cluster) GetContainerMetaBase(containerid string) *MetaBase {
+
+	if metaData := cluster.configCache.GetMetaDataOfContainer(containerid); metaData != nil {
+		return &metaData.MetaBase
+	}
+	return nil
+}
+
 // GetEngine is exported
 func (cluster *Cluster) GetEngine(ip string) *Engine {
 
@@ -367,6 +703,8 @@ func (cluster *Cluster) GetMetaEnginesContainers(metaData *MetaData, metaEngines
 					groupContainer.Containers = append(groupContainer.Containers, &types.EngineContainer{
 						IP:        engine.IP,
 						HostName:  engine.Name,
+						Index:     baseConfig.Index,
+						State:     containerStateText(container),
 						Container: container.Config.Container,
 					})
 					break
@@ -529,32 +867,74 @@ func (cluster *Cluster) SetGroup(group *Group) {
 }
 
 // RemoveGroup is exported
-func (cluster *Cluster) RemoveGroup(groupid string) bool {
+// job is canceled, not removed metas and containers are kept, group is not removed.
+func (cluster *Cluster) RemoveGroup(groupid string, job *Job) (bool, error) {
 
 	engines := cluster.GetGroupEngines(groupid)
 	if engines == nil {
 		logger.WARN("[#cluster#] remove group %s not found.", groupid)
-		return false
+		return false, nil
 	}
 
 	// remove group migrator's all meta.
 	cluster.migtatorCache.RemoveGroup(groupid)
 	// get group all metaData and clean metaData containers.
+	completed := 0
+	mutex := sync.Mutex{}
 	wgroup := sync.WaitGroup{}
 	groupMetaData := cluster.configCache.GetGroupMetaData(groupid)
+	job.SetProgress(completed, len(groupMetaData))
 	for _, metaData := range groupMetaData {
 		wgroup.Add(1)
 		go func(mdata *MetaData) {
-			cluster.removeContainers(mdata, "")
+			defer wgroup.Done()
+			if job.IsCanceled() {
+				return
+			}
+			cluster.removeContainers(mdata, "", job.subJob())
+			if job.IsCanceled() {
+				//canceled, meta of not removed containers is kept.
+				if metaData := cluster.configCache.GetMetaData(mdata.MetaID); metaData != nil && len(metaData.BaseConfigs) > 0 {
+					return
+				}
+			}
 			cluster.configCache.RemoveMetaData(mdata.MetaID)
 			cluster.hooksProcessor.Hook(mdata, RemoveMetaEvent)
-			wgroup.Done()
+			mutex.Lock()
+			completed++
+			job.SetProgress(completed, len(groupMetaData))
+			mutex.Unlock()
 		}(metaData)
 	}
 	wgroup.Wait()
 
+	if job.IsCanceled() {
+		logger.WARN("[#cluster#] remove group %s canceled, %d of %d metas removed.", groupid, completed, len(groupMetaData))
+		return false, ErrClusterJobCanceled
+	}
+
 	// remove metadata and group to cluster.
 	cluster.configCache.RemoveGroupMetaData(groupid)
+	cluster.dropGroup(groupid, engines)
+	return true, nil
+}
+
+// DropGroup is exported
+// follower remove group of cluster, group containers and metas are removed by the leader.
+func (cluster *Cluster) DropGroup(groupid string) bool {
+
+	engines := cluster.GetGroupEngines(groupid)
+	if engines == nil {
+		logger.WARN("[#cluster#] drop group %s not found.", groupid)
+		return false
+	}
+	cluster.dropGroup(groupid, engines)
+	return true
+}
+
+// dropGroup, remove group and engines not belong to any groups of cluster.
+func (cluster *Cluster) dropGroup(groupid string, engines []*Engine) {
+
 	cluster.Lock()
 	delete(cluster.groups, groupid) // remove group
 	logger.INFO("[#cluster#] removed group %s", groupid)
@@ -570,7 +950,6 @@ func (cluster *Cluster) RemoveGroup(groupid string) bool {
 			}
 		}
 	}
-	return true
 }
 
 func (cluster *Cluster) watchDiscoveryHandleFunc(added backends.Entries, removed backends.Entries, err error) {
@@ -665,12 +1044,46 @@ func (cluster *Cluster) OperateContainers(metaid string, containerid string, act
 }
 
 // UpgradeContainers is exported
-func (cluster *Cluster) UpgradeContainers(metaid string, imagetag string) (*types.UpgradeContainers, error) {
+// image tag is checked in registry before upgrade, prePull is true, engines pull image before upgrade.
+// Return pre-pull results of engines.
+// job is canceled, upgrader stop and recovery upgraded containers to original tag.
+func (cluster *Cluster) UpgradeContainers(metaid string, imagetag string, prePull bool, job *Job) (*types.UpgradeContainers, []*ImagePullResult, error) {
 
 	metaData, engines, err := cluster.validateMetaData(metaid)
 	if err != nil {
 		logger.ERROR("[#cluster#] upgrade containers %s error, %s", metaid, err.Error())
-		return nil, err
+		return nil, nil, err
+	}
+
+	//reserve meta as upgrading, image check and pre-pull are not run twice and drain waits them.
+	if err := cluster.upgraderCache.Reserve(metaData.MetaID); err != nil {
+		logger.ERROR("[#cluster#] upgrade containers %s error, %s", metaid, err.Error())
+		return nil, nil, err
+	}
+
+	repo, _ := splitImageTag(metaData.Config.Image)
+	image := repo + ":" + imagetag
+	if err := cluster.checkImage(image); err != nil {
+		cluster.upgraderCache.Release(metaData.MetaID)
+		logger.ERROR("[#cluster#] upgrade containers %s error, %s", metaid, err.Error())
+		return nil, nil, err
+	}
+
+	var pullResults []*ImagePullResult
+	if prePull {
+		if pullResults, err = cluster.prePullImage(metaData, engines, image); err != nil {
+			cluster.upgraderCache.Release(metaData.MetaID)
+			return nil, pullResults, err
+		}
+		if job.IsCanceled() {
+			cluster.upgraderCache.Release(metaData.MetaID)
+			return nil, pullResults, ErrClusterJobCanceled
+		}
+	}
+
+	if cluster.drainSignaled() {
+		cluster.upgraderCache.Release(metaData.MetaID)
+		return nil, pullResults, ErrClusterDraining
 	}
 
 	containers := Containers{}
@@ -681,15 +1094,26 @@ func (cluster *Cluster) UpgradeContainers(metaid string, imagetag string) (*type
 	}
 
 	upgradeContainers := types.UpgradeContainers{}
-	if len(containers) > 0 {
+	if len(containers) == 0 {
+		cluster.upgraderCache.Release(metaData.MetaID)
+	} else {
 		ret := false
 		upgradeCh := make(chan bool)
-		cluster.upgraderCache.Upgrade(upgradeCh, metaData.MetaID, imagetag, containers)
+		if err := cluster.upgraderCache.Upgrade(upgradeCh, metaData.MetaID, imagetag, containers, job); err != nil {
+			logger.ERROR("[#cluster#] upgrade containers %s error, %s", metaid, err.Error())
+			return nil, pullResults, err
+		}
 		ret = <-upgradeCh
 		close(upgradeCh)
 		cluster.hooksProcessor.Hook(metaData, UpgradeMetaEvent)
+		if !ret && job.IsCanceled() {
+			return nil, pullResults, ErrClusterJobCanceled
+		}
+		if !ret && cluster.drainSignaled() {
+			return nil, pullResults, ErrClusterDraining
+		}
 		if !ret {
-			return nil, fmt.Errorf("upgrade containers failure to %s", imagetag)
+			return nil, pullResults, fmt.Errorf("upgrade containers failure to %s", imagetag)
 		}
 		for _, engine := range engines {
 			if engine.IsHealthy() {
@@ -700,7 +1124,7 @@ func (cluster *Cluster) UpgradeContainers(metaid string, imagetag string) (*type
 			}
 		}
 	}
-	return &upgradeContainers, nil
+	return &upgradeContainers, pullResults, nil
 }
 
 // RemoveContainer is exported
@@ -710,13 +1134,13 @@ func (cluster *Cluster) RemoveContainer(containerid string) (string, *types.Remo
 	if metaData == nil {
 		return "", nil, ErrClusterContainerNotFound
 	}
-	removedContainers, err := cluster.RemoveContainers(metaData.MetaID, containerid)
+	removedContainers, err := cluster.RemoveContainers(metaData.MetaID, containerid, nil)
 	return metaData.MetaID, removedContainers, err
 }
 
 // RemoveContainers is exported
 // if containerid is empty string so remove metaid's all containers
-func (cluster *Cluster) RemoveContainers(metaid string, containerid string) (*types.RemovedContainers, error) {
+func (cluster *Cluster) RemoveContainers(metaid string, containerid string, job *Job) (*types.RemovedContainers, error) {
 
 	metaData, _, err := cluster.validateMetaData(metaid)
 	if err != nil {
@@ -724,25 +1148,37 @@ func (cluster *Cluster) RemoveContainers(metaid string, containerid string) (*ty
 		return nil, err
 	}
 
-	removedContainers := cluster.removeContainers(metaData, containerid)
+	removedContainers := cluster.removeContainers(metaData, containerid, job)
 	cluster.hooksProcessor.Hook(metaData, RemoveMetaEvent)
 	if metaData := cluster.configCache.GetMetaData(metaData.MetaID); metaData != nil {
 		if len(metaData.BaseConfigs) == 0 {
 			cluster.configCache.RemoveMetaData(metaData.MetaID)
 		}
 	}
+
+	if job.IsCanceled() {
+		//canceled, not removed containers are kept, recovery reconciles meta instances.
+		return removedContainers, ErrClusterJobCanceled
+	}
 	return removedContainers, nil
 }
 
 // RecoveryContainers is exported
-func (cluster *Cluster) RecoveryContainers(metaid string) error {
+// Return recovery created and removed containers count.
+func (cluster *Cluster) RecoveryContainers(metaid string) (int, int, error) {
 
 	metaData, engines, err := cluster.validateMetaData(metaid)
 	if err != nil {
 		logger.WARN("[#cluster#] recovery containers %s error, %s", metaid, err.Error())
-		return err
+		return 0, 0, err
 	}
 
+	if metaData.IsPaused() {
+		logger.WARN("[#cluster#] recovery containers %s skipped, meta is paused.", metaid)
+		return 0, 0, ErrClusterContainersPaused
+	}
+
+	removed := 0
 	baseConfigs := cluster.configCache.GetMetaDataBaseConfigs(metaData.MetaID)
 	for _, baseConfig := range baseConfigs {
 		found := false
@@ -755,23 +1191,30 @@ func (cluster *Cluster) RecoveryContainers(metaid string) error {
 		if !found { //clean meta invalid container.
 			cluster.configCache.RemoveContainerBaseConfig(metaData.MetaID, baseConfig.ID)
 			logger.WARN("[#cluster#] recovery containers %s remove invalid container %s", metaData.MetaID, baseConfig.ID[:12])
+			removed++
 		}
 	}
 
+	created := 0
 	if len(engines) > 0 {
 		baseConfigsCount := cluster.configCache.GetMetaDataBaseConfigsCount(metaData.MetaID)
 		if baseConfigsCount != -1 && metaData.Instances != baseConfigsCount {
 			var err error
 			if metaData.Instances > baseConfigsCount {
-				_, err = cluster.createContainers(metaData, metaData.Instances-baseConfigsCount, metaData.Config)
+				var createdContainers types.CreatedContainers
+				createdContainers, err = cluster.createContainers(metaData, metaData.Instances-baseConfigsCount, metaData.Config, nil)
+				created = len(createdContainers)
 			} else {
-				cluster.reduceContainers(metaData, baseConfigsCount-metaData.Instances)
+				removed = removed + cluster.reduceContainers(metaData, baseConfigsCount-metaData.Instances)
 			}
 			cluster.hooksProcessor.Hook(metaData, RecoveryMetaEvent)
 			cluster.NotifyGroupMetaContainersEvent("Cluster Meta Containers Recovered.", err, metaData.MetaID)
+			if err != nil {
+				return created, removed, err
+			}
 		}
 	}
-	return nil
+	return created, removed, nil
 }
 
 // UpdateContainers is exported
@@ -792,7 +1235,7 @@ func (cluster *Cluster) UpdateContainers(metaid string, instances int, webhooks
 	if len(engines) > 0 {
 		originalInstances := len(metaData.BaseConfigs)
 		if originalInstances < instances {
-			cluster.createContainers(metaData, instances-originalInstances, metaData.Config)
+			cluster.createContainers(metaData, instances-originalInstances, metaData.Config, nil)
 		} else {
 			cluster.reduceContainers(metaData, originalInstances-instances)
 		}
@@ -811,13 +1254,106 @@ func (cluster *Cluster) UpdateContainers(metaid string, instances int, webhooks
 	return &createdContainers, nil
 }
 
+// GetRecoveryRuns is exported
+// Return the last count recovery runs, count <= 0 return all kept runs.
+func (cluster *Cluster) GetRecoveryRuns(count int) []*RecoveryRun {
+
+	return cluster.metaRestorer.Runs(count)
+}
+
+// GetQuarantinedMetas is exported
+// Return metas quarantined by metastore, can not be decoded when loaded.
+func (cluster *Cluster) GetQuarantinedMetas() ([]*QuarantinedMeta, error) {
+
+	return cluster.configCache.GetQuarantinedMetas()
+}
+
+// WriteAudit is exported
+// Append an operation audit record, record groupid is empty, set groupid of record meta.
+func (cluster *Cluster) WriteAudit(record *AuditRecord) {
+
+	if record.GroupID == "" && record.MetaID != "" {
+		if metaData := cluster.configCache.GetMetaData(record.MetaID); metaData != nil {
+			record.GroupID = metaData.GroupID
+		}
+	}
+
+	if err := cluster.auditLog.Append(record); err != nil {
+		logger.ERROR("[#cluster#] write audit %s %s error, %s", record.Operation, record.MetaID, err.Error())
+	}
+}
+
+// QueryAudit is exported
+// Return filter matched audit records, newest first.
+func (cluster *Cluster) QueryAudit(filter *AuditFilter) ([]*AuditRecord, error) {
+
+	return cluster.auditLog.Query(filter)
+}
+
+// BeginIdempotent is exported
+// Return stored response record of idempotency key, nil record means key is new and in-flight.
+func (cluster *Cluster) BeginIdempotent(key string, fingerprint string) (*IdempotencyRecord, error) {
+
+	return cluster.idempotencyCache.Begin(key, fingerprint)
+}
+
+// CompleteIdempotent is exported
+// Store response of in-flight idempotency key.
+func (cluster *Cluster) CompleteIdempotent(key string, statusCode int, header map[string][]string, body []byte) {
+
+	if err := cluster.idempotencyCache.Complete(key, statusCode, header, body); err != nil {
+		logger.ERROR("[#cluster#] store idempotency key %s error, %s", key, err.Error())
+	}
+}
+
+// AbortIdempotent is exported
+// Release in-flight idempotency key, response is not stored.
+func (cluster *Cluster) AbortIdempotent(key string) {
+
+	cluster.idempotencyCache.Abort(key)
+}
+
+// RecoveryMetas is exported
+// Trigger a recovery run on demand.
+func (cluster *Cluster) RecoveryMetas() (*RecoveryRun, error) {
+
+	return cluster.metaRestorer.Trigger()
+}
+
+// SetContainersMaintenance is exported
+// pause or resume meta recovery and migration, expires is zero never expires.
+func (cluster *Cluster) SetContainersMaintenance(metaid string, paused bool, expires time.Duration) (*MetaBase, error) {
+
+	metaData := cluster.configCache.GetMetaData(metaid)
+	if metaData == nil {
+		logger.ERROR("[#cluster#] set containers maintenance %s error, %s", metaid, ErrClusterMetaDataNotFound)
+		return nil, ErrClusterMetaDataNotFound
+	}
+
+	var pauseExpires int64
+	if paused && expires > 0 {
+		pauseExpires = time.Now().Add(expires).UnixNano()
+	}
+
+	if ret := cluster.configCache.SetMetaDataPaused(metaData.MetaID, paused, pauseExpires); !ret {
+		logger.ERROR("[#cluster#] set containers maintenance %s error, %s", metaid, ErrClusterContainersMaintenanceFailure)
+		return nil, ErrClusterContainersMaintenanceFailure
+	}
+	logger.INFO("[#cluster#] set containers maintenance %s paused:%t expires:%s", metaid, paused, expires)
+	return &metaData.MetaBase, nil
+}
+
 // CreateContainers is exported
-func (cluster *Cluster) CreateContainers(groupid string, instances int, webhooks types.WebHooks, config models.Container) (string, *types.CreatedContainers, error) {
+func (cluster *Cluster) CreateContainers(groupid string, instances int, webhooks types.WebHooks, config models.Container, job *Job) (string, *types.CreatedContainers, error) {
 
 	if instances <= 0 {
 		return "", nil, ErrClusterContainersInstancesInvalid
 	}
 
+	if cluster.IsDraining() {
+		return "", nil, ErrClusterDraining
+	}
+
 	engines := cluster.GetGroupEngines(groupid)
 	if engines == nil {
 		logger.ERROR("[#cluster#] create containers error %s : %s", groupid, ErrClusterGroupNotFound)
@@ -840,21 +1376,43 @@ func (cluster *Cluster) CreateContainers(groupid string, instances int, webhooks
 		return "", nil, ErrClusterContainersMetaCreateFailure
 	}
 
-	createdContainers, err := cluster.createContainers(metaData, instances, config)
+	job.SetMetaID(metaData.MetaID)
+	createdContainers, err := cluster.createContainers(metaData, instances, config, job)
 	if len(createdContainers) == 0 {
 		cluster.configCache.RemoveMetaData(metaData.MetaID)
+		if job.IsCanceled() {
+			return "", nil, ErrClusterJobCanceled
+		}
+		if cluster.drainSignaled() {
+			return "", nil, ErrClusterDraining
+		}
 		var resultErr string
 		if err != nil {
 			resultErr = err.Error()
 		}
 		return "", nil, fmt.Errorf("%s, %s\n", ErrClusterCreateContainerFailure.Error(), resultErr)
 	}
+
+	if job.IsCanceled() && len(createdContainers) < instances {
+		//canceled, keep created containers, recovery does not create the rest.
+		cluster.configCache.SetMetaData(metaData.MetaID, len(createdContainers), webhooks)
+		cluster.hooksProcessor.Hook(metaData, CreateMetaEvent)
+		return metaData.MetaID, &createdContainers, ErrClusterJobCanceled
+	}
+
+	if cluster.drainSignaled() && len(createdContainers) < instances {
+		//draining, meta instances are kept, the next leader recovery creates the rest.
+		cluster.writeInterruptedAudit(AuditCreate, metaData.MetaID, map[string]interface{}{"Created": len(createdContainers)}, ErrClusterDraining)
+		cluster.hooksProcessor.Hook(metaData, CreateMetaEvent)
+		return metaData.MetaID, &createdContainers, ErrClusterDraining
+	}
 	cluster.hooksProcessor.Hook(metaData, CreateMetaEvent)
 	return metaData.MetaID, &createdContainers, nil
 }
 
 // reduceContainers is exported
-func (cluster *Cluster) reduceContainers(metaData *MetaData, instances int) {
+// Return reduced containers count.
+func (cluster *Cluster) reduceContainers(metaData *MetaData, instances int) int {
 
 	cluster.Lock()
 	cluster.pendingContainers[metaData.Config.Name] = &pendingContainer{
@@ -864,15 +1422,19 @@ func (cluster *Cluster) reduceContainers(metaData *MetaData, instances int) {
 	}
 	cluster.Unlock()
 
+	reduced := 0
 	for ; instances > 0; instances-- {
 		if _, _, err := cluster.reduceContainer(metaData); err != nil {
 			logger.ERROR("[#cluster#] reduce container %s, error:%s", metaData.Config.Name, err.Error())
+			continue
 		}
+		reduced++
 	}
 
 	cluster.Lock()
 	delete(cluster.pendingContainers, metaData.Config.Name)
 	cluster.Unlock()
+	return reduced
 }
 
 // reduceContainer is exported
@@ -899,7 +1461,8 @@ func (cluster *Cluster) reduceContainer(metaData *MetaData) (*Engine, *Container
 }
 
 // removeContainers is exported
-func (cluster *Cluster) removeContainers(metaData *MetaData, containerid string) *types.RemovedContainers {
+// job is canceled, stop removing at next container.
+func (cluster *Cluster) removeContainers(metaData *MetaData, containerid string, job *Job) *types.RemovedContainers {
 
 	cluster.Lock()
 	cluster.pendingContainers[metaData.Config.Name] = &pendingContainer{
@@ -911,13 +1474,25 @@ func (cluster *Cluster) removeContainers(metaData *MetaData, containerid string)
 
 	removedContainers := types.RemovedContainers{}
 	if engines := cluster.GetGroupEngines(metaData.GroupID); engines != nil {
+		completed, total := 0, 1
+		if containerid == "" {
+			total = 0
+			for _, engine := range engines {
+				total += len(engine.Containers(metaData.MetaID))
+			}
+		}
+
+		job.SetProgress(completed, total)
 		foundContainer := false
 		for _, engine := range engines {
-			if foundContainer {
+			if foundContainer || job.IsCanceled() {
 				break
 			}
 			containers := engine.Containers(metaData.MetaID)
 			for _, container := range containers {
+				if job.IsCanceled() {
+					break
+				}
 				if containerid == "" || container.Info.ID == containerid {
 					var err error
 					if engine.IsHealthy() {
@@ -928,6 +1503,8 @@ func (cluster *Cluster) removeContainers(metaData *MetaData, containerid string)
 						err = fmt.Errorf("engine state is %s", engine.State())
 					}
 					removedContainers = removedContainers.SetRemovedPair(engine.IP, engine.Name, container.Info.ID, err)
+					completed++
+					job.SetProgress(completed, total)
 				}
 				if container.Info.ID == containerid {
 					foundContainer = true
@@ -944,7 +1521,8 @@ func (cluster *Cluster) removeContainers(metaData *MetaData, containerid string)
 }
 
 // createContainers is exported
-func (cluster *Cluster) createContainers(metaData *MetaData, instances int, config models.Container) (types.CreatedContainers, error) {
+// job is canceled or cluster is draining, stop creating at next container.
+func (cluster *Cluster) createContainers(metaData *MetaData, instances int, config models.Container, job *Job) (types.CreatedContainers, error) {
 
 	cluster.Lock()
 	cluster.pendingContainers[config.Name] = &pendingContainer{
@@ -957,18 +1535,21 @@ func (cluster *Cluster) createContainers(metaData *MetaData, instances int, conf
 	var resultErr error
 	createdContainers := types.CreatedContainers{}
 	filter := NewEnginesFilter()
+	total := instances
 	for ; instances > 0; instances-- {
+		job.SetProgress(total-instances, total)
+		if job.IsCanceled() {
+			break
+		}
+		if cluster.drainSignaled() {
+			logger.WARN("[#cluster#] create containers %s stopped, cluster is draining, %d of %d created.", metaData.MetaID, len(createdContainers), total)
+			break
+		}
 		index := cluster.configCache.MakeContainerIdleIndex(metaData.MetaID)
 		if index < 0 {
 			continue
 		}
-		indexStr := strconv.Itoa(index)
-		containerConfig := config
-		containerConfig.Name = "CLUSTER-" + metaData.GroupID[:8] + "-" + containerConfig.Name + "-" + indexStr
-		containerConfig.Env = append(containerConfig.Env, "HUMPBACK_CLUSTER_GROUPID="+metaData.GroupID)
-		containerConfig.Env = append(containerConfig.Env, "HUMPBACK_CLUSTER_METAID="+metaData.MetaID)
-		containerConfig.Env = append(containerConfig.Env, "HUMPBACK_CLUSTER_CONTAINER_INDEX="+indexStr)
-		containerConfig.Env = append(containerConfig.Env, "HUMPBACK_CLUSTER_CONTAINER_ORIGINALNAME="+containerConfig.Name)
+		containerConfig := newContainerConfig(metaData, config, index)
 		engine, container, err := cluster.createContainer(metaData, filter, containerConfig)
 		if err != nil {
 			if err == ErrClusterNoEngineAvailable || strings.Index(err.Error(), " not found") >= 0 {
@@ -993,6 +1574,7 @@ func (cluster *Cluster) createContainers(metaData *MetaData, instances int, conf
 		}
 		createdContainers = createdContainers.SetCreatedPair(engine.IP, engine.Name, container.Config.Container)
 	}
+	job.SetProgress(total-instances, total)
 
 	cluster.Lock()
 	delete(cluster.pendingContainers, config.Name)
@@ -1000,6 +1582,20 @@ func (cluster *Cluster) createContainers(metaData *MetaData, instances int, conf
 	return createdContainers, resultErr
 }
 
+// newContainerConfig, returns config of meta container instance index.
+func newContainerConfig(metaData *MetaData, config models.Container, index int) models.Container {
+
+	indexStr := strconv.Itoa(index)
+	containerConfig := config
+	containerConfig.Name = "CLUSTER-" + metaData.GroupID[:8] + "-" + containerConfig.Name + "-" + indexStr
+	containerConfig.Env = append([]string{}, config.Env...)
+	containerConfig.Env = append(containerConfig.Env, "HUMPBACK_CLUSTER_GROUPID="+metaData.GroupID)
+	containerConfig.Env = append(containerConfig.Env, "HUMPBACK_CLUSTER_METAID="+metaData.MetaID)
+	containerConfig.Env = append(containerConfig.Env, "HUMPBACK_CLUSTER_CONTAINER_INDEX="+indexStr)
+	containerConfig.Env = append(containerConfig.Env, "HUMPBACK_CLUSTER_CONTAINER_ORIGINALNAME="+containerConfig.Name)
+	return containerConfig
+}
+
 // createContainer is exported
 func (cluster *Cluster) createContainer(metaData *MetaData, filter *EnginesFilter, config models.Container) (*Engine, *Container, error) {
 
@@ -1096,6 +1692,10 @@ func (cluster *Cluster) cehckContainerNameUniqueness(groupid string, name string
 // validateMetaData is exported
 func (cluster *Cluster) validateMetaData(metaid string) (*MetaData, []*Engine, error) {
 
+	if cluster.IsDraining() {
+		return nil, nil, ErrClusterDraining
+	}
+
 	metaData, engines, err := cluster.GetMetaDataEngines(metaid)
 	if err != nil {
 		return nil, nil, err
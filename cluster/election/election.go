@@ -0,0 +1,143 @@
+package election
+
+import "github.com/humpback/gounits/logger"
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrElectionLeaderNotFound is exported
+	ErrElectionLeaderNotFound = errors.New("election leader not found")
+)
+
+const (
+	// campaign retry interval after lock error or leadership lost
+	campaignRetryInterval = 3 * time.Second
+)
+
+// Backend is exported
+// election backend, a distributed lock with the leader candidate value.
+// Campaign: block until candidate become leader or stopCh closed, return a channel closed when leadership lost.
+// Resign: candidate release leadership.
+// Leader: return current leader candidate.
+type Backend interface {
+	Campaign(candidate string, stopCh chan struct{}) (<-chan struct{}, error)
+	Resign(candidate string) error
+	Leader() (string, error)
+}
+
+// LeaderHandleFunc is exported
+// call when candidate leadership changed.
+type LeaderHandleFunc func(leading bool)
+
+// Elector is exported
+// Candidate: instance identity, other instances use it to reach the leader, eg: http://192.168.2.80:8589
+type Elector struct {
+	sync.RWMutex
+	Candidate string
+	backend   Backend
+	leading   bool
+	handler   LeaderHandleFunc
+	stopCh    chan struct{}
+	doneCh    chan struct{}
+}
+
+// NewElector is exported
+func NewElector(backend Backend, candidate string, handler LeaderHandleFunc) *Elector {
+
+	return &Elector{
+		Candidate: candidate,
+		backend:   backend,
+		handler:   handler,
+		stopCh:    make(chan struct{}),
+		doneCh:    make(chan struct{}),
+	}
+}
+
+// Start is exported
+func (elector *Elector) Start() {
+
+	go elector.doLoop()
+}
+
+// Stop is exported
+// stop campaign and resign leadership.
+func (elector *Elector) Stop() {
+
+	close(elector.stopCh)
+	<-elector.doneCh
+}
+
+// IsLeader is exported
+func (elector *Elector) IsLeader() bool {
+
+	elector.RLock()
+	defer elector.RUnlock()
+	return elector.leading
+}
+
+// Leader is exported
+// Return current leader candidate.
+func (elector *Elector) Leader() (string, error) {
+
+	if elector.IsLeader() {
+		return elector.Candidate, nil
+	}
+
+	leader, err := elector.backend.Leader()
+	if err != nil {
+		return "", err
+	}
+
+	if leader == "" {
+		return "", ErrElectionLeaderNotFound
+	}
+	return leader, nil
+}
+
+func (elector *Elector) doLoop() {
+
+	defer close(elector.doneCh)
+	for {
+		lostCh, err := elector.backend.Campaign(elector.Candidate, elector.stopCh)
+		if err != nil {
+			logger.ERROR("[#cluster#] election %s campaign error, %s", elector.Candidate, err.Error())
+		} else if lostCh != nil {
+			elector.setLeading(true)
+			select {
+			case <-lostCh:
+				logger.WARN("[#cluster#] election %s leadership lost.", elector.Candidate)
+				elector.setLeading(false)
+			case <-elector.stopCh:
+				elector.setLeading(false)
+				if err := elector.backend.Resign(elector.Candidate); err != nil {
+					logger.ERROR("[#cluster#] election %s resign error, %s", elector.Candidate, err.Error())
+				}
+				return
+			}
+		}
+
+		select {
+		case <-elector.stopCh:
+			return
+		case <-time.After(campaignRetryInterval):
+		}
+	}
+}
+
+func (elector *Elector) setLeading(leading bool) {
+
+	elector.Lock()
+	changed := elector.leading != leading
+	elector.leading = leading
+	elector.Unlock()
+	if changed {
+		logger.INFO("[#cluster#] election %s leading:%t", elector.Candidate, leading)
+		if elector.handler != nil {
+			elector.handler(leading)
+		}
+	}
+}
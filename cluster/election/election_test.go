@@ -0,0 +1,70 @@
+package election
+
+import (
+	"testing"
+	"time"
+)
+
+// waitLeading, wait elector leading state changed to leading.
+func waitLeading(t *testing.T, elector *Elector, leading bool) {
+
+	deadline := time.Now().Add(5 * time.Second)
+	for elector.IsLeader() != leading {
+		if time.Now().After(deadline) {
+			t.Fatalf("elector %s leading not %t", elector.Candidate, leading)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestElectorFailover(t *testing.T) {
+
+	backend := NewMemoryBackend()
+	changes := make(chan bool, 4)
+	first := NewElector(backend, "http://center1:8589", nil)
+	second := NewElector(backend, "http://center2:8589", func(leading bool) { changes <- leading })
+
+	first.Start()
+	waitLeading(t, first, true)
+	second.Start()
+	defer second.Stop()
+
+	if second.IsLeader() {
+		t.Fatal("follower is leader while leader running")
+	}
+
+	if leader, err := second.Leader(); err != nil || leader != first.Candidate {
+		t.Fatalf("follower leader %s, %v, want %s", leader, err, first.Candidate)
+	}
+
+	first.Stop()
+	waitLeading(t, second, true)
+	if leader, err := backend.Leader(); err != nil || leader != second.Candidate {
+		t.Fatalf("leader after failover %s, %v, want %s", leader, err, second.Candidate)
+	}
+
+	select {
+	case leading := <-changes:
+		if !leading {
+			t.Fatal("follower handler called with leading false")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("follower handler not called")
+	}
+}
+
+func TestElectorLeadershipLost(t *testing.T) {
+
+	backend := NewMemoryBackend()
+	first := NewElector(backend, "http://center1:8589", nil)
+	second := NewElector(backend, "http://center2:8589", nil)
+	first.Start()
+	defer first.Stop()
+	waitLeading(t, first, true)
+	second.Start()
+	defer second.Stop()
+
+	backend.Expire()
+	waitLeading(t, first, false)
+	waitLeading(t, second, true)
+}
@@ -0,0 +1,83 @@
+package election
+
+import "github.com/docker/libkv/store"
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// KVBackend is exported
+// election lock of discovery kv backend, lock key value is the leader candidate.
+type KVBackend struct {
+	sync.Mutex
+	Key    string
+	ttl    time.Duration
+	kv     store.Store
+	locker store.Locker
+}
+
+// NewKVBackend is exported
+// key: lock key, eg: humpback/center/leader
+func NewKVBackend(kv store.Store, key string, ttl time.Duration) *KVBackend {
+
+	return &KVBackend{
+		Key: strings.Trim(strings.TrimSpace(key), "/"),
+		ttl: ttl,
+		kv:  kv,
+	}
+}
+
+// Campaign is exported
+func (backend *KVBackend) Campaign(candidate string, stopCh chan struct{}) (<-chan struct{}, error) {
+
+	locker, err := backend.kv.NewLock(backend.Key, &store.LockOptions{
+		Value: []byte(candidate),
+		TTL:   backend.ttl,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	lostCh, err := locker.Lock(stopCh)
+	if err != nil {
+		return nil, err
+	}
+
+	if lostCh == nil {
+		// stopCh closed before lock acquired.
+		return nil, nil
+	}
+
+	backend.Lock()
+	backend.locker = locker
+	backend.Unlock()
+	return lostCh, nil
+}
+
+// Resign is exported
+func (backend *KVBackend) Resign(candidate string) error {
+
+	backend.Lock()
+	locker := backend.locker
+	backend.locker = nil
+	backend.Unlock()
+	if locker == nil {
+		return nil
+	}
+	return locker.Unlock()
+}
+
+// Leader is exported
+func (backend *KVBackend) Leader() (string, error) {
+
+	pair, err := backend.kv.Get(backend.Key)
+	if err != nil {
+		if err == store.ErrKeyNotFound {
+			return "", ErrElectionLeaderNotFound
+		}
+		return "", err
+	}
+	return string(pair.Value), nil
+}
@@ -0,0 +1,88 @@
+package election
+
+import (
+	"sync"
+)
+
+// MemoryBackend is exported
+// in-process election lock, shared by electors of one process.
+// use for single instance and failover tests.
+type MemoryBackend struct {
+	sync.Mutex
+	leader    string
+	lostCh    chan struct{}
+	releaseCh chan struct{}
+}
+
+// NewMemoryBackend is exported
+func NewMemoryBackend() *MemoryBackend {
+
+	return &MemoryBackend{
+		releaseCh: make(chan struct{}),
+	}
+}
+
+// Campaign is exported
+func (backend *MemoryBackend) Campaign(candidate string, stopCh chan struct{}) (<-chan struct{}, error) {
+
+	for {
+		backend.Lock()
+		if backend.leader == "" {
+			backend.leader = candidate
+			backend.lostCh = make(chan struct{})
+			lostCh := backend.lostCh
+			backend.Unlock()
+			return lostCh, nil
+		}
+		releaseCh := backend.releaseCh
+		backend.Unlock()
+
+		select {
+		case <-releaseCh:
+		case <-stopCh:
+			return nil, nil
+		}
+	}
+}
+
+// Resign is exported
+func (backend *MemoryBackend) Resign(candidate string) error {
+
+	backend.Lock()
+	defer backend.Unlock()
+	if backend.leader == candidate {
+		backend.release()
+	}
+	return nil
+}
+
+// Leader is exported
+func (backend *MemoryBackend) Leader() (string, error) {
+
+	backend.Lock()
+	defer backend.Unlock()
+	if backend.leader == "" {
+		return "", ErrElectionLeaderNotFound
+	}
+	return backend.leader, nil
+}
+
+// Expire is exported
+// simulate current leader session expired, leader lost leadership and others campaign.
+func (backend *MemoryBackend) Expire() {
+
+	backend.Lock()
+	defer backend.Unlock()
+	if backend.leader != "" {
+		backend.release()
+	}
+}
+
+func (backend *MemoryBackend) release() {
+
+	close(backend.lostCh)
+	close(backend.releaseCh)
+	backend.leader = ""
+	backend.lostCh = nil
+	backend.releaseCh = make(chan struct{})
+}
@@ -7,6 +7,7 @@ import "github.com/humpback/gounits/http"
 import "github.com/humpback/gounits/logger"
 import ctypes "humpback-center/cluster/types"
 import "common/models"
+import "humpback-center/metrics"
 
 import (
 	"bytes"
@@ -70,13 +71,15 @@ type Engine struct {
 	overcommitRatio int64
 	client          *http.HttpClient
 	configCache     *ContainersConfigCache
+	events          *EventStream
 	containers      map[string]*Container
 	stopCh          chan struct{}
 	state           engineState
 }
 
 // NewEngine is exported
-func NewEngine(nodeData *NodeData, overcommitRatio float64, configCache *ContainersConfigCache) (*Engine, error) {
+// events publish engine containers state changes, nil is disabled.
+func NewEngine(nodeData *NodeData, overcommitRatio float64, configCache *ContainersConfigCache, events *EventStream) (*Engine, error) {
 
 	ipAddr, err := net.ResolveIPAddr("ip4", nodeData.IP)
 	if err != nil {
@@ -95,6 +98,7 @@ func NewEngine(nodeData *NodeData, overcommitRatio float64, configCache *Contain
 		overcommitRatio: int64(overcommitRatio * 100),
 		client:          http.NewWithTimeout(requestTimeout),
 		configCache:     configCache,
+		events:          events,
 		containers:      make(map[string]*Container),
 		state:           StatePending,
 	}, nil
@@ -322,7 +326,9 @@ func (engine *Engine) CreateContainer(config models.Container) (*Container, erro
 	}
 
 	header := map[string][]string{"Content-Type": []string{"application/json"}}
+	start := time.Now()
 	respCreated, err := engine.client.Post("http://"+engine.APIAddr+"/v1/containers", nil, buf, header)
+	metrics.ObserveAgentRequest("createcontainer", start, agentRequestError(respCreated, err))
 	if err != nil {
 		return nil, err
 	}
@@ -392,7 +398,9 @@ func (engine *Engine) RemoveContainer(containerid string) error {
 
 	//delete container
 	query := map[string][]string{"force": []string{"true"}}
+	start := time.Now()
 	respRemoved, err := engine.client.Delete("http://"+engine.APIAddr+"/v1/containers/"+containerid, query, nil)
+	metrics.ObserveAgentRequest("removecontainer", start, agentRequestError(respRemoved, err))
 	if err != nil {
 		return err
 	}
@@ -423,7 +431,9 @@ func (engine *Engine) OperateContainer(operate models.ContainerOperate) error {
 	}
 
 	header := map[string][]string{"Content-Type": []string{"application/json"}}
+	start := time.Now()
 	respOperated, err := engine.client.Put("http://"+engine.APIAddr+"/v1/containers", nil, buf, header)
+	metrics.ObserveAgentRequest("operatecontainer", start, agentRequestError(respOperated, err))
 	if err != nil {
 		return err
 	}
@@ -467,7 +477,9 @@ func (engine *Engine) UpgradeContainer(operate models.ContainerOperate) (*Contai
 	}
 
 	header := map[string][]string{"Content-Type": []string{"application/json"}}
+	start := time.Now()
 	respUpgraded, err := engine.client.Put("http://"+engine.APIAddr+"/v1/containers", nil, buf, header)
+	metrics.ObserveAgentRequest("upgradecontainer", start, agentRequestError(respUpgraded, err))
 	if err != nil {
 		return nil, err
 	}
@@ -509,7 +521,9 @@ func (engine *Engine) UpgradeContainer(operate models.ContainerOperate) (*Contai
 func (engine *Engine) RefreshContainers() error {
 
 	query := map[string][]string{"all": []string{"true"}}
+	start := time.Now()
 	respContainers, err := engine.client.Get("http://"+engine.APIAddr+"/v1/containers", query, nil)
+	metrics.ObserveAgentRequest("containers", start, agentRequestError(respContainers, err))
 	if err != nil {
 		return err
 	}
@@ -525,6 +539,7 @@ func (engine *Engine) RefreshContainers() error {
 	}
 
 	logger.INFO("[#cluster#] engine %s %s refresh containers.", engine.IP, engine.State())
+	previous := engine.containersState()
 	merged := make(map[string]*Container)
 	for _, container := range dockerContainers {
 		mergedUpdate, err := engine.updateContainer(container.ID, merged)
@@ -538,6 +553,9 @@ func (engine *Engine) RefreshContainers() error {
 	engine.Lock()
 	engine.containers = merged
 	engine.Unlock()
+	if !engine.IsPending() {
+		engine.publishContainersState(previous)
+	}
 	return nil
 }
 
@@ -622,7 +640,9 @@ func (engine *Engine) refreshLoop() {
 // updateSpecs exported
 func (engine *Engine) updateSpecs() error {
 
+	start := time.Now()
 	respSpecs, err := engine.client.Get("http://"+engine.APIAddr+"/v1/dockerinfo", nil, nil)
+	metrics.ObserveAgentRequest("dockerinfo", start, agentRequestError(respSpecs, err))
 	if err != nil {
 		return err
 	}
@@ -681,7 +701,9 @@ func (engine *Engine) updateContainer(containerid string, containers map[string]
 	engine.RUnlock()
 
 	query := map[string][]string{"originaldata": []string{"true"}}
+	start := time.Now()
 	respContainer, err := engine.client.Get("http://"+engine.APIAddr+"/v1/containers/"+containerid, query, nil)
+	metrics.ObserveAgentRequest("container", start, agentRequestError(respContainer, err))
 	if err != nil {
 		return nil, err
 	}
@@ -701,3 +723,83 @@ func (engine *Engine) updateContainer(containerid string, containers map[string]
 	engine.Unlock()
 	return containers, nil
 }
+
+// agentRequestError return err or agent response status error.
+func agentRequestError(response *http.Response, err error) error {
+
+	if err != nil {
+		return err
+	}
+	if response.StatusCode() != 200 {
+		return fmt.Errorf("agent response status %d", response.StatusCode())
+	}
+	return nil
+}
+
+// containerState is engine cluster container state of a refresh.
+type containerState struct {
+	GroupID string
+	MetaID  string
+	State   string
+}
+
+// containersState return engine cluster containers state.
+func (engine *Engine) containersState() map[string]containerState {
+
+	engine.RLock()
+	defer engine.RUnlock()
+	states := map[string]containerState{}
+	for containerid, container := range engine.containers {
+		if metaid := container.MetaID(); metaid != "" {
+			states[containerid] = containerState{
+				GroupID: container.GroupID(),
+				MetaID:  metaid,
+				State:   containerStateText(container),
+			}
+		}
+	}
+	return states
+}
+
+// publishContainersState publish cluster containers state changed of previous refresh, removed containers state is Removed.
+func (engine *Engine) publishContainersState(previous map[string]containerState) {
+
+	if engine.events == nil {
+		return
+	}
+
+	current := engine.containersState()
+	for containerid, state := range current {
+		if prevState, ret := previous[containerid]; !ret || prevState.State != state.State {
+			engine.publishContainerState(containerid, state)
+		}
+	}
+
+	for containerid, state := range previous {
+		if _, ret := current[containerid]; !ret {
+			state.State = "Removed"
+			engine.publishContainerState(containerid, state)
+		}
+	}
+}
+
+func (engine *Engine) publishContainerState(containerid string, state containerState) {
+
+	engine.events.Publish(&ClusterEvent{
+		Type:        ContainerStateEvent,
+		GroupIDs:    []string{state.GroupID},
+		MetaID:      state.MetaID,
+		ContainerID: containerid,
+		IP:          engine.IP,
+		Name:        engine.Name,
+		State:       state.State,
+	})
+}
+
+func containerStateText(container *Container) string {
+
+	if container.Info.ContainerJSONBase == nil || container.Info.State == nil {
+		return ""
+	}
+	return StateString(container.Info.State)
+}
@@ -48,6 +48,19 @@ func (pool *EnginesPool) Release() {
 	pool.Unlock()
 }
 
+// Engines is exported
+// Returns all pool engines, contains pending and closed engines.
+func (pool *EnginesPool) Engines() []*Engine {
+
+	pool.RLock()
+	defer pool.RUnlock()
+	engines := []*Engine{}
+	for _, engine := range pool.poolEngines {
+		engines = append(engines, engine)
+	}
+	return engines
+}
+
 // AddEngine is exported
 func (pool *EnginesPool) AddEngine(ip string, name string) {
 
@@ -84,14 +97,16 @@ func (pool *EnginesPool) AddEngine(ip string, name string) {
 	if ret {
 		poolEngine.Update(nodeData)
 		poolEngine.SetState(StatePending)
+		pool.Cluster.publishEngineState(poolEngine)
 		logger.INFO("[#cluster#] addengine, pool engine reused %s %s %s.", poolEngine.IP, poolEngine.Name, poolEngine.State())
 	} else {
 		var err error
-		poolEngine, err = NewEngine(nodeData, pool.Cluster.overcommitRatio, pool.Cluster.configCache)
+		poolEngine, err = NewEngine(nodeData, pool.Cluster.overcommitRatio, pool.Cluster.configCache, pool.Cluster.events)
 		if err != nil {
 			return
 		}
 		pool.poolEngines[poolEngine.IP] = poolEngine
+		pool.Cluster.publishEngineState(poolEngine)
 		logger.INFO("[#cluster#] addengine, pool engine create %s %s %s.", poolEngine.IP, poolEngine.Name, poolEngine.State())
 	}
 	pool.pendEngines[poolEngine.IP] = poolEngine
@@ -136,6 +151,7 @@ func (pool *EnginesPool) doLoop() {
 								pool.Cluster.Lock()
 								pool.Cluster.engines[engine.IP] = engine
 								pool.Cluster.Unlock()
+								pool.Cluster.publishEngineState(engine)
 								logger.INFO("[#cluster#] engine %s %s %s", engine.IP, engine.Name, engine.State())
 							}
 							wgroup.Done()
@@ -145,6 +161,7 @@ func (pool *EnginesPool) doLoop() {
 						go func(engine *Engine) {
 							pool.Cluster.migtatorCache.Start(engine)
 							engine.Close()
+							pool.Cluster.publishEngineState(engine)
 							logger.INFO("[#cluster#] engine %s %s %s", engine.IP, engine.Name, engine.State())
 							wgroup.Done()
 						}(pendEngine)
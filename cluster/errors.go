@@ -28,6 +28,44 @@ var (
 	ErrClusterContainersMigrating = errors.New("cluster containers state is migrating")
 	//cluster containers is setting
 	ErrClusterContainersSetting = errors.New("cluster containers state is setting")
+	//cluster containers meta is paused(maintenance mode)
+	ErrClusterContainersPaused = errors.New("cluster containers meta is paused")
+	//cluster containers meta set maintenance failure
+	ErrClusterContainersMaintenanceFailure = errors.New("cluster containers meta set maintenance failure")
+	//cluster recovery run is already running
+	ErrClusterRecoveryRunning = errors.New("cluster recovery is already running")
+	//cluster metadata checksum or decode invalid
+	ErrClusterMetaDataCorrupted = errors.New("cluster metadata corrupted")
+	//cluster metadata archive invalid
+	ErrClusterMetaDataArchiveInvalid = errors.New("cluster metadata archive invalid")
+	//cluster metadata import mode invalid
+	ErrClusterMetaDataImportModeInvalid = errors.New("cluster metadata import mode invalid")
+	//cluster metadata import metaid or name conflict
+	ErrClusterMetaDataImportConflict = errors.New("cluster metadata import conflict")
+	//cluster metadata import failure
+	ErrClusterMetaDataImportFailure = errors.New("cluster metadata import failure")
+	//cluster election advertise address invalid
+	ErrClusterAdvertiseInvalid = errors.New("cluster election advertise invalid, can not be empty")
+	//cluster is not the leader
+	ErrClusterNotLeader = errors.New("cluster is not the leader")
+	//cluster is draining, shutting down
+	ErrClusterDraining = errors.New("cluster is shutting down, not accept mutating operations")
+	//cluster drain timeout
+	ErrClusterDrainTimeout = errors.New("cluster drain timeout")
 	//cluster containers instances no change
 	ErrClusterContainersInstancesNoChange = errors.New("cluster containers instances no change")
+	//cluster job not found
+	ErrClusterJobNotFound = errors.New("cluster job not found")
+	//cluster job is finished, can not cancel
+	ErrClusterJobFinished = errors.New("cluster job is finished")
+	//cluster job is canceled
+	ErrClusterJobCanceled = errors.New("cluster job is canceled")
+	//cluster upgrade image not found in registry
+	ErrClusterImageNotFound = errors.New("cluster upgrade image not found in registry")
+	//cluster engines pre-pull image failure
+	ErrClusterImagePullFailure = errors.New("cluster engines pre-pull image failure")
+	//cluster idempotency key is reused with a different request
+	ErrClusterIdempotencyKeyConflict = errors.New("cluster idempotency key is already used by a different request")
+	//cluster idempotency key request is in progress
+	ErrClusterIdempotencyKeyInProgress = errors.New("cluster idempotency key request is in progress")
 )
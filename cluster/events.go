@@ -0,0 +1,221 @@
+package cluster
+
+import (
+	"sync"
+	"time"
+)
+
+// cluster stream event types, meta lifecycle events use HookEvent text.
+const (
+	EngineStateEvent     = "EngineStateEvent"
+	ContainerStateEvent  = "ContainerStateEvent"
+	UpgradeProgressEvent = "UpgradeProgressEvent"
+	MigrateProgressEvent = "MigrateProgressEvent"
+)
+
+// IsEventType is exported
+// event type is a stream event type or a meta lifecycle HookEvent text.
+func IsEventType(eventType string) bool {
+
+	switch eventType {
+	case EngineStateEvent, ContainerStateEvent, UpgradeProgressEvent, MigrateProgressEvent:
+		return true
+	}
+
+	for event := CreateMetaEvent; event <= RecoveryMetaEvent; event++ {
+		if event.String() == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// subscriber channel size, a slow subscriber is closed when channel is full.
+const eventSubscriberSize = 256
+
+/*
+ClusterEvent is exported
+ID: resumable event id, increase in a center process, seeded by start time.
+GroupIDs: engine events contains all groups of engine.
+Completed & Total: upgrade and migrate progress containers.
+*/
+type ClusterEvent struct {
+	ID          uint64   `json:"Id"`
+	Type        string   `json:"Type"`
+	Timestamp   int64    `json:"Timestamp"`
+	GroupIDs    []string `json:"GroupIds"`
+	MetaID      string   `json:"MetaId,omitempty"`
+	ContainerID string   `json:"ContainerId,omitempty"`
+	IP          string   `json:"IP,omitempty"`
+	Name        string   `json:"Name,omitempty"`
+	State       string   `json:"State,omitempty"`
+	Message     string   `json:"Message,omitempty"`
+	Completed   int      `json:"Completed,omitempty"`
+	Total       int      `json:"Total,omitempty"`
+}
+
+// EventFilter is exported
+// empty field matches all.
+type EventFilter struct {
+	GroupID string
+	MetaID  string
+	Types   []string
+}
+
+// Match is exported
+func (filter *EventFilter) Match(event *ClusterEvent) bool {
+
+	if filter == nil {
+		return true
+	}
+
+	if filter.MetaID != "" && filter.MetaID != event.MetaID {
+		return false
+	}
+
+	if filter.GroupID != "" {
+		found := false
+		for _, groupid := range event.GroupIDs {
+			if groupid == filter.GroupID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(filter.Types) > 0 {
+		for _, eventType := range filter.Types {
+			if eventType == event.Type {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// EventSubscriber is exported
+// C is closed when unsubscribed or subscriber too slow, resume with last received event id.
+type EventSubscriber struct {
+	C      chan *ClusterEvent
+	filter *EventFilter
+}
+
+// EventStream is exported
+// bounded ring buffer of cluster events and subscribers.
+type EventStream struct {
+	sync.Mutex
+	nextID      uint64
+	events      []*ClusterEvent
+	head        int
+	count       int
+	closed      bool
+	subscribers map[*EventSubscriber]bool
+}
+
+// NewEventStream is exported
+func NewEventStream(size int) *EventStream {
+
+	return &EventStream{
+		nextID:      uint64(time.Now().UnixNano()),
+		events:      make([]*ClusterEvent, size),
+		subscribers: make(map[*EventSubscriber]bool),
+	}
+}
+
+// Publish is exported
+// set event id and timestamp, push to buffer and matched subscribers.
+func (stream *EventStream) Publish(event *ClusterEvent) {
+
+	if stream == nil {
+		return
+	}
+
+	stream.Lock()
+	defer stream.Unlock()
+	stream.nextID++
+	event.ID = stream.nextID
+	event.Timestamp = time.Now().UnixNano()
+	if event.GroupIDs == nil {
+		event.GroupIDs = []string{}
+	}
+
+	if len(stream.events) > 0 {
+		index := (stream.head + stream.count) % len(stream.events)
+		stream.events[index] = event
+		if stream.count < len(stream.events) {
+			stream.count++
+		} else {
+			stream.head = (stream.head + 1) % len(stream.events)
+		}
+	}
+
+	for subscriber := range stream.subscribers {
+		if !subscriber.filter.Match(event) {
+			continue
+		}
+		select {
+		case subscriber.C <- event:
+		default:
+			delete(stream.subscribers, subscriber)
+			close(subscriber.C)
+		}
+	}
+}
+
+// Subscribe is exported
+// return buffered events after lastID, lastID is 0 return none.
+// if lastID is expired from buffer, return all buffered events.
+// stream is closed, subscriber channel is closed.
+func (stream *EventStream) Subscribe(lastID uint64, filter *EventFilter) (*EventSubscriber, []*ClusterEvent) {
+
+	subscriber := &EventSubscriber{
+		C:      make(chan *ClusterEvent, eventSubscriberSize),
+		filter: filter,
+	}
+
+	stream.Lock()
+	defer stream.Unlock()
+	events := []*ClusterEvent{}
+	if lastID > 0 {
+		for i := 0; i < stream.count; i++ {
+			event := stream.events[(stream.head+i)%len(stream.events)]
+			if event.ID > lastID && filter.Match(event) {
+				events = append(events, event)
+			}
+		}
+	}
+	if stream.closed {
+		close(subscriber.C)
+	} else {
+		stream.subscribers[subscriber] = true
+	}
+	return subscriber, events
+}
+
+// Unsubscribe is exported
+func (stream *EventStream) Unsubscribe(subscriber *EventSubscriber) {
+
+	stream.Lock()
+	defer stream.Unlock()
+	if _, ret := stream.subscribers[subscriber]; ret {
+		delete(stream.subscribers, subscriber)
+		close(subscriber.C)
+	}
+}
+
+// Close is exported
+// close all subscribers, new subscribers are closed immediately, events are still buffered.
+func (stream *EventStream) Close() {
+
+	stream.Lock()
+	defer stream.Unlock()
+	stream.closed = true
+	for subscriber := range stream.subscribers {
+		delete(stream.subscribers, subscriber)
+		close(subscriber.C)
+	}
+}
@@ -3,10 +3,12 @@ package cluster
 import "github.com/humpback/gounits/http"
 import "github.com/humpback/gounits/logger"
 import "common/models"
+import "humpback-center/metrics"
 
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"sync"
 	"time"
 )
@@ -108,9 +110,15 @@ func (hook *Hook) Post(pool *sync.Pool) {
 		resp, err := http.NewWithTimeout(requestTimeout).Post(metaWebHook.URL, nil, buf, header)
 		if err != nil {
 			pool.Put(buf)
+			metrics.ObserveWebHook(hook.Event, err)
 			logger.ERROR("[#cluster#] webhook %s post %s to %s, http error:%s", hook.Event, hook.MetaBase.MetaID, metaWebHook.URL, err.Error())
 			continue
 		}
+		if resp.StatusCode() >= 200 && resp.StatusCode() < 300 {
+			metrics.ObserveWebHook(hook.Event, nil)
+		} else {
+			metrics.ObserveWebHook(hook.Event, fmt.Errorf("http code %d", resp.StatusCode()))
+		}
 		resp.Close()
 		pool.Put(buf)
 		logger.INFO("[#cluster#] webhook %s post %s to %s, http code %d", hook.Event, hook.MetaBase.MetaID, metaWebHook.URL, resp.StatusCode())
@@ -142,8 +150,17 @@ func (processor *HooksProcessor) SetCluster(cluster *Cluster) {
 }
 
 // Hook is exported
+// meta lifecycle events are also published to cluster event stream.
 func (processor *HooksProcessor) Hook(metaData *MetaData, hookEvent HookEvent) {
 
+	if metaData != nil {
+		processor.Cluster.events.Publish(&ClusterEvent{
+			Type:     hookEvent.String(),
+			GroupIDs: []string{metaData.GroupID},
+			MetaID:   metaData.MetaID,
+		})
+	}
+
 	if metaData != nil && len(metaData.WebHooks) > 0 {
 		timeStamp := time.Now().UnixNano()
 		hook := NewHook(processor.Cluster, metaData, timeStamp, hookEvent)
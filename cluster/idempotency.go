@@ -0,0 +1,178 @@
+package cluster
+
+import "github.com/humpback/gounits/logger"
+import "github.com/humpback/gounits/system"
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// idempotency records expired sweep interval
+const idempotencySweepInterval = time.Minute
+
+/*
+IdempotencyRecord is exported
+Key: caller scoped idempotency key.
+Fingerprint: hash of request method, uri and body, a key reused with other request is conflict.
+StatusCode & Header & Body: stored response, replayed for duplicate key.
+*/
+type IdempotencyRecord struct {
+	Key         string              `json:"Key"`
+	Fingerprint string              `json:"Fingerprint"`
+	StatusCode  int                 `json:"StatusCode"`
+	Header      map[string][]string `json:"Header"`
+	Body        []byte              `json:"Body"`
+	Created     time.Time           `json:"Created"`
+	Expires     time.Time           `json:"Expires"`
+}
+
+// IdempotencyCache is exported
+// records persisted under cache root, one file per key, in-flight keys are memory only.
+type IdempotencyCache struct {
+	sync.Mutex
+	root     string
+	ttl      time.Duration
+	swept    time.Time
+	records  map[string]*IdempotencyRecord
+	inflight map[string]string
+}
+
+// NewIdempotencyCache is exported
+func NewIdempotencyCache(root string, ttl time.Duration) (*IdempotencyCache, error) {
+
+	idempotencyRoot := filepath.Join(root, "idempotency")
+	if err := system.MakeDirectory(idempotencyRoot); err != nil {
+		return nil, fmt.Errorf("idempotency directory init error:%s", err.Error())
+	}
+
+	cache := &IdempotencyCache{
+		root:     idempotencyRoot,
+		ttl:      ttl,
+		swept:    time.Now(),
+		records:  make(map[string]*IdempotencyRecord),
+		inflight: make(map[string]string),
+	}
+
+	files, err := ioutil.ReadDir(idempotencyRoot)
+	if err != nil {
+		return nil, fmt.Errorf("idempotency directory read error:%s", err.Error())
+	}
+
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(idempotencyRoot, file.Name())
+		record := &IdempotencyRecord{}
+		buf, err := ioutil.ReadFile(path)
+		if err != nil || json.Unmarshal(buf, record) != nil || time.Now().After(record.Expires) {
+			os.Remove(path)
+			continue
+		}
+		cache.records[record.Key] = record
+	}
+	logger.INFO("[#cluster#] idempotency cache loaded %d records, ttl %s", len(cache.records), ttl)
+	return cache, nil
+}
+
+func (cache *IdempotencyCache) recordPath(key string) string {
+
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(cache.root, hex.EncodeToString(hash[:])+".json")
+}
+
+// sweep, remove expired records, call with lock.
+func (cache *IdempotencyCache) sweep() {
+
+	if time.Since(cache.swept) < idempotencySweepInterval {
+		return
+	}
+
+	cache.swept = time.Now()
+	for key, record := range cache.records {
+		if cache.swept.After(record.Expires) {
+			delete(cache.records, key)
+			os.Remove(cache.recordPath(key))
+		}
+	}
+}
+
+/*
+Begin is exported
+returns stored record of key for replay,
+nil record means the key is new and marked in-flight, must be completed or aborted.
+*/
+func (cache *IdempotencyCache) Begin(key string, fingerprint string) (*IdempotencyRecord, error) {
+
+	cache.Lock()
+	defer cache.Unlock()
+	cache.sweep()
+	if record, ret := cache.records[key]; ret && time.Now().Before(record.Expires) {
+		if record.Fingerprint != fingerprint {
+			return nil, ErrClusterIdempotencyKeyConflict
+		}
+		return record, nil
+	}
+
+	if inflight, ret := cache.inflight[key]; ret {
+		if inflight != fingerprint {
+			return nil, ErrClusterIdempotencyKeyConflict
+		}
+		return nil, ErrClusterIdempotencyKeyInProgress
+	}
+	cache.inflight[key] = fingerprint
+	return nil, nil
+}
+
+// Complete is exported
+// store response of in-flight key.
+func (cache *IdempotencyCache) Complete(key string, statusCode int, header map[string][]string, body []byte) error {
+
+	cache.Lock()
+	defer cache.Unlock()
+	fingerprint, ret := cache.inflight[key]
+	if !ret {
+		return nil
+	}
+
+	delete(cache.inflight, key)
+	record := &IdempotencyRecord{
+		Key:         key,
+		Fingerprint: fingerprint,
+		StatusCode:  statusCode,
+		Header:      header,
+		Body:        body,
+		Created:     time.Now(),
+	}
+	record.Expires = record.Created.Add(cache.ttl)
+	cache.records[key] = record
+
+	buf, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	path := cache.recordPath(key)
+	if err := ioutil.WriteFile(path+".tmp", buf, 0777); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// Abort is exported
+// release in-flight key without response stored, the key can be retried.
+func (cache *IdempotencyCache) Abort(key string) {
+
+	cache.Lock()
+	delete(cache.inflight, key)
+	cache.Unlock()
+}
@@ -0,0 +1,202 @@
+package cluster
+
+import (
+	"sort"
+)
+
+// GroupSummary is exported
+// Servers: servers count of group.
+// Engines: servers count of engine state text, server not in cluster engines is Disconnected.
+type GroupSummary struct {
+	ID          string         `json:"ID"`
+	Name        string         `json:"Name"`
+	IsCluster   bool           `json:"IsCluster"`
+	Location    string         `json:"ClusterLocation"`
+	ContactInfo string         `json:"ContactInfo"`
+	Servers     int            `json:"Servers"`
+	Engines     map[string]int `json:"Engines"`
+	Metas       int            `json:"Metas"`
+}
+
+// GetGroupsSummary is exported
+func (cluster *Cluster) GetGroupsSummary() []*GroupSummary {
+
+	groupsSummary := []*GroupSummary{}
+	for _, group := range cluster.GetGroups() {
+		groupSummary := &GroupSummary{
+			ID:          group.ID,
+			Name:        group.Name,
+			IsCluster:   group.IsCluster,
+			Location:    group.Location,
+			ContactInfo: group.ContactInfo,
+			Servers:     len(group.Servers),
+			Engines:     map[string]int{},
+			Metas:       len(cluster.configCache.GetGroupMetaData(group.ID)),
+		}
+		for _, engine := range cluster.GetGroupAllEngines(group.ID) {
+			groupSummary.Engines[engine.State()]++
+		}
+		groupsSummary = append(groupsSummary, groupSummary)
+	}
+
+	sort.Slice(groupsSummary, func(i, j int) bool {
+		return groupsSummary[i].Name < groupsSummary[j].Name
+	})
+	return groupsSummary
+}
+
+// GetPoolEngines is exported
+// Returns all engines of engines pool, contains pending engines.
+func (cluster *Cluster) GetPoolEngines() []*Engine {
+
+	engines := cluster.enginesPool.Engines()
+	sort.Slice(engines, func(i, j int) bool {
+		return engines[i].IP < engines[j].IP
+	})
+	return engines
+}
+
+/*
+CapacityUsage is exported
+Cpus & Memory: declared resources of healthy engines, memory unit is MB.
+TotalCpus & TotalMemory: overcommitted resources of healthy engines.
+UsedCpus & UsedMemory: resources of engines containers.
+FreeCpus & FreeMemory: overcommitted total minus used, not less than zero.
+Containers: metas containers recorded in cache.
+*/
+type CapacityUsage struct {
+	Engines        int   `json:"Engines"`
+	HealthyEngines int   `json:"HealthyEngines"`
+	Cpus           int64 `json:"Cpus"`
+	TotalCpus      int64 `json:"TotalCpus"`
+	UsedCpus       int64 `json:"UsedCpus"`
+	FreeCpus       int64 `json:"FreeCpus"`
+	Memory         int64 `json:"Memory"`
+	TotalMemory    int64 `json:"TotalMemory"`
+	UsedMemory     int64 `json:"UsedMemory"`
+	FreeMemory     int64 `json:"FreeMemory"`
+	Metas          int   `json:"Metas"`
+	Containers     int   `json:"Containers"`
+}
+
+// addEngine, append engine resources to usage.
+func (usage *CapacityUsage) addEngine(engine *Engine) {
+
+	usage.Engines++
+	if !engine.IsHealthy() {
+		return
+	}
+
+	usage.HealthyEngines++
+	usage.Cpus += engine.Cpus
+	usage.TotalCpus += engine.TotalCpus()
+	usage.UsedCpus += engine.UsedCpus()
+	usage.Memory += engine.Memory
+	usage.TotalMemory += engine.TotalMemory()
+	usage.UsedMemory += engine.UsedMemory() / 1024 / 1024
+}
+
+// complete, calculate free resources.
+func (usage *CapacityUsage) complete() {
+
+	usage.FreeCpus = usage.TotalCpus - usage.UsedCpus
+	if usage.FreeCpus < 0 {
+		usage.FreeCpus = 0
+	}
+
+	usage.FreeMemory = usage.TotalMemory - usage.UsedMemory
+	if usage.FreeMemory < 0 {
+		usage.FreeMemory = 0
+	}
+}
+
+// GroupCapacity is exported
+type GroupCapacity struct {
+	GroupID   string `json:"GroupId"`
+	GroupName string `json:"GroupName"`
+	Location  string `json:"Location"`
+	CapacityUsage
+}
+
+// LocationCapacity is exported
+// engines shared by groups of location are counted once.
+type LocationCapacity struct {
+	Location string `json:"Location"`
+	Groups   int    `json:"Groups"`
+	CapacityUsage
+}
+
+// ClusterCapacity is exported
+// Total: all engines of groups are counted once.
+type ClusterCapacity struct {
+	Total     CapacityUsage       `json:"Total"`
+	Groups    []*GroupCapacity    `json:"Groups"`
+	Locations []*LocationCapacity `json:"Locations"`
+}
+
+// GetCapacity is exported
+func (cluster *Cluster) GetCapacity() *ClusterCapacity {
+
+	clusterCapacity := &ClusterCapacity{
+		Groups:    []*GroupCapacity{},
+		Locations: []*LocationCapacity{},
+	}
+
+	clusterEngines := map[string]bool{}
+	locations := map[string]*LocationCapacity{}
+	locationEngines := map[string]map[string]bool{}
+	for _, group := range cluster.GetGroups() {
+		groupCapacity := &GroupCapacity{
+			GroupID:   group.ID,
+			GroupName: group.Name,
+			Location:  group.Location,
+		}
+
+		locationCapacity, ret := locations[group.Location]
+		if !ret {
+			locationCapacity = &LocationCapacity{Location: group.Location}
+			locations[group.Location] = locationCapacity
+			locationEngines[group.Location] = map[string]bool{}
+			clusterCapacity.Locations = append(clusterCapacity.Locations, locationCapacity)
+		}
+		locationCapacity.Groups++
+
+		for _, engine := range cluster.GetGroupAllEngines(group.ID) {
+			ipOrName := selectIPOrName(engine.IP, engine.Name)
+			groupCapacity.addEngine(engine)
+			if !locationEngines[group.Location][ipOrName] {
+				locationEngines[group.Location][ipOrName] = true
+				locationCapacity.addEngine(engine)
+			}
+			if !clusterEngines[ipOrName] {
+				clusterEngines[ipOrName] = true
+				clusterCapacity.Total.addEngine(engine)
+			}
+		}
+
+		metaData := cluster.configCache.GetGroupMetaData(group.ID)
+		groupCapacity.Metas = len(metaData)
+		for _, meta := range metaData {
+			groupCapacity.Containers += cluster.configCache.GetMetaDataBaseConfigsCount(meta.MetaID)
+		}
+		locationCapacity.Metas += groupCapacity.Metas
+		locationCapacity.Containers += groupCapacity.Containers
+		clusterCapacity.Total.Metas += groupCapacity.Metas
+		clusterCapacity.Total.Containers += groupCapacity.Containers
+		groupCapacity.complete()
+		clusterCapacity.Groups = append(clusterCapacity.Groups, groupCapacity)
+	}
+
+	for _, locationCapacity := range clusterCapacity.Locations {
+		locationCapacity.complete()
+	}
+	clusterCapacity.Total.complete()
+
+	sort.Slice(clusterCapacity.Groups, func(i, j int) bool {
+		return clusterCapacity.Groups[i].GroupName < clusterCapacity.Groups[j].GroupName
+	})
+	sort.Slice(clusterCapacity.Locations, func(i, j int) bool {
+		return clusterCapacity.Locations[i].Location < clusterCapacity.Locations[j].Location
+	})
+	return clusterCapacity
+}
@@ -0,0 +1,240 @@
+package cluster
+
+import "github.com/humpback/gounits/rand"
+
+import (
+	"sync"
+	"time"
+)
+
+// job states
+const (
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCanceled  = "canceled"
+)
+
+// finished jobs retention, jobs are kept in memory of the center running them.
+const jobRetention = time.Hour
+
+// drain wait canceled jobs stop timeout, a canceled job stops after the running engine request.
+const drainCancelTimeout = requestTimeout
+
+/*
+JobInfo is exported
+Operation: audit operation of job, create, upgrade or remove.
+Completed & Total: progress containers.
+Result: operation response, same as synchronous request response.
+*/
+type JobInfo struct {
+	ID        string      `json:"Id"`
+	Operation string      `json:"Operation"`
+	RequestID string      `json:"RequestId"`
+	Caller    string      `json:"Caller"`
+	GroupID   string      `json:"GroupId"`
+	MetaID    string      `json:"MetaId"`
+	State     string      `json:"State"`
+	Completed int         `json:"Completed"`
+	Total     int         `json:"Total"`
+	Result    interface{} `json:"Result"`
+	Error     string      `json:"Error"`
+	Created   time.Time   `json:"Created"`
+	Finished  time.Time   `json:"Finished"`
+}
+
+// Job is exported
+// a nil job is a synchronous operation, never canceled.
+type Job struct {
+	sync.RWMutex
+	info     JobInfo
+	canceled bool
+	parent   *Job
+}
+
+// JobFunc is exported
+// job run function, return operation result and error.
+type JobFunc func(job *Job) (interface{}, error)
+
+// NewJob is exported
+func NewJob(operation string, requestid string, caller string, groupid string, metaid string) *Job {
+
+	return &Job{
+		info: JobInfo{
+			ID:        rand.UUID(true),
+			Operation: operation,
+			RequestID: requestid,
+			Caller:    caller,
+			GroupID:   groupid,
+			MetaID:    metaid,
+			State:     JobRunning,
+			Created:   time.Now(),
+		},
+	}
+}
+
+// Info is exported
+// return job info copy.
+func (job *Job) Info() *JobInfo {
+
+	job.RLock()
+	defer job.RUnlock()
+	info := job.info
+	return &info
+}
+
+// IsCanceled is exported
+func (job *Job) IsCanceled() bool {
+
+	if job == nil {
+		return false
+	}
+
+	job.RLock()
+	canceled := job.canceled
+	job.RUnlock()
+	return canceled || job.parent.IsCanceled()
+}
+
+// subJob, job of a part of the job operation, canceled with the job, progress is not reported to the job.
+func (job *Job) subJob() *Job {
+
+	if job == nil {
+		return nil
+	}
+	return &Job{parent: job}
+}
+
+// SetProgress is exported
+func (job *Job) SetProgress(completed int, total int) {
+
+	if job == nil {
+		return
+	}
+
+	job.Lock()
+	job.info.Completed = completed
+	job.info.Total = total
+	job.Unlock()
+}
+
+// SetMetaID is exported
+func (job *Job) SetMetaID(metaid string) {
+
+	if job == nil {
+		return
+	}
+
+	job.Lock()
+	job.info.MetaID = metaid
+	job.Unlock()
+}
+
+// isRunning return true if job is not finished.
+func (job *Job) isRunning() bool {
+
+	job.RLock()
+	defer job.RUnlock()
+	return job.info.State == JobRunning
+}
+
+// finish set job result, a canceled job state is canceled.
+func (job *Job) finish(result interface{}, err error) {
+
+	job.Lock()
+	defer job.Unlock()
+	job.info.Result = result
+	job.info.Finished = time.Now()
+	job.info.State = JobSucceeded
+	if job.canceled {
+		job.info.State = JobCanceled
+	} else if err != nil {
+		job.info.State = JobFailed
+	}
+
+	if err != nil {
+		job.info.Error = err.Error()
+	}
+}
+
+// JobsCache is exported
+type JobsCache struct {
+	sync.RWMutex
+	jobs map[string]*Job
+}
+
+// NewJobsCache is exported
+func NewJobsCache() *JobsCache {
+
+	return &JobsCache{
+		jobs: make(map[string]*Job),
+	}
+}
+
+// Submit is exported
+// run job in background, expired finished jobs are cleared.
+func (cache *JobsCache) Submit(job *Job, fn JobFunc) *JobInfo {
+
+	cache.Lock()
+	for jobid, cachedJob := range cache.jobs {
+		if info := cachedJob.Info(); info.State != JobRunning && time.Since(info.Finished) > jobRetention {
+			delete(cache.jobs, jobid)
+		}
+	}
+	cache.jobs[job.info.ID] = job
+	cache.Unlock()
+
+	go func() {
+		result, err := fn(job)
+		job.finish(result, err)
+	}()
+	return job.Info()
+}
+
+// Get is exported
+func (cache *JobsCache) Get(jobid string) (*JobInfo, error) {
+
+	cache.RLock()
+	defer cache.RUnlock()
+	job, ret := cache.jobs[jobid]
+	if !ret {
+		return nil, ErrClusterJobNotFound
+	}
+	return job.Info(), nil
+}
+
+// Cancel is exported
+// mark job canceled, job stop at a safe point between two containers.
+func (cache *JobsCache) Cancel(jobid string) (*JobInfo, error) {
+
+	cache.RLock()
+	job, ret := cache.jobs[jobid]
+	cache.RUnlock()
+	if !ret {
+		return nil, ErrClusterJobNotFound
+	}
+
+	job.Lock()
+	if job.info.State != JobRunning {
+		job.Unlock()
+		return nil, ErrClusterJobFinished
+	}
+	job.canceled = true
+	job.Unlock()
+	return job.Info(), nil
+}
+
+// Running is exported
+// return running jobs.
+func (cache *JobsCache) Running() []*Job {
+
+	cache.RLock()
+	defer cache.RUnlock()
+	jobs := []*Job{}
+	for _, job := range cache.jobs {
+		if job.isRunning() {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs
+}
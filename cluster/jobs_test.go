@@ -0,0 +1,40 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJobCancelSubJob(t *testing.T) {
+
+	jobsCache := NewJobsCache()
+	job := NewJob(AuditGroupEvent, "request1", "", "group1", "")
+	subJob := job.subJob()
+	stopped := make(chan struct{})
+	jobsCache.Submit(job, func(job *Job) (interface{}, error) {
+		for !subJob.IsCanceled() {
+			time.Sleep(10 * time.Millisecond)
+		}
+		close(stopped)
+		return nil, ErrClusterJobCanceled
+	})
+
+	if _, err := jobsCache.Cancel(job.Info().ID); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("sub job not canceled with job")
+	}
+
+	cluster := &Cluster{jobsCache: jobsCache}
+	if !cluster.waitJobsStopped(time.Second) {
+		t.Fatal("canceled job not stopped")
+	}
+
+	if info, _ := jobsCache.Get(job.Info().ID); info.State != JobCanceled {
+		t.Fatalf("job state %s, want %s", info.State, JobCanceled)
+	}
+}
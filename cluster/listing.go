@@ -0,0 +1,255 @@
+package cluster
+
+import "humpback-center/cluster/types"
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// containers listing sort keys.
+const (
+	SortByIndex   = "index"
+	SortByCreated = "created"
+)
+
+// engines listing sort keys.
+const (
+	SortByName = "name"
+	SortByIP   = "ip"
+)
+
+// container states, same as StateString returns.
+var containerStates = []string{"Running", "Paused", "Restarting", "Dead", "Created", "Exited"}
+
+// IsContainerState is exported
+func IsContainerState(state string) bool {
+
+	for _, value := range containerStates {
+		if strings.EqualFold(value, state) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEngineState is exported
+func IsEngineState(state string) bool {
+
+	for _, value := range stateText {
+		if strings.EqualFold(value, state) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+ListOptions is exported
+State: container state text or engine state text.
+Server: engine ip or hostname.
+Name: prefix of meta name or container name, engines use engine hostname.
+Image: container image, without tag matches all tags of image.
+Sort: containers sort by index or created, engines sort by name or ip.
+Limit & Cursor: page size and offset of page, cursor is NextCursor of previous page.
+*/
+type ListOptions struct {
+	State  string
+	Server string
+	Name   string
+	Image  string
+	Sort   string
+	Limit  int
+	Cursor int
+}
+
+// IsFiltered is exported
+// return true if options have any filter or paging.
+func (options *ListOptions) IsFiltered() bool {
+
+	if options == nil {
+		return false
+	}
+	return options.State != "" || options.Server != "" || options.Name != "" ||
+		options.Image != "" || options.Limit > 0 || options.Cursor > 0
+}
+
+// page returns the range of page and next cursor, next cursor is empty when last page.
+func (options *ListOptions) page(total int) (int, int, string) {
+
+	start := options.Cursor
+	if start > total {
+		start = total
+	}
+
+	end := total
+	if options.Limit > 0 && start+options.Limit < total {
+		end = start + options.Limit
+	}
+
+	nextCursor := ""
+	if end < total {
+		nextCursor = strconv.Itoa(end)
+	}
+	return start, end, nextCursor
+}
+
+func (options *ListOptions) matchServer(ip string, name string) bool {
+
+	return options.Server == "" || options.Server == ip || options.Server == name
+}
+
+func (options *ListOptions) matchImage(image string) bool {
+
+	return options.Image == "" || options.Image == image || strings.HasPrefix(image, options.Image+":")
+}
+
+func (options *ListOptions) matchContainer(groupContainer *types.GroupContainer, engineContainer *types.EngineContainer) bool {
+
+	if options.State != "" && !strings.EqualFold(options.State, engineContainer.State) {
+		return false
+	}
+
+	if !options.matchServer(engineContainer.IP, engineContainer.HostName) {
+		return false
+	}
+
+	if options.Name != "" {
+		containerName := strings.TrimPrefix(engineContainer.Container.Name, "/")
+		if !strings.HasPrefix(groupContainer.Config.Name, options.Name) && !strings.HasPrefix(containerName, options.Name) {
+			return false
+		}
+	}
+	return options.matchImage(engineContainer.Container.Image)
+}
+
+type listedContainer struct {
+	order           int
+	groupContainer  *types.GroupContainer
+	engineContainer *types.EngineContainer
+}
+
+/*
+ListGroupContainers is exported
+filter, sort and page containers of metas, returns total of matched containers and next page cursor.
+without any filter or paging, all metas are returned and contains metas that have no container.
+*/
+func ListGroupContainers(groupContainers types.GroupContainers, options *ListOptions) (types.GroupContainers, int, string) {
+
+	if options == nil {
+		options = &ListOptions{}
+	}
+
+	listedContainers := []*listedContainer{}
+	for order, groupContainer := range groupContainers {
+		for _, engineContainer := range groupContainer.Containers {
+			if options.matchContainer(groupContainer, engineContainer) {
+				listedContainers = append(listedContainers, &listedContainer{
+					order:           order,
+					groupContainer:  groupContainer,
+					engineContainer: engineContainer,
+				})
+			}
+		}
+	}
+
+	switch options.Sort {
+	case SortByIndex:
+		sort.SliceStable(listedContainers, func(i, j int) bool {
+			if listedContainers[i].order != listedContainers[j].order {
+				return listedContainers[i].order < listedContainers[j].order
+			}
+			return listedContainers[i].engineContainer.Index < listedContainers[j].engineContainer.Index
+		})
+	case SortByCreated:
+		sort.SliceStable(listedContainers, func(i, j int) bool {
+			return listedContainers[i].engineContainer.Container.Created < listedContainers[j].engineContainer.Container.Created
+		})
+	}
+
+	total := len(listedContainers)
+	start, end, nextCursor := options.page(total)
+	pageContainers := types.GroupContainers{}
+	pageMetas := map[string]*types.GroupContainer{}
+	if !options.IsFiltered() {
+		for _, groupContainer := range groupContainers {
+			pageMetas[groupContainer.MetaID] = copyGroupContainer(groupContainer)
+			pageContainers = append(pageContainers, pageMetas[groupContainer.MetaID])
+		}
+	}
+
+	for _, listed := range listedContainers[start:end] {
+		groupContainer, ret := pageMetas[listed.groupContainer.MetaID]
+		if !ret {
+			groupContainer = copyGroupContainer(listed.groupContainer)
+			pageMetas[groupContainer.MetaID] = groupContainer
+			pageContainers = append(pageContainers, groupContainer)
+		}
+		groupContainer.Containers = append(groupContainer.Containers, listed.engineContainer)
+	}
+	return pageContainers, total, nextCursor
+}
+
+func copyGroupContainer(groupContainer *types.GroupContainer) *types.GroupContainer {
+
+	return &types.GroupContainer{
+		MetaID:     groupContainer.MetaID,
+		Instances:  groupContainer.Instances,
+		WebHooks:   groupContainer.WebHooks,
+		Config:     groupContainer.Config,
+		Containers: make([]*types.EngineContainer, 0),
+	}
+}
+
+// ListEngines is exported
+// filter, sort and page engines, returns total of matched engines and next page cursor.
+func ListEngines(engines []*Engine, options *ListOptions) ([]*Engine, int, string) {
+
+	if options == nil {
+		options = &ListOptions{}
+	}
+
+	listedEngines := []*Engine{}
+	for _, engine := range engines {
+		if options.State != "" && !strings.EqualFold(options.State, engine.StateText) {
+			continue
+		}
+		if !options.matchServer(engine.IP, engine.Name) {
+			continue
+		}
+		if options.Name != "" && !strings.HasPrefix(engine.Name, options.Name) {
+			continue
+		}
+		if options.Image != "" && !engine.hasImage(options) {
+			continue
+		}
+		listedEngines = append(listedEngines, engine)
+	}
+
+	switch options.Sort {
+	case SortByName:
+		sort.SliceStable(listedEngines, func(i, j int) bool {
+			return listedEngines[i].Name < listedEngines[j].Name
+		})
+	case SortByIP:
+		sort.SliceStable(listedEngines, func(i, j int) bool {
+			return listedEngines[i].IP < listedEngines[j].IP
+		})
+	}
+
+	total := len(listedEngines)
+	start, end, nextCursor := options.page(total)
+	return listedEngines[start:end], total, nextCursor
+}
+
+// hasImage returns true if engine has any container of options image.
+func (engine *Engine) hasImage(options *ListOptions) bool {
+
+	for _, container := range engine.Containers("") {
+		if container.Config != nil && options.matchImage(container.Config.Image) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,235 @@
+package cluster
+
+import "humpback-center/metrics"
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// logsClient, agent logs request client without timeout, follow stream is ended by context.
+var logsClient = &http.Client{}
+
+/*
+ContainerLogsOptions is exported
+Tail: lines of end of logs, "all" or a number, empty is all.
+Since: unix seconds or RFC3339 time, only return logs since this time.
+Follow: keep streaming new logs.
+Timestamps: add timestamp to every line.
+*/
+type ContainerLogsOptions struct {
+	Tail       string
+	Since      string
+	Follow     bool
+	Timestamps bool
+}
+
+func (options *ContainerLogsOptions) query() url.Values {
+
+	query := url.Values{}
+	if options.Tail != "" {
+		query.Set("tail", options.Tail)
+	}
+	if options.Since != "" {
+		query.Set("since", options.Since)
+	}
+	query.Set("follow", strconv.FormatBool(options.Follow))
+	query.Set("timestamps", strconv.FormatBool(options.Timestamps))
+	return query
+}
+
+// ContainerLogs is exported
+// Engine request agent container logs, returns agent log output stream.
+func (engine *Engine) ContainerLogs(ctx context.Context, containerid string, options *ContainerLogsOptions) (io.ReadCloser, error) {
+
+	rawurl := "http://" + engine.APIAddr + "/v1/containers/" + containerid + "/logs?" + options.query().Encode()
+	req, err := http.NewRequest(http.MethodGet, rawurl, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := logsClient.Do(req.WithContext(ctx))
+	if err != nil {
+		metrics.ObserveAgentRequest("logs", start, err)
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		err = fmt.Errorf("agent response status %d", resp.StatusCode)
+		metrics.ObserveAgentRequest("logs", start, err)
+		data, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return nil, fmt.Errorf("engine %s, container %s logs failure, %s", engine.IP, shortContainerID(containerid), strings.TrimSpace(string(data)))
+	}
+	metrics.ObserveAgentRequest("logs", start, nil)
+	return resp.Body, nil
+}
+
+// drainContext, returns a context also canceled when cluster is draining.
+func (cluster *Cluster) drainContext(ctx context.Context) (context.Context, context.CancelFunc) {
+
+	drainCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-cluster.drainCh:
+			cancel()
+		case <-drainCtx.Done():
+		}
+	}()
+	return drainCtx, cancel
+}
+
+// logsReadCloser, close logs stream and cancel logs context.
+type logsReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (reader *logsReadCloser) Close() error {
+
+	err := reader.ReadCloser.Close()
+	reader.cancel()
+	return err
+}
+
+// GetContainerLogs is exported
+// resolve container engine, returns agent logs stream of container.
+func (cluster *Cluster) GetContainerLogs(ctx context.Context, containerid string, options *ContainerLogsOptions) (io.ReadCloser, error) {
+
+	metaData := cluster.configCache.GetMetaDataOfContainer(containerid)
+	if metaData == nil {
+		return nil, ErrClusterContainerNotFound
+	}
+
+	_, engines, err := cluster.GetMetaDataEngines(metaData.MetaID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, engine := range engines {
+		if engine.IsHealthy() {
+			if container := engine.Container(containerid); container != nil {
+				logsCtx, cancel := cluster.drainContext(ctx)
+				reader, err := engine.ContainerLogs(logsCtx, container.Info.ID, options)
+				if err != nil {
+					cancel()
+					return nil, err
+				}
+				return &logsReadCloser{ReadCloser: reader, cancel: cancel}, nil
+			}
+		}
+	}
+	return nil, ErrClusterNoEngineAvailable
+}
+
+// metaLogsInstance is a meta container instance of logs.
+type metaLogsInstance struct {
+	index       int
+	prefix      string
+	engine      *Engine
+	containerid string
+}
+
+/*
+GetMetaLogs is exported
+merges logs of all meta instances, every line is prefixed with instance index and engine ip.
+without follow, instances logs are streamed one by one in index order, with follow lines are written as received.
+an instance logs failure is written as a prefixed line, not stop other instances.
+*/
+func (cluster *Cluster) GetMetaLogs(ctx context.Context, metaid string, options *ContainerLogsOptions) (io.ReadCloser, error) {
+
+	metaData, engines, err := cluster.GetMetaDataEngines(metaid)
+	if err != nil {
+		return nil, err
+	}
+
+	baseConfigs := cluster.configCache.GetMetaDataBaseConfigs(metaData.MetaID)
+	instances := []*metaLogsInstance{}
+	for _, baseConfig := range baseConfigs {
+		for _, engine := range engines {
+			if engine.IsHealthy() && engine.Container(baseConfig.ID) != nil {
+				instances = append(instances, &metaLogsInstance{
+					index:       baseConfig.Index,
+					prefix:      fmt.Sprintf("[%d %s] ", baseConfig.Index, engine.IP),
+					engine:      engine,
+					containerid: baseConfig.ID,
+				})
+				break
+			}
+		}
+	}
+
+	if len(instances) == 0 {
+		return nil, ErrClusterContainerNotFound
+	}
+
+	sort.SliceStable(instances, func(i, j int) bool {
+		return instances[i].index < instances[j].index
+	})
+
+	logsCtx, cancel := cluster.drainContext(ctx)
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		if !options.Follow {
+			//instances logs are streamed one by one, logs are not buffered in memory.
+			for _, instance := range instances {
+				if logsCtx.Err() != nil {
+					break
+				}
+				instance.writeLogs(logsCtx, options, func(line string) {
+					pipeWriter.Write([]byte(line))
+				})
+			}
+			pipeWriter.Close()
+			return
+		}
+
+		mutex := sync.Mutex{}
+		waitGroup := sync.WaitGroup{}
+		for _, instance := range instances {
+			waitGroup.Add(1)
+			go func(instance *metaLogsInstance) {
+				defer waitGroup.Done()
+				instance.writeLogs(logsCtx, options, func(line string) {
+					mutex.Lock()
+					pipeWriter.Write([]byte(line))
+					mutex.Unlock()
+				})
+			}(instance)
+		}
+		waitGroup.Wait()
+		pipeWriter.Close()
+	}()
+	return &logsReadCloser{ReadCloser: pipeReader, cancel: cancel}, nil
+}
+
+// writeLogs, read instance container logs, write every prefixed line, a logs failure is written as a prefixed line.
+func (instance *metaLogsInstance) writeLogs(ctx context.Context, options *ContainerLogsOptions, write func(string)) {
+
+	reader, err := instance.engine.ContainerLogs(ctx, instance.containerid, options)
+	if err != nil {
+		write(instance.prefix + "logs error: " + err.Error() + "\n")
+		return
+	}
+
+	defer reader.Close()
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		write(instance.prefix + scanner.Text() + "\n")
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		write(instance.prefix + "logs error: " + err.Error() + "\n")
+	}
+}
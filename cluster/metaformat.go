@@ -0,0 +1,87 @@
+package cluster
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	// metadata header magic
+	metaHeaderMagic = "humpback-meta"
+	// metadata header format version
+	metaHeaderVersion = 1
+)
+
+// encodeMetaData is exported
+// metaData stored format, first line is header "humpback-meta/1 sha256:<hex>", followed by json body.
+// json body SchemaVersion is always the current schema version.
+func encodeMetaData(metaData *MetaData) ([]byte, error) {
+
+	stored := *metaData
+	stored.SchemaVersion = metaSchemaVersion
+	body := bytes.NewBuffer([]byte{})
+	if err := json.NewEncoder(body).Encode(&stored); err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(body.Bytes())
+	buffer := bytes.NewBuffer([]byte{})
+	fmt.Fprintf(buffer, "%s/%d sha256:%s\n", metaHeaderMagic, metaHeaderVersion, hex.EncodeToString(sum[:]))
+	buffer.Write(body.Bytes())
+	return buffer.Bytes(), nil
+}
+
+// decodeMetaData is exported
+// verify header checksum and decode metaData, apply schema upgrades to current version.
+// return metaData and the stored schema version.
+func decodeMetaData(data []byte) (*MetaData, int, error) {
+
+	metaData, _, err := upgradeMetaData(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &metaData.MetaData, metaData.fromVersion, nil
+}
+
+// hasMetaHeader, data without header is a legacy json meta.
+func hasMetaHeader(data []byte) bool {
+
+	return bytes.HasPrefix(data, []byte(metaHeaderMagic))
+}
+
+// verifyMetaData, verify header checksum and return json body.
+func verifyMetaData(data []byte) ([]byte, error) {
+
+	if !hasMetaHeader(data) {
+		return data, nil
+	}
+
+	pos := bytes.IndexByte(data, '\n')
+	if pos < 0 {
+		return nil, fmt.Errorf("%s, header incomplete", ErrClusterMetaDataCorrupted)
+	}
+
+	var (
+		version  int
+		checksum string
+	)
+	header := string(data[:pos])
+	if _, err := fmt.Sscanf(header, metaHeaderMagic+"/%d sha256:%s", &version, &checksum); err != nil {
+		return nil, fmt.Errorf("%s, header invalid, %s", ErrClusterMetaDataCorrupted, err)
+	}
+
+	if version > metaHeaderVersion {
+		return nil, fmt.Errorf("%s, header version %d not supported", ErrClusterMetaDataCorrupted, version)
+	}
+
+	body := data[pos+1:]
+	sum := sha256.Sum256(body)
+	if !strings.EqualFold(checksum, hex.EncodeToString(sum[:])) {
+		return nil, fmt.Errorf("%s, checksum mismatch", ErrClusterMetaDataCorrupted)
+	}
+	return body, nil
+}
@@ -0,0 +1,84 @@
+package cluster
+
+// GroupMetrics is exported
+// cluster group capacity and usage of healthy engines.
+// Containers: sum of group metas containers recorded in cache.
+// DesiredInstances: sum of group metas instances.
+// ActualInstances: sum of group metas running containers on healthy engines.
+type GroupMetrics struct {
+	GroupID          string
+	GroupName        string
+	TotalCpus        int64
+	UsedCpus         int64
+	TotalMemory      int64
+	UsedMemory       int64
+	Metas            int
+	Containers       int
+	DesiredInstances int
+	ActualInstances  int
+}
+
+// ClusterMetrics is exported
+// cluster metrics snapshot.
+// Engines: engines count of state text.
+type ClusterMetrics struct {
+	Engines   map[string]int
+	Groups    []*GroupMetrics
+	Upgraders int
+	Migrators int
+}
+
+// GetMetrics is exported
+func (cluster *Cluster) GetMetrics() *ClusterMetrics {
+
+	clusterMetrics := &ClusterMetrics{
+		Engines:   map[string]int{},
+		Groups:    []*GroupMetrics{},
+		Upgraders: len(cluster.upgraderCache.MetaIDs()),
+		Migrators: len(cluster.migtatorCache.MetaIDs()),
+	}
+
+	for _, state := range []engineState{StatePending, StateHealthy, StateUnhealthy, StateDisconnected} {
+		clusterMetrics.Engines[stateText[state]] = 0
+	}
+
+	cluster.RLock()
+	for _, engine := range cluster.engines {
+		clusterMetrics.Engines[engine.State()]++
+	}
+	cluster.RUnlock()
+
+	for _, group := range cluster.GetGroups() {
+		groupMetrics := &GroupMetrics{
+			GroupID:   group.ID,
+			GroupName: group.Name,
+		}
+
+		engines := []*Engine{}
+		for _, engine := range cluster.GetGroupEngines(group.ID) {
+			if engine.IsHealthy() {
+				engines = append(engines, engine)
+				groupMetrics.TotalCpus += engine.TotalCpus()
+				groupMetrics.UsedCpus += engine.UsedCpus()
+				groupMetrics.TotalMemory += engine.TotalMemory()
+				groupMetrics.UsedMemory += engine.UsedMemory() / 1024 / 1024
+			}
+		}
+
+		metaData := cluster.configCache.GetGroupMetaData(group.ID)
+		groupMetrics.Metas = len(metaData)
+		for _, meta := range metaData {
+			groupMetrics.DesiredInstances += meta.Instances
+			groupMetrics.Containers += cluster.configCache.GetMetaDataBaseConfigsCount(meta.MetaID)
+			for _, engine := range engines {
+				for _, container := range engine.Containers(meta.MetaID) {
+					if container.Info.ContainerJSONBase != nil && container.Info.State != nil && container.Info.State.Running {
+						groupMetrics.ActualInstances++
+					}
+				}
+			}
+		}
+		clusterMetrics.Groups = append(clusterMetrics.Groups, groupMetrics)
+	}
+	return clusterMetrics
+}
@@ -238,10 +238,21 @@ func (migrator *Migrator) Container(containerid string) *MigrateContainer {
 }
 
 // Start is exported
+// cluster draining, migrator quit at a safe point, between two containers migrate,
+// not migrated containers are kept in metadata, recovered by the next leader.
 func (migrator *Migrator) Start() {
 
-	time.Sleep(migrator.migrateDelay)
+	select {
+	case <-time.After(migrator.migrateDelay):
+	case <-migrator.Cluster.drainCh:
+	}
+
 	for {
+		if migrator.Cluster.IsDraining() {
+			logger.WARN("[#cluster] migrator %s quit, cluster is draining.", migrator.MetaID)
+			break
+		}
+
 		migrator.RLock()
 		mContainers := migrator.containers
 		migrator.RUnlock()
@@ -266,6 +277,7 @@ func (migrator *Migrator) Start() {
 			if mContainer.GetState() == MigrateCompleted {
 				migrator.Cluster.configCache.RemoveContainerBaseConfig(migrator.MetaID, mContainer.ID)
 			}
+			migrator.publishProgress(mContainer)
 			continue
 		}
 
@@ -296,6 +308,32 @@ func (migrator *Migrator) Start() {
 	migrator.handler.OnMigratorQuitHandleFunc(migrator)
 }
 
+// publishProgress publish migrate container state and completed containers count.
+func (migrator *Migrator) publishProgress(mContainer *MigrateContainer) {
+
+	completed := 0
+	mContainers := migrator.Containers()
+	for _, container := range mContainers {
+		if container.GetState() == MigrateCompleted {
+			completed++
+		}
+	}
+
+	event := &ClusterEvent{
+		Type:        MigrateProgressEvent,
+		MetaID:      migrator.MetaID,
+		ContainerID: mContainer.ID,
+		State:       mContainer.GetState().String(),
+		Completed:   completed,
+		Total:       len(mContainers),
+	}
+
+	if metaData := migrator.Cluster.GetMetaData(migrator.MetaID); metaData != nil {
+		event.GroupIDs = []string{metaData.GroupID}
+	}
+	migrator.Cluster.events.Publish(event)
+}
+
 // Update is exported
 func (migrator *Migrator) Update(metaid string, containers Containers) {
 
@@ -387,6 +425,19 @@ func (cache *MigrateContainersCache) Contains(metaid string) bool {
 	return ret
 }
 
+// MetaIDs is exported
+// Return metaids of migrating.
+func (cache *MigrateContainersCache) MetaIDs() []string {
+
+	cache.RLock()
+	defer cache.RUnlock()
+	metaids := []string{}
+	for metaid := range cache.migrators {
+		metaids = append(metaids, metaid)
+	}
+	return metaids
+}
+
 // RemoveGroup is exported
 // cancel group all metadata migrate.
 func (cache *MigrateContainersCache) RemoveGroup(groupid string) {
@@ -407,6 +458,16 @@ func (cache *MigrateContainersCache) RemoveGroup(groupid string) {
 // engine parameter is offline engine pointer.
 func (cache *MigrateContainersCache) Start(engine *Engine) {
 
+	if !cache.Cluster.IsLeader() {
+		logger.INFO("[#cluster#] cluster is follower, skip migrate engine %s containers.", engine.IP)
+		return
+	}
+
+	if cache.Cluster.IsDraining() {
+		logger.WARN("[#cluster#] cluster is draining, skip migrate engine %s containers.", engine.IP)
+		return
+	}
+
 	if engine.IsHealthy() {
 		metaids := engine.MetaIds()
 		cache.start(engine, metaids)
@@ -429,6 +490,10 @@ func (cache *MigrateContainersCache) start(engine *Engine, metaids []string) {
 	if len(metaids) > 0 {
 		cache.Lock()
 		for _, metaid := range metaids {
+			if metaData := cache.Cluster.GetMetaData(metaid); metaData != nil && metaData.IsPaused() {
+				logger.INFO("[#cluster] migrator skipped %s %s, meta is paused", engine.IP, metaid)
+				continue
+			}
 			containers := engine.Containers(metaid)
 			if len(containers) == 0 {
 				continue
@@ -489,7 +554,17 @@ func (cache *MigrateContainersCache) OnMigratorNotifyHandleFunc(migrator *Migrat
 	cache.Cluster.hooksProcessor.Hook(metaData, MigrateMetaEvent)
 	cache.Cluster.NotifyGroupMetaContainersEvent("Cluster Meta Containers Migrated.", err, migrator.MetaID)
 	mContainers := migrator.Containers()
+	containers := map[string]string{}
 	for _, mContainer := range mContainers {
 		logger.INFO("[#cluster] migrator container %s %s", mContainer.ID[:12], mContainer.state.String())
+		containers[mContainer.ID] = mContainer.state.String()
+	}
+
+	record := &AuditRecord{
+		Caller:    auditCallerCluster,
+		Operation: AuditMigrate,
+		MetaID:    migrator.MetaID,
 	}
+	record.SetResult(containers, err)
+	cache.Cluster.WriteAudit(record)
 }
@@ -0,0 +1,154 @@
+package cluster
+
+import "github.com/humpback/gounits/http"
+import "github.com/humpback/gounits/logger"
+import ctypes "humpback-center/cluster/types"
+import "humpback-center/metrics"
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// engine image pull request timeout
+	pullTimeout = 10 * time.Minute
+)
+
+// ImageChecker is exported
+// check an image reference exists in its registry, err is registry unavailable.
+type ImageChecker interface {
+	CheckImage(image string) (bool, error)
+}
+
+// ImagePullResult is exported
+// engine image pre-pull result, Error is empty if pulled.
+type ImagePullResult struct {
+	IP       string  `json:"IP"`
+	HostName string  `json:"HostName"`
+	Image    string  `json:"Image"`
+	Seconds  float64 `json:"Seconds"`
+	Error    string  `json:"Error"`
+}
+
+// SetImageChecker is exported
+// upgrade image is checked before upgrade, checker is nil not check.
+func (cluster *Cluster) SetImageChecker(checker ImageChecker) {
+
+	cluster.Lock()
+	cluster.imageChecker = checker
+	cluster.Unlock()
+}
+
+// checkImage, a not exists image is rejected, registry unavailable is ignored, engines pull the image.
+func (cluster *Cluster) checkImage(image string) error {
+
+	cluster.RLock()
+	checker := cluster.imageChecker
+	cluster.RUnlock()
+	if checker == nil {
+		return nil
+	}
+
+	exists, err := checker.CheckImage(image)
+	if err != nil {
+		logger.WARN("[#cluster#] check image %s error, %s", image, err.Error())
+		return nil
+	}
+
+	if !exists {
+		logger.WARN("[#cluster#] check image %s not found.", image)
+		return ErrClusterImageNotFound
+	}
+	return nil
+}
+
+// PullImage is exported
+// Engine request agent pull image.
+func (engine *Engine) PullImage(image string) error {
+
+	buf := bytes.NewBuffer([]byte{})
+	if err := json.NewEncoder(buf).Encode(map[string]string{"Image": image}); err != nil {
+		return err
+	}
+
+	header := map[string][]string{"Content-Type": []string{"application/json"}}
+	start := time.Now()
+	respPulled, err := http.NewWithTimeout(pullTimeout).Post("http://"+engine.APIAddr+"/v1/images", nil, buf, header)
+	metrics.ObserveAgentRequest("pullimage", start, agentRequestError(respPulled, err))
+	if err != nil {
+		return err
+	}
+
+	defer respPulled.Close()
+	if respPulled.StatusCode() != 200 {
+		return fmt.Errorf("engine %s, pull image %s failure, %s", engine.IP, image, ctypes.ParseHTTPResponseError(respPulled))
+	}
+	return nil
+}
+
+// prePullImage, healthy engines of meta pull image in parallel.
+// Return pull results of engines, error if any engine pull failure or cluster is draining.
+func (cluster *Cluster) prePullImage(metaData *MetaData, engines []*Engine, image string) ([]*ImagePullResult, error) {
+
+	results := []*ImagePullResult{}
+	mutex := sync.Mutex{}
+	waitGroup := sync.WaitGroup{}
+	for _, engine := range engines {
+		if !engine.IsHealthy() || !engine.HasMeta(metaData.MetaID) {
+			continue
+		}
+
+		waitGroup.Add(1)
+		go func(e *Engine) {
+			defer waitGroup.Done()
+			start := time.Now()
+			result := &ImagePullResult{IP: e.IP, HostName: e.Name, Image: image}
+			if err := e.PullImage(image); err != nil {
+				result.Error = err.Error()
+			}
+			result.Seconds = time.Since(start).Seconds()
+			mutex.Lock()
+			results = append(results, result)
+			mutex.Unlock()
+		}(engine)
+	}
+	done := make(chan struct{})
+	go func() {
+		waitGroup.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-cluster.drainCh:
+		//draining, not wait engines pulling, pulled images are kept on engines.
+		logger.WARN("[#cluster#] meta %s pre-pull image %s stopped, cluster is draining.", metaData.MetaID, image)
+		mutex.Lock()
+		pulled := append([]*ImagePullResult{}, results...)
+		mutex.Unlock()
+		return pulled, ErrClusterDraining
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].IP < results[j].IP
+	})
+
+	failures := 0
+	for _, result := range results {
+		if result.Error != "" {
+			failures++
+			logger.ERROR("[#cluster#] meta %s pre-pull image %s error, %s", metaData.MetaID, image, result.Error)
+		}
+	}
+
+	if failures > 0 {
+		return results, fmt.Errorf("%s, %d of %d engines", ErrClusterImagePullFailure.Error(), failures, len(results))
+	}
+	logger.INFO("[#cluster#] meta %s pre-pull image %s on %d engines.", metaData.MetaID, image, len(results))
+	return results, nil
+}
@@ -1,22 +1,57 @@
 package cluster
 
+import "github.com/humpback/gounits/logger"
+import "github.com/humpback/gounits/rand"
+
 import (
+	mrand "math/rand"
+	"sync"
 	"time"
 )
 
+const (
+	// recovery runs keep max count
+	maxRecoveryRuns = 32
+	// recovery metas random jitter max interval
+	recoveryJitter = 500 * time.Millisecond
+)
+
+// RecoveryRun is exported
+// Trigger: recovery run trigger, interval or manual.
+type RecoveryRun struct {
+	ID                string    `json:"ID"`
+	Trigger           string    `json:"Trigger"`
+	StartAt           time.Time `json:"StartAt"`
+	EndAt             time.Time `json:"EndAt"`
+	MetasChecked      int       `json:"MetasChecked"`
+	MetasSkipped      int       `json:"MetasSkipped"`
+	ContainersCreated int       `json:"ContainersCreated"`
+	ContainersRemoved int       `json:"ContainersRemoved"`
+	Errors            []string  `json:"Errors"`
+}
+
 // MetaRestorer is exported
 type MetaRestorer struct {
+	sync.RWMutex
 	Cluster          *Cluster
 	recoveryInterval time.Duration
+	concurrency      int
+	running          bool
+	runs             []*RecoveryRun
 	stopCh           chan struct{}
 }
 
 // NewMetaRestorer is exported
-func NewMetaRestorer(recoveryInterval time.Duration) *MetaRestorer {
+func NewMetaRestorer(recoveryInterval time.Duration, concurrency int) *MetaRestorer {
+
+	if concurrency <= 0 {
+		concurrency = 1
+	}
 
 	return &MetaRestorer{
 		recoveryInterval: recoveryInterval,
-		stopCh:           make(chan struct{}),
+		concurrency:      concurrency,
+		runs:             []*RecoveryRun{},
 	}
 }
 
@@ -27,21 +62,90 @@ func (restorer *MetaRestorer) SetCluster(cluster *Cluster) {
 }
 
 // Start is exported
+// restorer can be started again after stopped, cluster leadership changed.
 func (restorer *MetaRestorer) Start() {
 
-	if restorer.Cluster != nil {
-		go restorer.doLoop()
+	if restorer.Cluster == nil {
+		return
+	}
+
+	restorer.Lock()
+	defer restorer.Unlock()
+	if restorer.stopCh == nil {
+		restorer.stopCh = make(chan struct{})
+		go restorer.doLoop(restorer.stopCh)
 	}
 }
 
 // Stop is exported
 func (restorer *MetaRestorer) Stop() {
 
-	close(restorer.stopCh)
+	restorer.Lock()
+	defer restorer.Unlock()
+	if restorer.stopCh != nil {
+		close(restorer.stopCh)
+		restorer.stopCh = nil
+	}
+}
+
+// Runs is exported
+// Return the last count recovery runs, newest first.
+func (restorer *MetaRestorer) Runs(count int) []*RecoveryRun {
+
+	restorer.RLock()
+	defer restorer.RUnlock()
+	if count <= 0 || count > len(restorer.runs) {
+		count = len(restorer.runs)
+	}
+
+	runs := []*RecoveryRun{}
+	for i := len(restorer.runs) - 1; i >= len(restorer.runs)-count; i-- {
+		run := *restorer.runs[i]
+		run.Errors = append([]string{}, restorer.runs[i].Errors...)
+		runs = append(runs, &run)
+	}
+	return runs
+}
+
+// Trigger is exported
+// Start a recovery run on demand, return the started run.
+func (restorer *MetaRestorer) Trigger() (*RecoveryRun, error) {
+
+	if restorer.Cluster == nil {
+		return nil, ErrClusterDiscoveryInvalid
+	}
+
+	if !restorer.Cluster.IsLeader() {
+		return nil, ErrClusterNotLeader
+	}
+
+	if restorer.Cluster.IsDraining() {
+		return nil, ErrClusterDraining
+	}
+
+	run := restorer.begin("manual")
+	if run == nil {
+		return nil, ErrClusterRecoveryRunning
+	}
+
+	started := *run
+	go restorer.recovery(run, restorer.stopChannel())
+	return &started, nil
+}
+
+// stopChannel, return the running loop stop channel, a stopped restorer return a never closed channel.
+func (restorer *MetaRestorer) stopChannel() chan struct{} {
+
+	restorer.RLock()
+	defer restorer.RUnlock()
+	if restorer.stopCh == nil {
+		return make(chan struct{})
+	}
+	return restorer.stopCh
 }
 
 // doLoop is exported
-func (restorer *MetaRestorer) doLoop() {
+func (restorer *MetaRestorer) doLoop(stopCh chan struct{}) {
 
 	for {
 		ticker := time.NewTicker(restorer.recoveryInterval)
@@ -49,9 +153,13 @@ func (restorer *MetaRestorer) doLoop() {
 		case <-ticker.C:
 			{
 				ticker.Stop()
-				restorer.recovery()
+				if run := restorer.begin("interval"); run != nil {
+					restorer.recovery(run, stopCh)
+				} else {
+					logger.WARN("[#cluster#] recovery run is already running, skipped.")
+				}
 			}
-		case <-restorer.stopCh:
+		case <-stopCh:
 			{
 				ticker.Stop()
 				return
@@ -60,30 +168,107 @@ func (restorer *MetaRestorer) doLoop() {
 	}
 }
 
-func (restorer *MetaRestorer) recovery() {
-
-	if restorer.Cluster != nil {
-		metaids := []string{}
-		metaEngines := make(map[string]*Engine)
-		groups := restorer.Cluster.GetGroups()
-		for _, group := range groups {
-			groupMetaData := restorer.Cluster.configCache.GetGroupMetaData(group.ID)
-			for _, metaData := range groupMetaData {
-				metaids = append(metaids, metaData.MetaID)
-				if _, engines, err := restorer.Cluster.GetMetaDataEngines(metaData.MetaID); err == nil {
-					for _, engine := range engines {
-						if engine.IsHealthy() && engine.HasMeta(metaData.MetaID) {
-							metaEngines[engine.IP] = engine
-						}
+// begin is exported
+// create a recovery run, return nil if a run is already running.
+func (restorer *MetaRestorer) begin(trigger string) *RecoveryRun {
+
+	restorer.Lock()
+	defer restorer.Unlock()
+	if restorer.running {
+		return nil
+	}
+
+	restorer.running = true
+	run := &RecoveryRun{
+		ID:      rand.UUID(true),
+		Trigger: trigger,
+		StartAt: time.Now(),
+		Errors:  []string{},
+	}
+	restorer.runs = append(restorer.runs, run)
+	if len(restorer.runs) > maxRecoveryRuns {
+		restorer.runs = restorer.runs[len(restorer.runs)-maxRecoveryRuns:]
+	}
+	return run
+}
+
+// end is exported
+func (restorer *MetaRestorer) end(run *RecoveryRun) {
+
+	restorer.Lock()
+	run.EndAt = time.Now()
+	restorer.running = false
+	restorer.Unlock()
+	logger.INFO("[#cluster#] recovery run %s done, checked:%d skipped:%d created:%d removed:%d errors:%d (%s)", run.ID,
+		run.MetasChecked, run.MetasSkipped, run.ContainersCreated, run.ContainersRemoved, len(run.Errors), run.EndAt.Sub(run.StartAt))
+}
+
+func (restorer *MetaRestorer) recovery(run *RecoveryRun, stopCh chan struct{}) {
+
+	defer restorer.end(run)
+	metaids := []string{}
+	metaEngines := make(map[string]*Engine)
+	groups := restorer.Cluster.GetGroups()
+	for _, group := range groups {
+		groupMetaData := restorer.Cluster.configCache.GetGroupMetaData(group.ID)
+		for _, metaData := range groupMetaData {
+			metaids = append(metaids, metaData.MetaID)
+			if _, engines, err := restorer.Cluster.GetMetaDataEngines(metaData.MetaID); err == nil {
+				for _, engine := range engines {
+					if engine.IsHealthy() && engine.HasMeta(metaData.MetaID) {
+						metaEngines[engine.IP] = engine
 					}
 				}
 			}
 		}
-		if len(metaids) > 0 {
-			restorer.Cluster.RefreshEnginesContainers(metaEngines)
-			for _, metaid := range metaids {
-				restorer.Cluster.RecoveryContainers(metaid)
+	}
+
+	if len(metaids) == 0 {
+		return
+	}
+
+	restorer.Cluster.RefreshEnginesContainers(metaEngines)
+	waitGroup := sync.WaitGroup{}
+	limitCh := make(chan struct{}, restorer.concurrency)
+	for i, metaid := range metaids {
+		if i > 0 {
+			select {
+			case <-time.After(time.Duration(mrand.Int63n(int64(recoveryJitter)))):
+			case <-stopCh:
+				waitGroup.Wait()
+				return
 			}
 		}
+		limitCh <- struct{}{}
+		waitGroup.Add(1)
+		go func(id string) {
+			defer func() {
+				<-limitCh
+				waitGroup.Done()
+			}()
+			created, removed, err := restorer.Cluster.RecoveryContainers(id)
+			if created > 0 || removed > 0 || (err != nil && err != ErrClusterContainersPaused) {
+				record := &AuditRecord{
+					RequestID:  run.ID,
+					Caller:     auditCallerCluster,
+					Operation:  AuditRecovery,
+					MetaID:     id,
+					Parameters: map[string]string{"Trigger": run.Trigger},
+				}
+				record.SetResult(map[string]int{"Created": created, "Removed": removed}, err)
+				restorer.Cluster.WriteAudit(record)
+			}
+			restorer.Lock()
+			run.MetasChecked = run.MetasChecked + 1
+			run.ContainersCreated = run.ContainersCreated + created
+			run.ContainersRemoved = run.ContainersRemoved + removed
+			if err == ErrClusterContainersPaused {
+				run.MetasSkipped = run.MetasSkipped + 1
+			} else if err != nil {
+				run.Errors = append(run.Errors, id+", "+err.Error())
+			}
+			restorer.Unlock()
+		}(metaid)
 	}
+	waitGroup.Wait()
 }
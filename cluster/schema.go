@@ -0,0 +1,183 @@
+package cluster
+
+import "humpback-center/cluster/storage"
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// current metadata schema version
+	metaSchemaVersion = 1
+)
+
+// metaSchemaUpgradeFunc, upgrade raw meta from version to version + 1, return changes description.
+type metaSchemaUpgradeFunc func(meta map[string]interface{}) ([]string, error)
+
+// metaSchemaUpgrades, metadata schema upgrade registry, key is the version upgrade from.
+// a struct change of MetaData, ContainerBaseConfig or models.Container must increase
+// metaSchemaVersion and register an upgrade func of the previous version.
+var metaSchemaUpgrades = map[int]metaSchemaUpgradeFunc{
+	0: upgradeMetaSchemaV0,
+}
+
+// MetaSchemaMigration is exported
+// a meta migrate to current schema version result.
+type MetaSchemaMigration struct {
+	MetaID      string   `json:"MetaId"`
+	FromVersion int      `json:"FromVersion"`
+	ToVersion   int      `json:"ToVersion"`
+	Changes     []string `json:"Changes"`
+	Error       string   `json:"Error"`
+}
+
+// MigrateMetaStore is exported
+// Rewrite all metas of metaStore to current schema version, return every meta migration.
+// metas can not be decoded are reported and not modified.
+func MigrateMetaStore(metaStore storage.MetaStore) ([]*MetaSchemaMigration, error) {
+
+	metaids, err := metaStore.Keys()
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := []*MetaSchemaMigration{}
+	for _, metaid := range metaids {
+		migration := &MetaSchemaMigration{
+			MetaID:    metaid,
+			ToVersion: metaSchemaVersion,
+			Changes:   []string{},
+		}
+		migrations = append(migrations, migration)
+		data, err := metaStore.Read(metaid)
+		if err != nil {
+			migration.Error = err.Error()
+			continue
+		}
+
+		metaData, changes, err := upgradeMetaData(data)
+		if err != nil {
+			migration.Error = err.Error()
+			continue
+		}
+
+		migration.FromVersion = metaData.fromVersion
+		migration.Changes = changes
+		buf, err := encodeMetaData(&metaData.MetaData)
+		if err != nil {
+			migration.Error = err.Error()
+			continue
+		}
+
+		if err := metaStore.Write(metaid, buf); err != nil {
+			migration.Error = err.Error()
+		}
+	}
+	return migrations, nil
+}
+
+// upgradedMetaData, a decoded meta with its stored schema version.
+type upgradedMetaData struct {
+	MetaData
+	fromVersion int
+}
+
+// upgradeMetaData, verify and decode stored data, apply registered upgrades to current schema version.
+func upgradeMetaData(data []byte) (*upgradedMetaData, []string, error) {
+
+	body, err := verifyMetaData(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	changes := []string{}
+	if !hasMetaHeader(data) {
+		changes = append(changes, "checksum header added")
+	}
+
+	// numbers are kept as json.Number, int64 fields such as PauseExpires not lose precision of float64.
+	meta := map[string]interface{}{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&meta); err != nil {
+		return nil, nil, fmt.Errorf("%s, %s", ErrClusterMetaDataCorrupted, err)
+	}
+
+	fromVersion := 0
+	if value, ret := meta["SchemaVersion"].(json.Number); ret {
+		version, err := value.Int64()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s, schema version %s invalid", ErrClusterMetaDataCorrupted, value)
+		}
+		fromVersion = int(version)
+	}
+
+	if fromVersion > metaSchemaVersion {
+		return nil, nil, fmt.Errorf("%s, schema version %d not supported", ErrClusterMetaDataCorrupted, fromVersion)
+	}
+
+	for version := fromVersion; version < metaSchemaVersion; version++ {
+		upgradeFunc, ret := metaSchemaUpgrades[version]
+		if !ret {
+			return nil, nil, fmt.Errorf("%s, schema version %d upgrade not registered", ErrClusterMetaDataCorrupted, version)
+		}
+		upgradeChanges, err := upgradeFunc(meta)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s, schema version %d upgrade error, %s", ErrClusterMetaDataCorrupted, version, err)
+		}
+		meta["SchemaVersion"] = version + 1
+		changes = append(changes, upgradeChanges...)
+		changes = append(changes, fmt.Sprintf("schema version %d to %d", version, version+1))
+	}
+
+	// a meta of current schema version is decoded from the stored body, only an upgraded meta is marshaled from map.
+	if fromVersion < metaSchemaVersion {
+		if body, err = json.Marshal(meta); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	metaData := &upgradedMetaData{fromVersion: fromVersion}
+	if err := json.Unmarshal(body, &metaData.MetaData); err != nil {
+		return nil, nil, fmt.Errorf("%s, %s", ErrClusterMetaDataCorrupted, err)
+	}
+
+	if metaData.MetaID == "" {
+		return nil, nil, fmt.Errorf("%s, metaid invalid", ErrClusterMetaDataCorrupted)
+	}
+	return metaData, changes, nil
+}
+
+// upgradeMetaSchemaV0, version 0 is meta stored before schema version,
+// BaseConfigs may be null, ImageTag may be empty and maintenance fields not exist.
+func upgradeMetaSchemaV0(meta map[string]interface{}) ([]string, error) {
+
+	changes := []string{}
+	if value, ret := meta["BaseConfigs"]; !ret || value == nil {
+		meta["BaseConfigs"] = []interface{}{}
+		changes = append(changes, "BaseConfigs set empty")
+	}
+
+	if value, _ := meta["ImageTag"].(string); value == "" {
+		imageTag := "latest"
+		if config, ret := meta["Config"].(map[string]interface{}); ret {
+			if image, _ := config["Image"].(string); image != "" {
+				_, imageTag = splitImageTag(image)
+			}
+		}
+		meta["ImageTag"] = imageTag
+		changes = append(changes, "ImageTag set "+imageTag)
+	}
+
+	if _, ret := meta["Paused"]; !ret {
+		meta["Paused"] = false
+		changes = append(changes, "Paused set false")
+	}
+
+	if _, ret := meta["PauseExpires"]; !ret {
+		meta["PauseExpires"] = 0
+	}
+	return changes, nil
+}
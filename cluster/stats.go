@@ -0,0 +1,199 @@
+package cluster
+
+import "github.com/docker/docker/api/types"
+import "github.com/humpback/gounits/http"
+import ctypes "humpback-center/cluster/types"
+import "humpback-center/metrics"
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// engine container stats request timeout
+	statsTimeout = 10 * time.Second
+)
+
+// ContainerStats is exported
+// CPUPercent: percent of a cpu, 200 means two cpus full usage.
+// MemoryUsage: memory usage without page cache, bytes.
+type ContainerStats struct {
+	ContainerID   string  `json:"ContainerId"`
+	Index         int     `json:"Index"`
+	IP            string  `json:"IP"`
+	HostName      string  `json:"HostName"`
+	CPUPercent    float64 `json:"CPUPercent"`
+	MemoryUsage   uint64  `json:"MemoryUsage"`
+	MemoryLimit   uint64  `json:"MemoryLimit"`
+	MemoryPercent float64 `json:"MemoryPercent"`
+	NetworkRx     uint64  `json:"NetworkRx"`
+	NetworkTx     uint64  `json:"NetworkTx"`
+}
+
+// StatsTotal is exported
+// sum of meta instances stats.
+type StatsTotal struct {
+	Instances   int     `json:"Instances"`
+	CPUPercent  float64 `json:"CPUPercent"`
+	MemoryUsage uint64  `json:"MemoryUsage"`
+	MemoryLimit uint64  `json:"MemoryLimit"`
+	NetworkRx   uint64  `json:"NetworkRx"`
+	NetworkTx   uint64  `json:"NetworkTx"`
+}
+
+// StatsFailure is exported
+// engine or container stats failure, ContainerID is empty when engine is unavailable.
+type StatsFailure struct {
+	IP          string `json:"IP"`
+	HostName    string `json:"HostName"`
+	ContainerID string `json:"ContainerId"`
+	Error       string `json:"Error"`
+}
+
+// MetaStats is exported
+type MetaStats struct {
+	MetaID    string            `json:"MetaId"`
+	Instances []*ContainerStats `json:"Instances"`
+	Total     StatsTotal        `json:"Total"`
+	Failures  []*StatsFailure   `json:"Failures"`
+}
+
+// ContainerStats is exported
+// Engine request agent one-shot container stats.
+func (engine *Engine) ContainerStats(containerid string) (*types.StatsJSON, error) {
+
+	query := map[string][]string{"stream": []string{"false"}}
+	start := time.Now()
+	respStats, err := http.NewWithTimeout(statsTimeout).Get("http://"+engine.APIAddr+"/v1/containers/"+containerid+"/stats", query, nil)
+	metrics.ObserveAgentRequest("stats", start, agentRequestError(respStats, err))
+	if err != nil {
+		return nil, err
+	}
+
+	defer respStats.Close()
+	if respStats.StatusCode() != 200 {
+		return nil, fmt.Errorf("engine %s, container %s stats failure, %s", engine.IP, containerid[:12], ctypes.ParseHTTPResponseError(respStats))
+	}
+
+	statsJSON := &types.StatsJSON{}
+	if err := respStats.JSON(statsJSON); err != nil {
+		return nil, err
+	}
+	return statsJSON, nil
+}
+
+// newContainerStats, calculate container stats figures, same as docker stats.
+func newContainerStats(statsJSON *types.StatsJSON) *ContainerStats {
+
+	containerStats := &ContainerStats{}
+	cpuDelta := float64(statsJSON.CPUStats.CPUUsage.TotalUsage) - float64(statsJSON.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(statsJSON.CPUStats.SystemUsage) - float64(statsJSON.PreCPUStats.SystemUsage)
+	onlineCPUs := float64(statsJSON.CPUStats.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(statsJSON.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		containerStats.CPUPercent = cpuDelta / systemDelta * onlineCPUs * 100
+	}
+
+	containerStats.MemoryUsage = statsJSON.MemoryStats.Usage
+	if cache, ret := statsJSON.MemoryStats.Stats["cache"]; ret && cache < containerStats.MemoryUsage {
+		containerStats.MemoryUsage -= cache
+	}
+	containerStats.MemoryLimit = statsJSON.MemoryStats.Limit
+	if containerStats.MemoryLimit > 0 {
+		containerStats.MemoryPercent = float64(containerStats.MemoryUsage) / float64(containerStats.MemoryLimit) * 100
+	}
+
+	for _, network := range statsJSON.Networks {
+		containerStats.NetworkRx += network.RxBytes
+		containerStats.NetworkTx += network.TxBytes
+	}
+	return containerStats
+}
+
+// GetMetaStats is exported
+// fan out to containers of meta in parallel, engines or containers stats failure are returned as partial failures.
+func (cluster *Cluster) GetMetaStats(metaid string) (*MetaStats, error) {
+
+	metaData, engines, err := cluster.GetMetaDataEngines(metaid)
+	if err != nil {
+		return nil, err
+	}
+
+	metaStats := &MetaStats{
+		MetaID:    metaData.MetaID,
+		Instances: []*ContainerStats{},
+		Failures:  []*StatsFailure{},
+	}
+
+	mutex := sync.Mutex{}
+	waitGroup := sync.WaitGroup{}
+	for _, engine := range engines {
+		if !engine.HasMeta(metaData.MetaID) {
+			continue
+		}
+
+		if !engine.IsHealthy() {
+			mutex.Lock()
+			metaStats.Failures = append(metaStats.Failures, &StatsFailure{
+				IP:       engine.IP,
+				HostName: engine.Name,
+				Error:    fmt.Sprintf("engine state is %s", engine.State()),
+			})
+			mutex.Unlock()
+			continue
+		}
+
+		//containers stats are requested in parallel, a hung container not delays the others.
+		for _, container := range engine.Containers(metaData.MetaID) {
+			waitGroup.Add(1)
+			go func(e *Engine, c *Container) {
+				defer waitGroup.Done()
+				statsJSON, err := e.ContainerStats(c.Info.ID)
+				mutex.Lock()
+				defer mutex.Unlock()
+				if err != nil {
+					metaStats.Failures = append(metaStats.Failures, &StatsFailure{
+						IP:          e.IP,
+						HostName:    e.Name,
+						ContainerID: c.Info.ID,
+						Error:       err.Error(),
+					})
+					return
+				}
+				containerStats := newContainerStats(statsJSON)
+				containerStats.ContainerID = c.Info.ID
+				containerStats.Index = c.Index()
+				containerStats.IP = e.IP
+				containerStats.HostName = e.Name
+				metaStats.Instances = append(metaStats.Instances, containerStats)
+			}(engine, container)
+		}
+	}
+	waitGroup.Wait()
+
+	sort.Slice(metaStats.Instances, func(i, j int) bool {
+		return metaStats.Instances[i].Index < metaStats.Instances[j].Index
+	})
+
+	sort.Slice(metaStats.Failures, func(i, j int) bool {
+		if metaStats.Failures[i].IP != metaStats.Failures[j].IP {
+			return metaStats.Failures[i].IP < metaStats.Failures[j].IP
+		}
+		return metaStats.Failures[i].ContainerID < metaStats.Failures[j].ContainerID
+	})
+
+	for _, containerStats := range metaStats.Instances {
+		metaStats.Total.Instances++
+		metaStats.Total.CPUPercent += containerStats.CPUPercent
+		metaStats.Total.MemoryUsage += containerStats.MemoryUsage
+		metaStats.Total.MemoryLimit += containerStats.MemoryLimit
+		metaStats.Total.NetworkRx += containerStats.NetworkRx
+		metaStats.Total.NetworkTx += containerStats.NetworkTx
+	}
+	return metaStats, nil
+}
@@ -0,0 +1,204 @@
+package storage
+
+import "github.com/humpback/gounits/system"
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// file metastore quarantine directory name
+	quarantineDirectory = "quarantine"
+	// file metastore write temp file prefix
+	tempFilePrefix = ".tmp-"
+)
+
+// FileMetaStore is exported
+// one file per meta under root directory.
+type FileMetaStore struct {
+	Root string
+}
+
+// NewFileMetaStore is exported
+func NewFileMetaStore(root string) (*FileMetaStore, error) {
+
+	if len(strings.TrimSpace(root)) == 0 {
+		root = "./cache"
+	}
+
+	if err := system.MakeDirectory(root); err != nil {
+		return nil, fmt.Errorf("metastore directory init error:%s", err.Error())
+	}
+
+	return &FileMetaStore{
+		Root: root,
+	}, nil
+}
+
+// Keys is exported
+func (store *FileMetaStore) Keys() ([]string, error) {
+
+	return store.readKeys(store.Root)
+}
+
+// Read is exported
+func (store *FileMetaStore) Read(metaid string) ([]byte, error) {
+
+	metaPath, err := store.metaPath(metaid)
+	if err != nil {
+		return nil, err
+	}
+	return readFile(metaPath)
+}
+
+// Write is exported
+// write to a temp file, fsync and rename, a crash never truncates a meta file.
+func (store *FileMetaStore) Write(metaid string, data []byte) error {
+
+	metaPath, err := store.metaPath(metaid)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(metaPath)
+	fd, err := ioutil.TempFile(dir, tempFilePrefix+metaid+"-")
+	if err != nil {
+		return err
+	}
+
+	tempPath := fd.Name()
+	if _, err := fd.Write(data); err != nil {
+		fd.Close()
+		os.Remove(tempPath)
+		return err
+	}
+
+	if err := fd.Sync(); err != nil {
+		fd.Close()
+		os.Remove(tempPath)
+		return err
+	}
+
+	if err := fd.Close(); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+
+	if err := os.Chmod(tempPath, 0777); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+
+	if err := os.Rename(tempPath, metaPath); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	syncDirectory(dir)
+	return nil
+}
+
+// Remove is exported
+func (store *FileMetaStore) Remove(metaid string) error {
+
+	metaPath, err := store.metaPath(metaid)
+	if err != nil {
+		return err
+	}
+	return os.Remove(metaPath)
+}
+
+// Quarantine is exported
+// move meta file to root/quarantine directory.
+func (store *FileMetaStore) Quarantine(metaid string) error {
+
+	metaPath, err := store.metaPath(metaid)
+	if err != nil {
+		return err
+	}
+
+	quarantinePath, err := store.quarantinePath(metaid)
+	if err != nil {
+		return err
+	}
+
+	if err := system.MakeDirectory(filepath.Dir(quarantinePath)); err != nil {
+		return err
+	}
+
+	if err := os.Rename(metaPath, quarantinePath); err != nil {
+		return err
+	}
+	syncDirectory(filepath.Dir(metaPath))
+	return nil
+}
+
+// QuarantineKeys is exported
+func (store *FileMetaStore) QuarantineKeys() ([]string, error) {
+
+	keys, err := store.readKeys(filepath.Join(store.Root, quarantineDirectory))
+	if err != nil && os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	return keys, err
+}
+
+// ReadQuarantine is exported
+func (store *FileMetaStore) ReadQuarantine(metaid string) ([]byte, error) {
+
+	quarantinePath, err := store.quarantinePath(metaid)
+	if err != nil {
+		return nil, err
+	}
+	return readFile(quarantinePath)
+}
+
+func (store *FileMetaStore) readKeys(dir string) ([]string, error) {
+
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	for _, fi := range fis {
+		if !fi.IsDir() && !strings.HasPrefix(fi.Name(), ".") {
+			keys = append(keys, fi.Name())
+		}
+	}
+	return keys, nil
+}
+
+func (store *FileMetaStore) metaPath(metaid string) (string, error) {
+
+	return filepath.Abs(store.Root + "/" + metaid)
+}
+
+func (store *FileMetaStore) quarantinePath(metaid string) (string, error) {
+
+	return filepath.Abs(store.Root + "/" + quarantineDirectory + "/" + metaid)
+}
+
+func readFile(path string) ([]byte, error) {
+
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrMetaStoreKeyNotFound
+		}
+		return nil, err
+	}
+	return buf, nil
+}
+
+// syncDirectory, make rename durable, some platforms not support directory sync.
+func syncDirectory(dir string) {
+
+	if fd, err := os.Open(dir); err == nil {
+		fd.Sync()
+		fd.Close()
+	}
+}
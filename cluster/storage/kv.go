@@ -0,0 +1,194 @@
+package storage
+
+import "github.com/docker/libkv"
+import "github.com/docker/libkv/store"
+import "github.com/docker/libkv/store/consul"
+import "github.com/docker/libkv/store/etcd"
+import "github.com/docker/libkv/store/zookeeper"
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	// kv backend connection timeout
+	kvConnectionTimeout = 10 * time.Second
+)
+
+func init() {
+
+	zookeeper.Register()
+	consul.Register()
+	etcd.Register()
+}
+
+// KVMetaStore is exported
+// one key per meta under kv.path prefix.
+// quarantined metas are under kv.path + ".quarantine" prefix.
+type KVMetaStore struct {
+	Prefix string
+	kv     store.Store
+}
+
+// NewKVMetaStore is exported
+// uris is the same as discovery uris, eg: zk://192.168.2.80:2181,192.168.2.81:2181
+func NewKVMetaStore(uris string, configopts map[string]string) (*KVMetaStore, error) {
+
+	kv, err := NewKVStore(uris)
+	if err != nil {
+		return nil, fmt.Errorf("metastore %s", err.Error())
+	}
+	return NewKVMetaStoreWithStore(kv, configopts["kv.path"]), nil
+}
+
+// NewKVStore is exported
+// open a kv store of discovery uris, eg: zk://192.168.2.80:2181,192.168.2.81:2181
+func NewKVStore(uris string) (store.Store, error) {
+
+	parts := strings.SplitN(uris, "://", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("kv uris invalid, %s", uris)
+	}
+
+	var backend store.Backend
+	switch parts[0] {
+	case "zk":
+		backend = store.ZK
+	case "consul":
+		backend = store.CONSUL
+	case "etcd":
+		backend = store.ETCD
+	default:
+		return nil, fmt.Errorf("kv backend %s unsupported", parts[0])
+	}
+
+	hosts := parts[1]
+	if nPos := strings.Index(hosts, "/"); nPos >= 0 {
+		hosts = hosts[:nPos]
+	}
+
+	addrs := strings.Split(hosts, ",")
+	return libkv.NewStore(backend, addrs, &store.Config{ConnectionTimeout: kvConnectionTimeout})
+}
+
+// NewKVMetaStoreWithStore is exported
+// create a kv metastore of an opened kv store.
+func NewKVMetaStoreWithStore(kv store.Store, prefix string) *KVMetaStore {
+
+	prefix = strings.Trim(strings.TrimSpace(prefix), "/")
+	if prefix == "" {
+		prefix = "humpback/center/metas"
+	}
+
+	return &KVMetaStore{
+		Prefix: prefix,
+		kv:     kv,
+	}
+}
+
+// Keys is exported
+func (metaStore *KVMetaStore) Keys() ([]string, error) {
+
+	return metaStore.listKeys(metaStore.Prefix)
+}
+
+// Read is exported
+func (metaStore *KVMetaStore) Read(metaid string) ([]byte, error) {
+
+	pair, err := metaStore.kv.Get(metaStore.metaKey(metaid))
+	if err != nil {
+		if err == store.ErrKeyNotFound {
+			return nil, ErrMetaStoreKeyNotFound
+		}
+		return nil, err
+	}
+	return pair.Value, nil
+}
+
+// Write is exported
+func (metaStore *KVMetaStore) Write(metaid string, data []byte) error {
+
+	return metaStore.kv.Put(metaStore.metaKey(metaid), data, nil)
+}
+
+// Remove is exported
+func (metaStore *KVMetaStore) Remove(metaid string) error {
+
+	if err := metaStore.kv.Delete(metaStore.metaKey(metaid)); err != nil {
+		if err == store.ErrKeyNotFound {
+			return ErrMetaStoreKeyNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// Quarantine is exported
+func (metaStore *KVMetaStore) Quarantine(metaid string) error {
+
+	data, err := metaStore.Read(metaid)
+	if err != nil {
+		return err
+	}
+
+	if err := metaStore.kv.Put(metaStore.quarantineKey(metaid), data, nil); err != nil {
+		return err
+	}
+	return metaStore.Remove(metaid)
+}
+
+// QuarantineKeys is exported
+func (metaStore *KVMetaStore) QuarantineKeys() ([]string, error) {
+
+	return metaStore.listKeys(metaStore.quarantinePrefix())
+}
+
+// ReadQuarantine is exported
+func (metaStore *KVMetaStore) ReadQuarantine(metaid string) ([]byte, error) {
+
+	pair, err := metaStore.kv.Get(metaStore.quarantineKey(metaid))
+	if err != nil {
+		if err == store.ErrKeyNotFound {
+			return nil, ErrMetaStoreKeyNotFound
+		}
+		return nil, err
+	}
+	return pair.Value, nil
+}
+
+func (metaStore *KVMetaStore) listKeys(prefix string) ([]string, error) {
+
+	pairs, err := metaStore.kv.List(prefix)
+	if err != nil {
+		if err == store.ErrKeyNotFound {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	keys := []string{}
+	for _, pair := range pairs {
+		if key := path.Base(pair.Key); key != "" && key != path.Base(prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (metaStore *KVMetaStore) metaKey(metaid string) string {
+
+	return metaStore.Prefix + "/" + metaid
+}
+
+func (metaStore *KVMetaStore) quarantinePrefix() string {
+
+	return metaStore.Prefix + ".quarantine"
+}
+
+func (metaStore *KVMetaStore) quarantineKey(metaid string) string {
+
+	return metaStore.quarantinePrefix() + "/" + metaid
+}
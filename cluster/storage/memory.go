@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"sync"
+)
+
+// MemoryMetaStore is exported
+// in-memory metastore, data is not persisted.
+type MemoryMetaStore struct {
+	sync.RWMutex
+	data       map[string][]byte
+	quarantine map[string][]byte
+}
+
+// NewMemoryMetaStore is exported
+func NewMemoryMetaStore() *MemoryMetaStore {
+
+	return &MemoryMetaStore{
+		data:       make(map[string][]byte),
+		quarantine: make(map[string][]byte),
+	}
+}
+
+// Keys is exported
+func (store *MemoryMetaStore) Keys() ([]string, error) {
+
+	store.RLock()
+	defer store.RUnlock()
+	keys := []string{}
+	for key := range store.data {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Read is exported
+func (store *MemoryMetaStore) Read(metaid string) ([]byte, error) {
+
+	store.RLock()
+	defer store.RUnlock()
+	data, ret := store.data[metaid]
+	if !ret {
+		return nil, ErrMetaStoreKeyNotFound
+	}
+	return append([]byte{}, data...), nil
+}
+
+// Write is exported
+func (store *MemoryMetaStore) Write(metaid string, data []byte) error {
+
+	store.Lock()
+	store.data[metaid] = append([]byte{}, data...)
+	store.Unlock()
+	return nil
+}
+
+// Remove is exported
+func (store *MemoryMetaStore) Remove(metaid string) error {
+
+	store.Lock()
+	defer store.Unlock()
+	if _, ret := store.data[metaid]; !ret {
+		return ErrMetaStoreKeyNotFound
+	}
+	delete(store.data, metaid)
+	return nil
+}
+
+// Quarantine is exported
+func (store *MemoryMetaStore) Quarantine(metaid string) error {
+
+	store.Lock()
+	defer store.Unlock()
+	data, ret := store.data[metaid]
+	if !ret {
+		return ErrMetaStoreKeyNotFound
+	}
+	store.quarantine[metaid] = data
+	delete(store.data, metaid)
+	return nil
+}
+
+// QuarantineKeys is exported
+func (store *MemoryMetaStore) QuarantineKeys() ([]string, error) {
+
+	store.RLock()
+	defer store.RUnlock()
+	keys := []string{}
+	for key := range store.quarantine {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// ReadQuarantine is exported
+func (store *MemoryMetaStore) ReadQuarantine(metaid string) ([]byte, error) {
+
+	store.RLock()
+	defer store.RUnlock()
+	data, ret := store.quarantine[metaid]
+	if !ret {
+		return nil, ErrMetaStoreKeyNotFound
+	}
+	return append([]byte{}, data...), nil
+}
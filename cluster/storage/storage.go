@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+)
+
+// storage errors define
+var (
+	//metastore backend invalid
+	ErrMetaStoreBackendInvalid = errors.New("metastore backend invalid")
+	//metastore key not found
+	ErrMetaStoreKeyNotFound = errors.New("metastore key not found")
+)
+
+// MetaStore is exported
+// cluster meta data persistent store interface
+// Keys: return all stored metaids.
+// Quarantine: move a meta that can not be decoded out of keys, keep it for repair.
+// QuarantineKeys: return all quarantined metaids.
+// ReadQuarantine: return a quarantined meta raw data.
+type MetaStore interface {
+	Keys() ([]string, error)
+	Read(metaid string) ([]byte, error)
+	Write(metaid string, data []byte) error
+	Remove(metaid string) error
+	Quarantine(metaid string) error
+	QuarantineKeys() ([]string, error)
+	ReadQuarantine(metaid string) ([]byte, error)
+}
+
+// NewMetaStore is exported
+// backend: file or kv, default file.
+// root: file backend cache root directory.
+// uris: kv backend discovery uris, eg: zk://192.168.2.80:2181,192.168.2.81:2181
+// configopts: kv backend opts, kv.path is meta keys prefix.
+func NewMetaStore(backend string, root string, uris string, configopts map[string]string) (MetaStore, error) {
+
+	switch strings.ToLower(strings.TrimSpace(backend)) {
+	case "", "file":
+		return NewFileMetaStore(root)
+	case "kv":
+		return NewKVMetaStore(uris, configopts)
+	}
+	return nil, ErrMetaStoreBackendInvalid
+}
@@ -0,0 +1,166 @@
+package storage
+
+import "github.com/docker/libkv/store"
+
+import (
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// memoryKVStore, in-memory libkv store, only the methods used by KVMetaStore.
+type memoryKVStore struct {
+	store.Store
+	sync.Mutex
+	pairs map[string][]byte
+}
+
+func newMemoryKVStore() *memoryKVStore {
+
+	return &memoryKVStore{pairs: make(map[string][]byte)}
+}
+
+func (kv *memoryKVStore) Put(key string, value []byte, options *store.WriteOptions) error {
+
+	kv.Lock()
+	kv.pairs[key] = append([]byte{}, value...)
+	kv.Unlock()
+	return nil
+}
+
+func (kv *memoryKVStore) Get(key string) (*store.KVPair, error) {
+
+	kv.Lock()
+	defer kv.Unlock()
+	value, ret := kv.pairs[key]
+	if !ret {
+		return nil, store.ErrKeyNotFound
+	}
+	return &store.KVPair{Key: key, Value: value}, nil
+}
+
+func (kv *memoryKVStore) Delete(key string) error {
+
+	kv.Lock()
+	defer kv.Unlock()
+	if _, ret := kv.pairs[key]; !ret {
+		return store.ErrKeyNotFound
+	}
+	delete(kv.pairs, key)
+	return nil
+}
+
+func (kv *memoryKVStore) List(directory string) ([]*store.KVPair, error) {
+
+	kv.Lock()
+	defer kv.Unlock()
+	pairs := []*store.KVPair{}
+	for key, value := range kv.pairs {
+		if strings.HasPrefix(key, directory+"/") {
+			pairs = append(pairs, &store.KVPair{Key: key, Value: value})
+		}
+	}
+	if len(pairs) == 0 {
+		return nil, store.ErrKeyNotFound
+	}
+	return pairs, nil
+}
+
+func testMetaStore(t *testing.T, metaStore MetaStore) {
+
+	keys, err := metaStore.Keys()
+	if err != nil || len(keys) != 0 {
+		t.Fatalf("empty store keys %v, %v", keys, err)
+	}
+
+	if _, err := metaStore.Read("missing"); err != ErrMetaStoreKeyNotFound {
+		t.Fatalf("read missing meta error %v, want ErrMetaStoreKeyNotFound", err)
+	}
+
+	for _, metaid := range []string{"meta1", "meta2"} {
+		if err := metaStore.Write(metaid, []byte(`{"MetaID":"`+metaid+`"}`)); err != nil {
+			t.Fatalf("write %s error, %v", metaid, err)
+		}
+	}
+
+	if err := metaStore.Write("meta1", []byte(`{"MetaID":"meta1","Instances":2}`)); err != nil {
+		t.Fatalf("overwrite meta1 error, %v", err)
+	}
+
+	data, err := metaStore.Read("meta1")
+	if err != nil || string(data) != `{"MetaID":"meta1","Instances":2}` {
+		t.Fatalf("read meta1 %s, %v", data, err)
+	}
+
+	keys, _ = metaStore.Keys()
+	sort.Strings(keys)
+	if strings.Join(keys, ",") != "meta1,meta2" {
+		t.Fatalf("keys %v, want meta1,meta2", keys)
+	}
+
+	if err := metaStore.Quarantine("meta2"); err != nil {
+		t.Fatalf("quarantine meta2 error, %v", err)
+	}
+
+	keys, _ = metaStore.Keys()
+	if strings.Join(keys, ",") != "meta1" {
+		t.Fatalf("keys after quarantine %v, want meta1", keys)
+	}
+
+	keys, _ = metaStore.QuarantineKeys()
+	if strings.Join(keys, ",") != "meta2" {
+		t.Fatalf("quarantine keys %v, want meta2", keys)
+	}
+
+	data, err = metaStore.ReadQuarantine("meta2")
+	if err != nil || string(data) != `{"MetaID":"meta2"}` {
+		t.Fatalf("read quarantine meta2 %s, %v", data, err)
+	}
+
+	if err := metaStore.Remove("meta1"); err != nil {
+		t.Fatalf("remove meta1 error, %v", err)
+	}
+
+	if _, err := metaStore.Read("meta1"); err != ErrMetaStoreKeyNotFound {
+		t.Fatalf("read removed meta1 error %v, want ErrMetaStoreKeyNotFound", err)
+	}
+}
+
+func TestMemoryMetaStore(t *testing.T) {
+
+	testMetaStore(t, NewMemoryMetaStore())
+}
+
+func TestFileMetaStore(t *testing.T) {
+
+	root, err := ioutil.TempDir("", "metastore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	metaStore, err := NewFileMetaStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testMetaStore(t, metaStore)
+}
+
+func TestKVMetaStore(t *testing.T) {
+
+	kv := newMemoryKVStore()
+	testMetaStore(t, NewKVMetaStoreWithStore(kv, "/humpback/center/metas/"))
+	if _, ret := kv.pairs["humpback/center/metas.quarantine/meta2"]; !ret {
+		t.Fatalf("quarantined meta key not under kv.path prefix, %v", kv.pairs)
+	}
+}
+
+func TestNewMetaStoreBackendInvalid(t *testing.T) {
+
+	if _, err := NewMetaStore("redis", "", "", nil); err != ErrMetaStoreBackendInvalid {
+		t.Fatalf("backend redis error %v, want ErrMetaStoreBackendInvalid", err)
+	}
+}
@@ -6,6 +6,8 @@ import "common/models"
 type EngineContainer struct {
 	IP        string           `json:"IP"`
 	HostName  string           `json:"HostName"`
+	Index     int              `json:"Index"`
+	State     string           `json:"State"`
 	Container models.Container `json:"Container"`
 }
 
@@ -25,6 +25,23 @@ const (
 	UpgradeRecovery
 )
 
+func (state UpgradeState) String() string {
+
+	switch state {
+	case UpgradeReady:
+		return "UpgradeReady"
+	case UpgradeIgnore:
+		return "UpgradeIgnore"
+	case UpgradeCompleted:
+		return "UpgradeCompleted"
+	case UpgradeFailure:
+		return "UpgradeFailure"
+	case UpgradeRecovery:
+		return "UpgradeRecovery"
+	}
+	return ""
+}
+
 // UpgradeContainer is exported
 type UpgradeContainer struct {
 	Original *Container
@@ -82,14 +99,18 @@ type Upgrader struct {
 	OriginalTag   string
 	NewTag        string
 	configCache   *ContainersConfigCache
+	events        *EventStream
+	job           *Job
 	delayInterval time.Duration
 	callback      UpgraderHandleFunc
 	containers    []*UpgradeContainer
+	drainCh       <-chan struct{}
+	drained       bool
 }
 
 // NewUpgrader is exported
 func NewUpgrader(metaid string, originalTag string, newTag string, containers Containers, upgradeDelay time.Duration,
-	configCache *ContainersConfigCache, callback UpgraderHandleFunc) *Upgrader {
+	configCache *ContainersConfigCache, events *EventStream, job *Job, callback UpgraderHandleFunc) *Upgrader {
 
 	upgradeContainers := []*UpgradeContainer{}
 	for _, container := range containers {
@@ -107,6 +128,8 @@ func NewUpgrader(metaid string, originalTag string, newTag string, containers Co
 		OriginalTag:   originalTag,
 		NewTag:        newTag,
 		configCache:   configCache,
+		events:        events,
+		job:           job,
 		delayInterval: upgradeDelay,
 		callback:      callback,
 		containers:    upgradeContainers,
@@ -114,6 +137,8 @@ func NewUpgrader(metaid string, originalTag string, newTag string, containers Co
 }
 
 // Start is exported
+// cluster draining, upgrader stop at a safe point, between two containers upgrade,
+// upgraded containers are recovered to original tag as a failure, meta image tag is original tag, progress is written to audit.
 func (upgrader *Upgrader) Start(upgradeCh chan<- bool) {
 
 	var (
@@ -127,8 +152,22 @@ func (upgrader *Upgrader) Start(upgradeCh chan<- bool) {
 	upgrader.Lock()
 	defer upgrader.Unlock()
 	upgrader.configCache.SetImageTag(upgrader.MetaID, upgrader.NewTag)
-	for _, upgradeContainer := range upgrader.containers {
-		if err = upgradeContainer.Execute(upgrader.NewTag); err != nil {
+	for index, upgradeContainer := range upgrader.containers {
+		upgrader.job.SetProgress(index, len(upgrader.containers))
+		if upgrader.job.IsCanceled() {
+			err = ErrClusterJobCanceled
+			errMsgs = append(errMsgs, "upgrade canceled")
+			logger.WARN("[#cluster#] upgrade %s canceled, recovery upgraded containers.", upgrader.MetaID)
+			break
+		}
+		if upgrader.drainSignaled() {
+			upgrader.drained = true
+			logger.WARN("[#cluster#] upgrade %s stopped, cluster is draining, %d of %d upgraded.", upgrader.MetaID, index, len(upgrader.containers))
+			break
+		}
+		err = upgradeContainer.Execute(upgrader.NewTag)
+		upgrader.publishProgress(upgradeContainer.Original.Config.ID, upgradeContainer.State, index+1, err)
+		if err != nil {
 			upgrader.configCache.RemoveContainerBaseConfig(upgrader.MetaID, upgradeContainer.Original.Config.ID)
 			errMsgs = append(errMsgs, "upgrade container execute, "+err.Error())
 			logger.ERROR("[#cluster#] upgrade container %s execute %s", upgradeContainer.Original.Config.ID[:12], err.Error())
@@ -136,12 +175,20 @@ func (upgrader *Upgrader) Start(upgradeCh chan<- bool) {
 		}
 	}
 
-	if err != nil { //recovery upgrade completed containers
+	if upgrader.drained {
+		errMsgs = append(errMsgs, "upgrade stopped, cluster is draining")
+	} else if err == nil {
+		upgrader.job.SetProgress(len(upgrader.containers), len(upgrader.containers))
+	}
+
+	if err != nil || upgrader.drained { //recovery upgrade completed containers
 		ret = false
 		upgrader.configCache.SetImageTag(upgrader.MetaID, upgrader.OriginalTag)
 		for _, upgradeContainer := range upgrader.containers {
 			if upgradeContainer.State == UpgradeCompleted {
-				if err := upgradeContainer.Recovery(upgrader.OriginalTag); err != nil {
+				err := upgradeContainer.Recovery(upgrader.OriginalTag)
+				upgrader.publishProgress(upgradeContainer.New.Config.ID, upgradeContainer.State, 0, err)
+				if err != nil {
 					upgrader.configCache.RemoveContainerBaseConfig(upgrader.MetaID, upgradeContainer.New.Config.ID)
 					errMsgs = append(errMsgs, "upgrade container recovery, "+err.Error())
 					logger.ERROR("[#cluster#] upgrade container %s recovery %s", upgradeContainer.New.Config.ID[:12], err.Error())
@@ -153,6 +200,62 @@ func (upgrader *Upgrader) Start(upgradeCh chan<- bool) {
 	upgradeCh <- ret
 }
 
+// drainSignaled, return true if cluster drain channel is closed.
+func (upgrader *Upgrader) drainSignaled() bool {
+
+	select {
+	case <-upgrader.drainCh:
+		return true
+	default:
+	}
+	return false
+}
+
+// progress, return recovered container ids, recovery failure container ids and not upgraded container ids.
+func (upgrader *Upgrader) progress() ([]string, []string, []string) {
+
+	recovered := []string{}
+	failed := []string{}
+	remaining := []string{}
+	for _, upgradeContainer := range upgrader.containers {
+		switch upgradeContainer.State {
+		case UpgradeRecovery:
+			recovered = append(recovered, upgradeContainer.Original.Config.ID)
+		case UpgradeFailure, UpgradeCompleted:
+			if upgradeContainer.New != nil {
+				failed = append(failed, upgradeContainer.New.Config.ID)
+			} else {
+				failed = append(failed, upgradeContainer.Original.Config.ID)
+			}
+		default:
+			remaining = append(remaining, upgradeContainer.Original.Config.ID)
+		}
+	}
+	return recovered, failed, remaining
+}
+
+// publishProgress publish upgrade container state, completed is containers upgraded count.
+func (upgrader *Upgrader) publishProgress(containerid string, state UpgradeState, completed int, err error) {
+
+	event := &ClusterEvent{
+		Type:        UpgradeProgressEvent,
+		MetaID:      upgrader.MetaID,
+		ContainerID: containerid,
+		State:       state.String(),
+		Completed:   completed,
+		Total:       len(upgrader.containers),
+	}
+
+	if metaData := upgrader.configCache.GetMetaData(upgrader.MetaID); metaData != nil {
+		event.GroupIDs = []string{metaData.GroupID}
+	}
+
+	if err != nil {
+		event.Message = err.Error()
+	}
+	upgrader.events.Publish(event)
+}
+
 // UpgraderHandleFunc exported
 type UpgraderHandleFunc func(upgrader *Upgrader, errMsgs []string)
 
@@ -179,29 +282,59 @@ func (cache *UpgradeContainersCache) SetCluster(cluster *Cluster) {
 	cache.Cluster = cluster
 }
 
+// Reserve is exported
+// reserve meta as upgrading before image check and pre-pull, meta is upgrading return ErrClusterContainersUpgrading.
+// a reserved meta is released by Release or replaced by Upgrade.
+func (cache *UpgradeContainersCache) Reserve(metaid string) error {
+
+	cache.Lock()
+	defer cache.Unlock()
+	if _, ret := cache.upgraders[metaid]; ret {
+		return ErrClusterContainersUpgrading
+	}
+	cache.upgraders[metaid] = nil
+	return nil
+}
+
+// Release is exported
+// release a reserved meta not started upgrade, a running upgrader is not released.
+func (cache *UpgradeContainersCache) Release(metaid string) {
+
+	cache.Lock()
+	if upgrader, ret := cache.upgraders[metaid]; ret && upgrader == nil {
+		delete(cache.upgraders, metaid)
+	}
+	cache.Unlock()
+}
+
 // Upgrade is exported
-func (cache *UpgradeContainersCache) Upgrade(upgradeCh chan<- bool, metaid string, newTag string, containers Containers) {
+// start upgrader of a reserved or not upgrading meta, the result is sent to upgradeCh.
+// job is canceled, upgrader stop and recovery upgraded containers.
+func (cache *UpgradeContainersCache) Upgrade(upgradeCh chan<- bool, metaid string, newTag string, containers Containers, job *Job) error {
 
 	if cache.Cluster == nil || cache.Cluster.configCache == nil {
-		return
+		return ErrClusterMetaDataNotFound
 	}
 
 	configCache := cache.Cluster.configCache
 	metaData := configCache.GetMetaData(metaid)
 	if metaData == nil {
-		return
+		cache.Release(metaid)
+		return ErrClusterMetaDataNotFound
 	}
 
 	cache.Lock()
-	if _, ret := cache.upgraders[metaid]; !ret {
-		upgrader := NewUpgrader(metaData.MetaID, metaData.ImageTag, newTag, containers, cache.delayInterval, configCache, cache.UpgraderHandleFunc)
-		if upgrader != nil {
-			cache.upgraders[metaData.MetaID] = upgrader
-			logger.INFO("[#cluster#] upgrade start %s > %s", upgrader.MetaID, upgrader.NewTag)
-			go upgrader.Start(upgradeCh)
-		}
+	defer cache.Unlock()
+	if upgrader := cache.upgraders[metaid]; upgrader != nil {
+		return ErrClusterContainersUpgrading
 	}
-	cache.Unlock()
+
+	upgrader := NewUpgrader(metaData.MetaID, metaData.ImageTag, newTag, containers, cache.delayInterval, configCache, cache.Cluster.events, job, cache.UpgraderHandleFunc)
+	upgrader.drainCh = cache.Cluster.drainCh
+	cache.upgraders[metaData.MetaID] = upgrader
+	logger.INFO("[#cluster#] upgrade start %s > %s", upgrader.MetaID, upgrader.NewTag)
+	go upgrader.Start(upgradeCh)
+	return nil
 }
 
 // Contains is exported
@@ -213,12 +346,31 @@ func (cache *UpgradeContainersCache) Contains(metaid string) bool {
 	return ret
 }
 
+// MetaIDs is exported
+// Return metaids of upgrading, include reserved metas.
+func (cache *UpgradeContainersCache) MetaIDs() []string {
+
+	cache.RLock()
+	defer cache.RUnlock()
+	metaids := []string{}
+	for metaid := range cache.upgraders {
+		metaids = append(metaids, metaid)
+	}
+	return metaids
+}
+
 // UpgraderHandleFunc is exported
 func (cache *UpgradeContainersCache) UpgraderHandleFunc(upgrader *Upgrader, errMsgs []string) {
 
 	cache.Lock()
 	delete(cache.upgraders, upgrader.MetaID)
 	cache.Unlock()
+	if cache.Cluster != nil && upgrader.drained {
+		recovered, failed, remaining := upgrader.progress()
+		progress := map[string]interface{}{"NewTag": upgrader.NewTag, "Recovered": recovered, "RecoveryFailed": failed, "Remaining": remaining}
+		cache.Cluster.writeInterruptedAudit(AuditUpgrade, upgrader.MetaID, progress, ErrClusterDraining)
+	}
+
 	if cache.Cluster != nil {
 		if _, engines, err := cache.Cluster.GetMetaDataEngines(upgrader.MetaID); err == nil {
 			metaEngines := make(map[string]*Engine)
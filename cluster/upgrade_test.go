@@ -0,0 +1,113 @@
+package cluster
+
+import "humpback-center/cluster/storage"
+import "common/models"
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestUpgradeContainersCacheReserve(t *testing.T) {
+
+	root, err := ioutil.TempDir("", "configcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	configCache, err := NewContainersConfigCache(root, storage.NewMemoryMetaStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache := NewUpgradeContainersCache(0)
+	cache.SetCluster(&Cluster{configCache: configCache})
+	if err := cache.Reserve("meta1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cache.Reserve("meta1"); err != ErrClusterContainersUpgrading {
+		t.Fatalf("reserve a reserved meta error %v, want ErrClusterContainersUpgrading", err)
+	}
+
+	if !cache.Contains("meta1") {
+		t.Fatal("reserved meta not upgrading")
+	}
+
+	if err := cache.Upgrade(make(chan bool), "meta1", "2", Containers{}, nil); err != ErrClusterMetaDataNotFound {
+		t.Fatalf("upgrade not exists meta error %v, want ErrClusterMetaDataNotFound", err)
+	}
+
+	if cache.Contains("meta1") {
+		t.Fatal("reserved meta not released")
+	}
+}
+
+func TestUpgraderDrainStop(t *testing.T) {
+
+	root, err := ioutil.TempDir("", "upgrader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	configCache, err := NewContainersConfigCache(root, storage.NewMemoryMetaStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metaData := newImportTestMeta("meta1", "app:1")
+	metaData.ImageTag = "1"
+	if _, _, err := configCache.ImportMetaData([]*MetaData{metaData}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	auditLog, err := NewAuditLog(root, 1024*1024, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer auditLog.Close()
+
+	drainCh := make(chan struct{})
+	close(drainCh)
+	cache := NewUpgradeContainersCache(0)
+	cache.SetCluster(&Cluster{configCache: configCache, auditLog: auditLog, drainCh: drainCh})
+	containers := Containers{}
+	for _, id := range []string{"container1", "container2"} {
+		config := models.Container{ID: id}
+		containers = append(containers, &Container{
+			BaseConfig: &ContainerBaseConfig{Container: config},
+			Config:     &ContainerConfig{Container: config},
+			Engine:     &Engine{},
+		})
+	}
+
+	upgradeCh := make(chan bool)
+	if err := cache.Upgrade(upgradeCh, "meta1", "2", containers, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if ret := <-upgradeCh; ret {
+		t.Fatal("upgrade not stopped by drain")
+	}
+
+	if metaData := configCache.GetMetaData("meta1"); metaData.Config.Image != "app:1" {
+		t.Fatalf("meta image %s after drain stop, want app:1", metaData.Config.Image)
+	}
+
+	records, err := auditLog.Query(&AuditFilter{MetaID: "meta1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 1 || records[0].Result != auditResultInterrupted {
+		t.Fatalf("upgrade progress not written to audit, %+v", records)
+	}
+
+	remaining, _ := records[0].Parameters.(map[string]interface{})["Remaining"].([]interface{})
+	if len(remaining) != 2 {
+		t.Fatalf("upgrade progress remaining %v, want 2 containers", records[0].Parameters)
+	}
+}
@@ -128,3 +128,13 @@ func removeDuplicatesGroups(groups []*Group) []*Group {
 	}
 	return out
 }
+
+// shortContainerID is exported
+// returns 12 characters short id, a shorter id is returned unchanged.
+func shortContainerID(containerid string) string {
+
+	if len(containerid) > 12 {
+		return containerid[:12]
+	}
+	return containerid
+}
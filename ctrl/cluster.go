@@ -2,20 +2,32 @@ package ctrl
 
 import "github.com/humpback/discovery"
 import "github.com/humpback/gounits/logger"
+import "github.com/humpback/gounits/system"
 import "humpback-center/api/request"
 import "humpback-center/cluster"
+import "humpback-center/cluster/election"
+import "humpback-center/cluster/storage"
 import "humpback-center/cluster/types"
 import "humpback-center/etc"
 import "humpback-center/notify"
 import "common/models"
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
+	"io"
+	"os"
+	"strconv"
 	"strings"
 	"time"
 )
 
+const (
+	// migrate cache wait election lock timeout, longer than election ttl of a crashed leader.
+	migrateCacheElectionTimeout = time.Minute
+)
+
 func createCluster(configuration *etc.Configuration) (*cluster.Cluster, error) {
 
 	clusterOpts := configuration.Cluster
@@ -34,14 +46,124 @@ func createCluster(configuration *etc.Configuration) (*cluster.Cluster, error) {
 		return nil, err
 	}
 
-	notifySender := notify.NewNotifySender(configuration.GetNotificationsEndPoints())
-	cluster, err := cluster.NewCluster(clusterOpts.DriverOpts, notifySender, discovery)
+	metaStore, err := createMetaStore(configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	electionBackend, err := createElectionBackend(configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	notifySender, err := notify.NewNotifySender(configuration.GetNotifications())
+	if err != nil {
+		return nil, err
+	}
+
+	cluster, err := cluster.NewCluster(clusterOpts.DriverOpts, metaStore, electionBackend, notifySender, discovery)
 	if err != nil {
 		return nil, err
 	}
 	return cluster, nil
 }
 
+// createMetaStore, cluster opts metastore is file or kv, default file.
+// kv metastore use discovery uris, kv.path default is discovery cluster + "/metas".
+func createMetaStore(configuration *etc.Configuration) (storage.MetaStore, error) {
+
+	clusterOpts := configuration.Cluster
+	driverOpts := system.DriverOpts(clusterOpts.DriverOpts)
+	backend, _ := driverOpts.String("metastore", "")
+	cacheRoot, _ := driverOpts.String("cacheroot", "")
+	kvPath, ret := driverOpts.String("kv.path", "")
+	if !ret || strings.TrimSpace(kvPath) == "" {
+		kvPath = strings.TrimSuffix(strings.TrimSpace(clusterOpts.Discovery.Cluster), "/") + "/metas"
+	}
+
+	configopts := map[string]string{"kv.path": kvPath}
+	metaStore, err := storage.NewMetaStore(backend, cacheRoot, clusterOpts.Discovery.URIs, configopts)
+	if err != nil {
+		return nil, fmt.Errorf("cluster metastore init error:%s", err.Error())
+	}
+	logger.INFO("[#ctrl#] cluster metastore %T.", metaStore)
+	return metaStore, nil
+}
+
+// createElectionBackend, cluster opts election is true, create a discovery kv election lock.
+// lock key is discovery cluster + "/leader", lock ttl is electionttl, default 15s.
+func createElectionBackend(configuration *etc.Configuration) (election.Backend, error) {
+
+	clusterOpts := configuration.Cluster
+	driverOpts := system.DriverOpts(clusterOpts.DriverOpts)
+	val, ret := driverOpts.String("election", "")
+	if !ret {
+		return nil, nil
+	}
+
+	enabled, err := strconv.ParseBool(strings.TrimSpace(val))
+	if err != nil {
+		return nil, fmt.Errorf("cluster election invalid, %s", err.Error())
+	}
+
+	if !enabled {
+		return nil, nil
+	}
+
+	ttl := 15 * time.Second
+	if val, ret := driverOpts.String("electionttl", ""); ret {
+		dur, err := time.ParseDuration(val)
+		if err != nil || dur < time.Second {
+			return nil, fmt.Errorf("cluster electionttl invalid, should be at least 1s.")
+		}
+		ttl = dur
+	}
+
+	kv, err := storage.NewKVStore(clusterOpts.Discovery.URIs)
+	if err != nil {
+		return nil, fmt.Errorf("cluster election init error:%s", err.Error())
+	}
+
+	lockKey := strings.TrimSuffix(strings.TrimSpace(clusterOpts.Discovery.Cluster), "/") + "/leader"
+	logger.INFO("[#ctrl#] cluster election lock %s ttl %s.", lockKey, ttl)
+	return election.NewKVBackend(kv, lockKey, ttl), nil
+}
+
+// MigrateMetaCache is exported
+// rewrite all metas of the configured metastore to current schema version.
+// election is enabled, the election lock is held while rewriting, fail if a running center is the leader.
+func MigrateMetaCache(configuration *etc.Configuration) ([]*cluster.MetaSchemaMigration, error) {
+
+	metaStore, err := createMetaStore(configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	electionBackend, err := createElectionBackend(configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	if electionBackend != nil {
+		hostname, _ := os.Hostname()
+		candidate := "migrate-cache@" + hostname
+		stopCh := make(chan struct{})
+		timer := time.AfterFunc(migrateCacheElectionTimeout, func() { close(stopCh) })
+		lostCh, err := electionBackend.Campaign(candidate, stopCh)
+		timer.Stop()
+		if err != nil {
+			return nil, fmt.Errorf("migrate cache election error:%s", err.Error())
+		}
+
+		if lostCh == nil {
+			leader, _ := electionBackend.Leader()
+			return nil, fmt.Errorf("migrate cache election lock is held by %s, stop all centers before migrate.", leader)
+		}
+		defer electionBackend.Resign(candidate)
+	}
+	return cluster.MigrateMetaStore(metaStore)
+}
+
 func (c *Controller) initCluster() {
 
 	if groups := c.getClusterGroupStoreData(""); groups != nil {
@@ -124,18 +246,145 @@ func (c *Controller) GetClusterGroupContainersMetaBase(metaid string) *cluster.M
 	return c.Cluster.GetMetaBase(metaid)
 }
 
+func (c *Controller) GetClusterContainerMetaBase(containerid string) *cluster.MetaBase {
+
+	return c.Cluster.GetContainerMetaBase(containerid)
+}
+
+func (c *Controller) GetClusterEngineGroups(server string) []string {
+
+	groupids := []string{}
+	if engine := c.Cluster.GetEngine(server); engine != nil {
+		for _, group := range c.Cluster.GetEngineGroups(engine) {
+			groupids = append(groupids, group.ID)
+		}
+	}
+	return groupids
+}
+
 func (c *Controller) GetClusterGroupAllEngines(groupid string) []*cluster.Engine {
 
 	return c.Cluster.GetGroupAllEngines(groupid)
 }
 
+func (c *Controller) GetClusterGroupsSummary() []*cluster.GroupSummary {
+
+	return c.Cluster.GetGroupsSummary()
+}
+
+func (c *Controller) GetClusterPoolEngines() []*cluster.Engine {
+
+	return c.Cluster.GetPoolEngines()
+}
+
+func (c *Controller) GetClusterCapacity() *cluster.ClusterCapacity {
+
+	return c.Cluster.GetCapacity()
+}
+
+func (c *Controller) GetClusterMetaStats(metaid string) (*cluster.MetaStats, error) {
+
+	return c.Cluster.GetMetaStats(metaid)
+}
+
+func (c *Controller) GetClusterContainerLogs(ctx context.Context, containerid string, options *cluster.ContainerLogsOptions) (io.ReadCloser, error) {
+
+	return c.Cluster.GetContainerLogs(ctx, containerid, options)
+}
+
+func (c *Controller) GetClusterMetaLogs(ctx context.Context, metaid string, options *cluster.ContainerLogsOptions) (io.ReadCloser, error) {
+
+	return c.Cluster.GetMetaLogs(ctx, metaid, options)
+}
+
 func (c *Controller) GetClusterEngine(server string) *cluster.Engine {
 
 	return c.Cluster.GetEngine(server)
 }
 
-func (c *Controller) SetClusterGroupEvent(groupid string, event string) {
+func (c *Controller) GetClusterRecoveryRuns(count int) []*cluster.RecoveryRun {
+
+	return c.Cluster.GetRecoveryRuns(count)
+}
+
+func (c *Controller) SubscribeClusterEvents(lastID uint64, filter *cluster.EventFilter) (*cluster.EventSubscriber, []*cluster.ClusterEvent) {
+
+	return c.Cluster.SubscribeEvents(lastID, filter)
+}
+
+func (c *Controller) UnsubscribeClusterEvents(subscriber *cluster.EventSubscriber) {
+
+	c.Cluster.UnsubscribeEvents(subscriber)
+}
+
+func (c *Controller) GetClusterMetrics() *cluster.ClusterMetrics {
+
+	return c.Cluster.GetMetrics()
+}
+
+func (c *Controller) GetClusterQuarantinedMetas() ([]*cluster.QuarantinedMeta, error) {
+
+	return c.Cluster.GetQuarantinedMetas()
+}
+
+func (c *Controller) ExportClusterMetaData(groupid string) *cluster.MetaDataArchive {
+
+	return c.Cluster.ExportMetaData(groupid)
+}
+
+func (c *Controller) ImportClusterMetaData(archive *cluster.MetaDataArchive, mode string) (*cluster.MetaDataImportResult, error) {
+
+	return c.Cluster.ImportMetaData(archive, mode)
+}
+
+func (c *Controller) WriteClusterAudit(record *cluster.AuditRecord) {
+
+	c.Cluster.WriteAudit(record)
+}
+
+func (c *Controller) QueryClusterAudit(filter *cluster.AuditFilter) ([]*cluster.AuditRecord, error) {
+
+	return c.Cluster.QueryAudit(filter)
+}
+
+func (c *Controller) BeginClusterIdempotent(key string, fingerprint string) (*cluster.IdempotencyRecord, error) {
+
+	return c.Cluster.BeginIdempotent(key, fingerprint)
+}
+
+func (c *Controller) CompleteClusterIdempotent(key string, statusCode int, header map[string][]string, body []byte) {
+
+	c.Cluster.CompleteIdempotent(key, statusCode, header, body)
+}
+
+func (c *Controller) AbortClusterIdempotent(key string) {
+
+	c.Cluster.AbortIdempotent(key)
+}
+
+func (c *Controller) IsClusterDraining() bool {
+
+	return c.Cluster.IsDraining()
+}
+
+func (c *Controller) IsClusterLeader() bool {
+
+	return c.Cluster.IsLeader()
+}
 
+func (c *Controller) GetClusterLeader() (string, error) {
+
+	return c.Cluster.GetLeader()
+}
+
+func (c *Controller) RecoveryClusterMetas() (*cluster.RecoveryRun, error) {
+
+	return c.Cluster.RecoveryMetas()
+}
+
+func (c *Controller) SetClusterGroupEvent(groupid string, event string, job *cluster.Job) error {
+
+	var err error
 	logger.INFO("[#ctrl#] set cluster groupevent %s.", event)
 	switch event {
 	case request.GROUP_CREATE_EVENT, request.GROUP_CHANGE_EVENT:
@@ -149,14 +398,14 @@ func (c *Controller) SetClusterGroupEvent(groupid string, event string) {
 								c.Cluster.SetGroup(group)
 							} else { // group location changed
 								if c.Cluster.GetGroup(groupid) != nil {
-									c.Cluster.RemoveGroup(groupid)
+									err = c.removeClusterGroup(groupid, job)
 								}
 							}
 						}
 					}
 				} else { // group iscluster change to false
 					if c.Cluster.GetGroup(groupid) != nil {
-						c.Cluster.RemoveGroup(groupid)
+						err = c.removeClusterGroup(groupid, job)
 					}
 				}
 			}
@@ -164,15 +413,27 @@ func (c *Controller) SetClusterGroupEvent(groupid string, event string) {
 	case request.GROUP_REMOVE_EVENT:
 		{ // group removed
 			if c.Cluster.GetGroup(groupid) != nil {
-				c.Cluster.RemoveGroup(groupid)
+				err = c.removeClusterGroup(groupid, job)
 			}
 		}
 	}
+	return err
 }
 
-func (c *Controller) CreateClusterContainers(groupid string, instances int, webhooks types.WebHooks, config models.Container) (string, *types.CreatedContainers, error) {
+// removeClusterGroup, leader removes group containers and metas, follower only drops the group of cluster.
+func (c *Controller) removeClusterGroup(groupid string, job *cluster.Job) error {
 
-	return c.Cluster.CreateContainers(groupid, instances, webhooks, config)
+	if !c.Cluster.IsLeader() {
+		c.Cluster.DropGroup(groupid)
+		return nil
+	}
+	_, err := c.Cluster.RemoveGroup(groupid, job)
+	return err
+}
+
+func (c *Controller) CreateClusterContainers(groupid string, instances int, webhooks types.WebHooks, config models.Container, job *cluster.Job) (string, *types.CreatedContainers, error) {
+
+	return c.Cluster.CreateContainers(groupid, instances, webhooks, config, job)
 }
 
 func (c *Controller) UpdateClusterContainers(metaid string, instances int, webhooks types.WebHooks) (*types.CreatedContainers, error) {
@@ -190,14 +451,39 @@ func (c *Controller) OperateContainer(containerid string, action string) (string
 	return c.Cluster.OperateContainer(containerid, action)
 }
 
-func (c *Controller) UpgradeContainers(metaid string, imagetag string) (*types.UpgradeContainers, error) {
+func (c *Controller) UpgradeContainers(metaid string, imagetag string, prePull bool, job *cluster.Job) (*types.UpgradeContainers, []*cluster.ImagePullResult, error) {
+
+	return c.Cluster.UpgradeContainers(metaid, imagetag, prePull, job)
+}
+
+func (c *Controller) ApplyContainers(groupid string, name string, spec *cluster.ApplySpec, dryRun bool, job *cluster.Job) (*cluster.ApplyPlan, error) {
+
+	return c.Cluster.ApplyMeta(groupid, name, spec, dryRun, job)
+}
+
+func (c *Controller) SetContainersMaintenance(metaid string, paused bool, expires time.Duration) (*cluster.MetaBase, error) {
+
+	return c.Cluster.SetContainersMaintenance(metaid, paused, expires)
+}
+
+func (c *Controller) RemoveContainers(metaid string, job *cluster.Job) (*types.RemovedContainers, error) {
+
+	return c.Cluster.RemoveContainers(metaid, "", job)
+}
+
+func (c *Controller) SubmitClusterJob(job *cluster.Job, fn cluster.JobFunc) *cluster.JobInfo {
+
+	return c.Cluster.SubmitJob(job, fn)
+}
+
+func (c *Controller) GetClusterJob(jobid string) (*cluster.JobInfo, error) {
 
-	return c.Cluster.UpgradeContainers(metaid, imagetag)
+	return c.Cluster.GetJob(jobid)
 }
 
-func (c *Controller) RemoveContainers(metaid string) (*types.RemovedContainers, error) {
+func (c *Controller) CancelClusterJob(jobid string) (*cluster.JobInfo, error) {
 
-	return c.Cluster.RemoveContainers(metaid, "")
+	return c.Cluster.CancelJob(jobid)
 }
 
 func (c *Controller) RemoveContainer(containerid string) (string, *types.RemovedContainers, error) {
@@ -36,6 +36,9 @@ func NewController(configuration *etc.Configuration) (*Controller, error) {
 		return nil, err
 	}
 
+	if repositorycache.CheckImageEnabled() {
+		cluster.SetImageChecker(repositorycache)
+	}
 	return &Controller{
 		httpClient:      http.NewWithTimeout(requestAPITimeout),
 		Configuration:   configuration,
@@ -53,6 +56,14 @@ func (c *Controller) Initialize() error {
 	return c.startCluster()
 }
 
+// Drain is exported
+// stop accepting mutating operations, wait cluster in-flight operations.
+func (c *Controller) Drain(timeout time.Duration) error {
+
+	logger.INFO("[#ctrl#] controller draining.....")
+	return c.Cluster.Drain(timeout)
+}
+
 // UnInitialize is exported
 // uninit cluster
 func (c *Controller) UnInitialize() {
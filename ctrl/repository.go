@@ -1,12 +1,16 @@
 package ctrl
 
+import "humpback-center/cluster"
 import "humpback-center/etc"
 import "humpback-center/repository"
 
+import (
+	"fmt"
+)
+
 func createRepositoryCache(configuration *etc.Configuration) (*repository.RepositoryCache, error) {
 
-	repositorycache := repository.NewRepositoryCache()
-	return repositorycache, nil
+	return repository.NewRepositoryCache(configuration.Repository)
 }
 
 func (c *Controller) SetRepositoryCache(repositorycache *repository.RepositoryCache) {
@@ -15,3 +19,50 @@ func (c *Controller) SetRepositoryCache(repositorycache *repository.RepositoryCa
 		c.RepositoryCache = repositorycache
 	}
 }
+
+func (c *Controller) GetRepositoryCatalog(n int, last string) (string, []string, string, error) {
+
+	registry, err := c.RepositoryCache.Registry()
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	repositories, next, err := registry.Catalog(n, last)
+	return registry.Name(), repositories, next, err
+}
+
+func (c *Controller) GetRepositoryTags(name string, n int, last string) ([]string, string, error) {
+
+	registry, err := c.RepositoryCache.Registry()
+	if err != nil {
+		return nil, "", err
+	}
+	return registry.Tags(name, n, last)
+}
+
+func (c *Controller) RemoveRepositoryImage(name string, reference string) (string, error) {
+
+	registry, err := c.RepositoryCache.Registry()
+	if err != nil {
+		return "", err
+	}
+	return registry.DeleteManifest(name, reference)
+}
+
+func (c *Controller) MigrateRepositoryImages(source repository.Config, destination *repository.Config, images []repository.MigrateImage, job *cluster.Job) (*repository.MigrateResult, error) {
+
+	sourceRegistry, err := repository.NewRegistry(source)
+	if err != nil {
+		return nil, fmt.Errorf("source registry %s", err.Error())
+	}
+
+	var destinationRegistry *repository.Registry
+	if destination != nil {
+		if destinationRegistry, err = repository.NewRegistry(*destination); err != nil {
+			return nil, fmt.Errorf("destination registry %s", err.Error())
+		}
+	} else if destinationRegistry, err = c.RepositoryCache.Registry(); err != nil {
+		return nil, err
+	}
+	return c.RepositoryCache.Migrate(sourceRegistry, destinationRegistry, images, job.SetProgress, job.IsCanceled)
+}
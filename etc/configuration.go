@@ -2,11 +2,14 @@ package etc
 
 import "github.com/humpback/gounits/logger"
 import "gopkg.in/yaml.v2"
+import "humpback-center/api/middleware"
 import "humpback-center/notify"
+import "humpback-center/repository"
 
 import (
 	"io/ioutil"
 	"os"
+	"time"
 )
 
 // Configuration is exported
@@ -30,12 +33,26 @@ type Configuration struct {
 
 	//api options
 	API struct {
-		Hosts      []string `yaml:"hosts"`
-		EnableCors bool     `yaml:"enablecors"`
+		Hosts           []string `yaml:"hosts"`
+		EnableCors      bool     `yaml:"enablecors"`
+		ShutdownTimeout string   `yaml:"shutdowntimeout"`
+		//api authentication, bearer tokens and hmac secrets
+		Auth middleware.AuthConfig `yaml:"auth"`
+		//api tls, disabled if certfile is empty
+		TLS struct {
+			CertFile     string `yaml:"certfile"`
+			KeyFile      string `yaml:"keyfile"`
+			ClientCAFile string `yaml:"clientcafile"`
+			VerifyClient bool   `yaml:"verifyclient"`
+			MinVersion   string `yaml:"minversion"`
+		} `yaml:"tls"`
 	} `yaml:"api"`
 
 	Notifications notify.Notifications `yaml:"notifications,omitempty"`
 
+	//docker registry v2 repository options, disabled if host is empty
+	Repository repository.Config `yaml:"repository,omitempty"`
+
 	//log options
 	Logger struct {
 		LogFile  string `yaml:"logfile"`
@@ -69,10 +86,20 @@ func NewConfiguration(file string) (*Configuration, error) {
 	return conf, nil
 }
 
-// GetNotificationsEndPoints is exported
-func (conf *Configuration) GetNotificationsEndPoints() []notify.EndPoint {
+// GetNotifications is exported
+func (conf *Configuration) GetNotifications() notify.Notifications {
+
+	return conf.Notifications
+}
 
-	return conf.Notifications.EndPoints
+// GetShutdownTimeout is exported
+// graceful shutdown timeout, default 30s.
+func (conf *Configuration) GetShutdownTimeout() time.Duration {
+
+	if timeout, err := time.ParseDuration(conf.API.ShutdownTimeout); err == nil && timeout > 0 {
+		return timeout
+	}
+	return 30 * time.Second
 }
 
 // GetLogger is exported
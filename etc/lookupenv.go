@@ -37,6 +37,14 @@ func (conf *Configuration) ParseEnv() error {
 		return err
 	}
 
+	if err := parseRepositoryEnv(conf); err != nil {
+		return err
+	}
+
+	if err := parseNotificationsEnv(conf); err != nil {
+		return err
+	}
+
 	if err := parseLogEnv(conf); err != nil {
 		return err
 	}
@@ -60,6 +68,19 @@ func parseClusterEnv(conf *Configuration) error {
 		driverOpts["cacheroot"] = cacheRoot
 	}
 
+	metaStore := os.Getenv("CENTER_CLUSTER_METASTORE")
+	if metaStore != "" {
+		if metaStore != "file" && metaStore != "kv" {
+			return fmt.Errorf("%s, CENTER_CLUSTER_METASTORE should be file or kv", ERRConfigurationParseEnv.Error())
+		}
+		driverOpts["metastore"] = metaStore
+	}
+
+	kvPath := os.Getenv("CENTER_CLUSTER_KVPATH")
+	if kvPath != "" {
+		driverOpts["kv.path"] = kvPath
+	}
+
 	overCommit := os.Getenv("CENTER_CLUSTER_OVERCOMMIT")
 	if overCommit != "" {
 		if _, err := strconv.ParseFloat(overCommit, 2); err != nil {
@@ -76,6 +97,14 @@ func parseClusterEnv(conf *Configuration) error {
 		driverOpts["recoveryinterval"] = recoveryInterval
 	}
 
+	recoveryConcurrency := os.Getenv("CENTER_CLUSTER_RECOVERYCONCURRENCY")
+	if recoveryConcurrency != "" {
+		if _, err := strconv.Atoi(recoveryConcurrency); err != nil {
+			return fmt.Errorf("%s, CENTER_CLUSTER_RECOVERYCONCURRENCY %s", ERRConfigurationParseEnv.Error(), err.Error())
+		}
+		driverOpts["recoveryconcurrency"] = recoveryConcurrency
+	}
+
 	createRetry := os.Getenv("CENTER_CLUSTER_CREATERETRY")
 	if createRetry != "" {
 		if _, err := strconv.Atoi(createRetry); err != nil {
@@ -91,6 +120,76 @@ func parseClusterEnv(conf *Configuration) error {
 		}
 		driverOpts["migratedelay"] = migrateDelay
 	}
+
+	election := os.Getenv("CENTER_CLUSTER_ELECTION")
+	if election != "" {
+		enabled, err := strconv.ParseBool(election)
+		if err != nil {
+			return fmt.Errorf("%s, CENTER_CLUSTER_ELECTION %s", ERRConfigurationParseEnv.Error(), err.Error())
+		}
+		driverOpts["election"] = strconv.FormatBool(enabled)
+	}
+
+	electionTTL := os.Getenv("CENTER_CLUSTER_ELECTIONTTL")
+	if electionTTL != "" {
+		if _, err := time.ParseDuration(electionTTL); err != nil {
+			return fmt.Errorf("%s, CENTER_CLUSTER_ELECTIONTTL %s", ERRConfigurationParseEnv.Error(), err.Error())
+		}
+		driverOpts["electionttl"] = electionTTL
+	}
+
+	electionRefresh := os.Getenv("CENTER_CLUSTER_ELECTIONREFRESH")
+	if electionRefresh != "" {
+		if _, err := time.ParseDuration(electionRefresh); err != nil {
+			return fmt.Errorf("%s, CENTER_CLUSTER_ELECTIONREFRESH %s", ERRConfigurationParseEnv.Error(), err.Error())
+		}
+		driverOpts["electionrefresh"] = electionRefresh
+	}
+
+	electionForward := os.Getenv("CENTER_CLUSTER_ELECTIONFORWARD")
+	if electionForward != "" {
+		if electionForward != "redirect" && electionForward != "proxy" {
+			return fmt.Errorf("%s, CENTER_CLUSTER_ELECTIONFORWARD should be redirect or proxy", ERRConfigurationParseEnv.Error())
+		}
+		driverOpts["electionforward"] = electionForward
+	}
+
+	eventsBuffer := os.Getenv("CENTER_CLUSTER_EVENTSBUFFER")
+	if eventsBuffer != "" {
+		if _, err := strconv.Atoi(eventsBuffer); err != nil {
+			return fmt.Errorf("%s, CENTER_CLUSTER_EVENTSBUFFER %s", ERRConfigurationParseEnv.Error(), err.Error())
+		}
+		driverOpts["eventsbuffer"] = eventsBuffer
+	}
+
+	idempotencyTTL := os.Getenv("CENTER_CLUSTER_IDEMPOTENCYTTL")
+	if idempotencyTTL != "" {
+		if _, err := time.ParseDuration(idempotencyTTL); err != nil {
+			return fmt.Errorf("%s, CENTER_CLUSTER_IDEMPOTENCYTTL %s", ERRConfigurationParseEnv.Error(), err.Error())
+		}
+		driverOpts["idempotencyttl"] = idempotencyTTL
+	}
+
+	auditMaxSize := os.Getenv("CENTER_CLUSTER_AUDITMAXSIZE")
+	if auditMaxSize != "" {
+		if _, err := strconv.Atoi(auditMaxSize); err != nil {
+			return fmt.Errorf("%s, CENTER_CLUSTER_AUDITMAXSIZE %s", ERRConfigurationParseEnv.Error(), err.Error())
+		}
+		driverOpts["auditmaxsize"] = auditMaxSize
+	}
+
+	auditMaxFiles := os.Getenv("CENTER_CLUSTER_AUDITMAXFILES")
+	if auditMaxFiles != "" {
+		if _, err := strconv.Atoi(auditMaxFiles); err != nil {
+			return fmt.Errorf("%s, CENTER_CLUSTER_AUDITMAXFILES %s", ERRConfigurationParseEnv.Error(), err.Error())
+		}
+		driverOpts["auditmaxfiles"] = auditMaxFiles
+	}
+
+	advertise := os.Getenv("CENTER_CLUSTER_ADVERTISE")
+	if advertise != "" {
+		driverOpts["advertise"] = advertise
+	}
 	conf.Cluster.DriverOpts = convert.ConvertMapToKVStringSlice(driverOpts)
 
 	clusterURIs := os.Getenv("DOCKER_CLUSTER_URIS")
@@ -151,6 +250,124 @@ func parseAPIEnv(conf *Configuration) error {
 		}
 		conf.API.EnableCors = ret
 	}
+
+	shutdownTimeout := os.Getenv("CENTER_API_SHUTDOWNTIMEOUT")
+	if shutdownTimeout != "" {
+		if _, err := time.ParseDuration(shutdownTimeout); err != nil {
+			return fmt.Errorf("%s, CENTER_API_SHUTDOWNTIMEOUT %s", ERRConfigurationParseEnv.Error(), err.Error())
+		}
+		conf.API.ShutdownTimeout = shutdownTimeout
+	}
+
+	authForwardToken := os.Getenv("CENTER_API_AUTHFORWARDTOKEN")
+	if authForwardToken != "" {
+		conf.API.Auth.ForwardToken = authForwardToken
+	}
+
+	tlsCertFile := os.Getenv("CENTER_API_TLSCERTFILE")
+	if tlsCertFile != "" {
+		conf.API.TLS.CertFile = tlsCertFile
+	}
+
+	tlsKeyFile := os.Getenv("CENTER_API_TLSKEYFILE")
+	if tlsKeyFile != "" {
+		conf.API.TLS.KeyFile = tlsKeyFile
+	}
+
+	tlsClientCAFile := os.Getenv("CENTER_API_TLSCLIENTCAFILE")
+	if tlsClientCAFile != "" {
+		conf.API.TLS.ClientCAFile = tlsClientCAFile
+	}
+
+	tlsVerifyClient := os.Getenv("CENTER_API_TLSVERIFYCLIENT")
+	if tlsVerifyClient != "" {
+		ret, err := strconv.ParseBool(tlsVerifyClient)
+		if err != nil {
+			return fmt.Errorf("%s, CENTER_API_TLSVERIFYCLIENT %s", ERRConfigurationParseEnv.Error(), err.Error())
+		}
+		conf.API.TLS.VerifyClient = ret
+	}
+
+	tlsMinVersion := os.Getenv("CENTER_API_TLSMINVERSION")
+	if tlsMinVersion != "" {
+		conf.API.TLS.MinVersion = tlsMinVersion
+	}
+	return nil
+}
+
+func parseRepositoryEnv(conf *Configuration) error {
+
+	repositoryHost := os.Getenv("CENTER_REPOSITORY_HOST")
+	if repositoryHost != "" {
+		conf.Repository.Host = repositoryHost
+	}
+
+	repositoryUser := os.Getenv("CENTER_REPOSITORY_USER")
+	if repositoryUser != "" {
+		conf.Repository.User = repositoryUser
+	}
+
+	repositoryPassword := os.Getenv("CENTER_REPOSITORY_PASSWORD")
+	if repositoryPassword != "" {
+		conf.Repository.Password = repositoryPassword
+	}
+
+	repositoryToken := os.Getenv("CENTER_REPOSITORY_TOKEN")
+	if repositoryToken != "" {
+		conf.Repository.Token = repositoryToken
+	}
+
+	repositoryInsecure := os.Getenv("CENTER_REPOSITORY_INSECURE")
+	if repositoryInsecure != "" {
+		insecure, err := strconv.ParseBool(repositoryInsecure)
+		if err != nil {
+			return fmt.Errorf("%s, CENTER_REPOSITORY_INSECURE %s", ERRConfigurationParseEnv.Error(), err.Error())
+		}
+		conf.Repository.Insecure = insecure
+	}
+
+	repositoryTimeout := os.Getenv("CENTER_REPOSITORY_TIMEOUT")
+	if repositoryTimeout != "" {
+		if _, err := time.ParseDuration(repositoryTimeout); err != nil {
+			return fmt.Errorf("%s, CENTER_REPOSITORY_TIMEOUT %s", ERRConfigurationParseEnv.Error(), err.Error())
+		}
+		conf.Repository.Timeout = repositoryTimeout
+	}
+
+	repositoryMaxRoutine := os.Getenv("CENTER_REPOSITORY_MAXROUTINE")
+	if repositoryMaxRoutine != "" {
+		maxRoutine, err := strconv.Atoi(repositoryMaxRoutine)
+		if err != nil {
+			return fmt.Errorf("%s, CENTER_REPOSITORY_MAXROUTINE %s", ERRConfigurationParseEnv.Error(), err.Error())
+		}
+		conf.Repository.MaxRoutine = maxRoutine
+	}
+
+	repositoryRecovery := os.Getenv("CENTER_REPOSITORY_RECOVERY")
+	if repositoryRecovery != "" {
+		recovery, err := strconv.Atoi(repositoryRecovery)
+		if err != nil {
+			return fmt.Errorf("%s, CENTER_REPOSITORY_RECOVERY %s", ERRConfigurationParseEnv.Error(), err.Error())
+		}
+		conf.Repository.Recovery = recovery
+	}
+
+	repositoryCheckImage := os.Getenv("CENTER_REPOSITORY_CHECKIMAGE")
+	if repositoryCheckImage != "" {
+		conf.Repository.CheckImage = repositoryCheckImage
+	}
+	return nil
+}
+
+func parseNotificationsEnv(conf *Configuration) error {
+
+	notificationsTemplates := os.Getenv("CENTER_NOTIFICATIONS_TEMPLATES")
+	if notificationsTemplates != "" {
+		if _, err := filepath.Abs(notificationsTemplates); err != nil {
+			return fmt.Errorf("%s, CENTER_NOTIFICATIONS_TEMPLATES %s", ERRConfigurationParseEnv.Error(), err.Error())
+		}
+		conf.Notifications.Templates = notificationsTemplates
+	}
 	return nil
 }
 
@@ -16,6 +16,14 @@ func main() {
 		os.Exit(system.PorcessExitCode(err))
 	}
 
+	if service.MigrateCache {
+		if err := service.MigrateMetaCache(); err != nil {
+			log.Printf("service migrate cache error:%s\n", err.Error())
+			os.Exit(system.PorcessExitCode(err))
+		}
+		os.Exit(0)
+	}
+
 	defer func() {
 		service.Stop()
 		os.Exit(0)
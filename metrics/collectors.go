@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"io"
+	"strconv"
+	"time"
+)
+
+// delivery results
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+var (
+	registry = NewRegistry()
+
+	agentRequestDuration = NewHistogramVec("humpback_center_agent_request_duration_seconds",
+		"Humpback agent http request duration by operation.", nil, "operation")
+	agentRequestErrors = NewCounterVec("humpback_center_agent_request_errors_total",
+		"Humpback agent http request errors by operation.", "operation")
+	webhookDeliveries = NewCounterVec("humpback_center_webhook_deliveries_total",
+		"Meta webhook deliveries by event and result.", "event", "result")
+	notificationDeliveries = NewCounterVec("humpback_center_notification_deliveries_total",
+		"Notification deliveries by endpoint kind and result.", "endpoint", "result")
+	apiRequestDuration = NewHistogramVec("humpback_center_api_request_duration_seconds",
+		"Center API request duration by method, route and status code.", nil, "method", "route", "code")
+)
+
+func init() {
+
+	registry.Register(agentRequestDuration)
+	registry.Register(agentRequestErrors)
+	registry.Register(webhookDeliveries)
+	registry.Register(notificationDeliveries)
+	registry.Register(apiRequestDuration)
+}
+
+// Write is exported
+// write default registry families and snapshot gauges in prometheus text format.
+func Write(w io.Writer, gauges ...*GaugeFamily) {
+
+	for _, gauge := range gauges {
+		gauge.Write(w)
+	}
+	registry.Write(w)
+}
+
+// ObserveAgentRequest is exported
+// operation is agent call, eg: create, remove, operate, upgrade, containers, container, dockerinfo.
+func ObserveAgentRequest(operation string, start time.Time, err error) {
+
+	agentRequestDuration.Observe(time.Since(start).Seconds(), operation)
+	if err != nil {
+		agentRequestErrors.Inc(operation)
+	}
+}
+
+// ObserveWebHook is exported
+func ObserveWebHook(event string, err error) {
+
+	webhookDeliveries.Inc(event, result(err))
+}
+
+// ObserveNotification is exported
+// endpoint is notify endpoint kind, api or smtp.
+func ObserveNotification(endpoint string, err error) {
+
+	notificationDeliveries.Inc(endpoint, result(err))
+}
+
+// ObserveAPIRequest is exported
+// route is router path template, not request uri.
+func ObserveAPIRequest(method string, route string, code int, start time.Time) {
+
+	apiRequestDuration.Observe(time.Since(start).Seconds(), method, route, strconv.Itoa(code))
+}
+
+func result(err error) string {
+
+	if err != nil {
+		return ResultFailure
+	}
+	return ResultSuccess
+}
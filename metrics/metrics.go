@@ -0,0 +1,285 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// default histogram buckets, seconds.
+var defaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Family is exported
+// a metric family write itself in prometheus text format.
+type Family interface {
+	Write(w io.Writer)
+}
+
+// Registry is exported
+type Registry struct {
+	sync.RWMutex
+	families []Family
+}
+
+// NewRegistry is exported
+func NewRegistry() *Registry {
+
+	return &Registry{
+		families: []Family{},
+	}
+}
+
+// Register is exported
+func (registry *Registry) Register(family Family) {
+
+	registry.Lock()
+	registry.families = append(registry.families, family)
+	registry.Unlock()
+}
+
+// Write is exported
+// write all registered families in prometheus text format.
+func (registry *Registry) Write(w io.Writer) {
+
+	registry.RLock()
+	defer registry.RUnlock()
+	for _, family := range registry.families {
+		family.Write(w)
+	}
+}
+
+// CounterVec is exported
+// monotonic counters partitioned by label values.
+type CounterVec struct {
+	sync.Mutex
+	name   string
+	help   string
+	labels []string
+	values map[string]float64
+}
+
+// NewCounterVec is exported
+func NewCounterVec(name string, help string, labels ...string) *CounterVec {
+
+	return &CounterVec{
+		name:   name,
+		help:   help,
+		labels: labels,
+		values: make(map[string]float64),
+	}
+}
+
+// Inc is exported
+// label values order same as NewCounterVec labels.
+func (counter *CounterVec) Inc(values ...string) {
+
+	key := labelsKey(values)
+	counter.Lock()
+	counter.values[key]++
+	counter.Unlock()
+}
+
+// Write is exported
+func (counter *CounterVec) Write(w io.Writer) {
+
+	counter.Lock()
+	defer counter.Unlock()
+	writeHeader(w, counter.name, counter.help, "counter")
+	for _, key := range sortedKeys(counter.values) {
+		fmt.Fprintf(w, "%s%s %s\n", counter.name, formatLabels(counter.labels, splitKey(key), "", ""), formatValue(counter.values[key]))
+	}
+}
+
+// histogramValue is a single histogram, buckets counts are not cumulative.
+type histogramValue struct {
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+// HistogramVec is exported
+// histograms partitioned by label values.
+type HistogramVec struct {
+	sync.Mutex
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+	values  map[string]*histogramValue
+}
+
+// NewHistogramVec is exported
+// buckets is nil, use default seconds buckets.
+func NewHistogramVec(name string, help string, buckets []float64, labels ...string) *HistogramVec {
+
+	if buckets == nil {
+		buckets = defaultBuckets
+	}
+	return &HistogramVec{
+		name:    name,
+		help:    help,
+		labels:  labels,
+		buckets: buckets,
+		values:  make(map[string]*histogramValue),
+	}
+}
+
+// Observe is exported
+// label values order same as NewHistogramVec labels.
+func (histogram *HistogramVec) Observe(value float64, values ...string) {
+
+	key := labelsKey(values)
+	histogram.Lock()
+	defer histogram.Unlock()
+	hv, ret := histogram.values[key]
+	if !ret {
+		hv = &histogramValue{buckets: make([]uint64, len(histogram.buckets))}
+		histogram.values[key] = hv
+	}
+
+	for i, bound := range histogram.buckets {
+		if value <= bound {
+			hv.buckets[i]++
+			break
+		}
+	}
+	hv.count++
+	hv.sum += value
+}
+
+// Write is exported
+func (histogram *HistogramVec) Write(w io.Writer) {
+
+	histogram.Lock()
+	defer histogram.Unlock()
+	writeHeader(w, histogram.name, histogram.help, "histogram")
+	keys := []string{}
+	for key := range histogram.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		hv := histogram.values[key]
+		values := splitKey(key)
+		cumulative := uint64(0)
+		for i, bound := range histogram.buckets {
+			cumulative += hv.buckets[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", histogram.name, formatLabels(histogram.labels, values, "le", formatValue(bound)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", histogram.name, formatLabels(histogram.labels, values, "le", "+Inf"), hv.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", histogram.name, formatLabels(histogram.labels, values, "", ""), formatValue(hv.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", histogram.name, formatLabels(histogram.labels, values, "", ""), hv.count)
+	}
+}
+
+// GaugeFamily is exported
+// gauges snapshot, built at scrape time and not registered.
+type GaugeFamily struct {
+	name    string
+	help    string
+	labels  []string
+	samples []gaugeSample
+}
+
+type gaugeSample struct {
+	values []string
+	value  float64
+}
+
+// NewGaugeFamily is exported
+func NewGaugeFamily(name string, help string, labels ...string) *GaugeFamily {
+
+	return &GaugeFamily{
+		name:    name,
+		help:    help,
+		labels:  labels,
+		samples: []gaugeSample{},
+	}
+}
+
+// Set is exported
+// label values order same as NewGaugeFamily labels.
+func (gauge *GaugeFamily) Set(value float64, values ...string) {
+
+	gauge.samples = append(gauge.samples, gaugeSample{values: values, value: value})
+}
+
+// Write is exported
+func (gauge *GaugeFamily) Write(w io.Writer) {
+
+	writeHeader(w, gauge.name, gauge.help, "gauge")
+	for _, sample := range gauge.samples {
+		fmt.Fprintf(w, "%s%s %s\n", gauge.name, formatLabels(gauge.labels, sample.values, "", ""), formatValue(sample.value))
+	}
+}
+
+func writeHeader(w io.Writer, name string, help string, metricType string) {
+
+	fmt.Fprintf(w, "# HELP %s %s\n", name, strings.NewReplacer("\\", `\\`, "\n", `\n`).Replace(help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, metricType)
+}
+
+// labelsKey join label values as a map key, values never contains \xff.
+func labelsKey(values []string) string {
+
+	return strings.Join(values, "\xff")
+}
+
+func splitKey(key string) []string {
+
+	return strings.Split(key, "\xff")
+}
+
+func sortedKeys(values map[string]float64) []string {
+
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatLabels format labels pairs, extra label appended if extraName is not empty.
+func formatLabels(labels []string, values []string, extraName string, extraValue string) string {
+
+	pairs := []string{}
+	for i, label := range labels {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		pairs = append(pairs, label+"="+escapeLabelValue(value))
+	}
+
+	if extraName != "" {
+		pairs = append(pairs, extraName+"="+escapeLabelValue(extraValue))
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func escapeLabelValue(value string) string {
+
+	return `"` + strings.NewReplacer("\\", `\\`, "\n", `\n`, `"`, `\"`).Replace(value) + `"`
+}
+
+func formatValue(value float64) string {
+
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -3,5 +3,6 @@ package notify
 // IEndPoint is exported
 // sender endPoint interface
 type IEndPoint interface {
+	TemplateKind() TemplateKind
 	DoEvent(event *Event, data interface{})
 }
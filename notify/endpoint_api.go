@@ -2,8 +2,12 @@ package notify
 
 import "github.com/humpback/gounits/http"
 import "github.com/humpback/gounits/logger"
+import "humpback-center/metrics"
 
 import (
+	"bytes"
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -23,30 +27,61 @@ func NewAPIEndPoint(endpoint EndPoint) IEndPoint {
 	}
 }
 
+// TemplateKind is exported
+func (endpoint *APIEndPoint) TemplateKind() TemplateKind {
+
+	return TemplateKind(endpoint.Format)
+}
+
 // DoEvent is exported
+// json format posts the rendered json document, html and text formats post a mail request.
 func (endpoint *APIEndPoint) DoEvent(event *Event, data interface{}) {
 
 	if !endpoint.Enabled {
 		return
 	}
 
-	value := map[string]interface{}{
-		"From":        endpoint.Sender,
-		"To":          event.ContactInfo,
-		"Subject":     event.makeSubjectText(),
-		"Body":        data,
-		"ContentType": "HTML",
-		"MailType":    "Smtp",
-		"SmtpSetting": map[string]interface{}{},
+	var (
+		response *http.Response
+		err      error
+	)
+
+	if endpoint.TemplateKind() == TemplateJSON {
+		header := map[string][]string{"Content-Type": []string{"application/json; charset=utf-8"}}
+		for key, values := range endpoint.Headers {
+			if strings.EqualFold(key, "Content-Type") {
+				delete(header, "Content-Type")
+			}
+			header[key] = values
+		}
+		response, err = endpoint.client.Post(endpoint.URL, nil, bytes.NewBufferString(data.(string)), header)
+	} else {
+		contentType := "HTML"
+		if endpoint.TemplateKind() == TemplateText {
+			contentType = "Text"
+		}
+		value := map[string]interface{}{
+			"From":        endpoint.Sender,
+			"To":          event.ContactInfo,
+			"Subject":     event.makeSubjectText(),
+			"Body":        data,
+			"ContentType": contentType,
+			"MailType":    "Smtp",
+			"SmtpSetting": map[string]interface{}{},
+		}
+		response, err = endpoint.client.PostJSON(endpoint.URL, nil, value, endpoint.Headers)
 	}
 
-	response, err := endpoint.client.PostJSON(endpoint.URL, nil, value, endpoint.Headers)
 	if err != nil {
+		metrics.ObserveNotification("api", err)
 		logger.ERROR("[#notify#] api endpoint error: %s", err.Error())
 		return
 	}
 	defer response.Close()
 	if response.StatusCode() != 200 {
+		metrics.ObserveNotification("api", fmt.Errorf("response code %d", response.StatusCode()))
 		logger.ERROR("[#notify#] api endpoint response code: %d", response.StatusCode())
+		return
 	}
+	metrics.ObserveNotification("api", nil)
 }
@@ -2,6 +2,7 @@ package notify
 
 import "github.com/humpback/gounits/logger"
 import "gopkg.in/gomail.v1"
+import "humpback-center/metrics"
 
 // SMTPEndPoint is exported
 type SMTPEndPoint struct {
@@ -20,6 +21,12 @@ func NewSMTPEndpoint(endpoint EndPoint) IEndPoint {
 	}
 }
 
+// TemplateKind is exported
+func (endpoint *SMTPEndPoint) TemplateKind() TemplateKind {
+
+	return TemplateKind(endpoint.Format)
+}
+
 // DoEvent is exported
 func (endpoint *SMTPEndPoint) DoEvent(event *Event, data interface{}) {
 
@@ -31,8 +38,14 @@ func (endpoint *SMTPEndPoint) DoEvent(event *Event, data interface{}) {
 	msg.SetHeader("From", endpoint.Sender)
 	msg.SetHeader("To", event.ContactInfo)
 	msg.SetHeader("Subject", event.makeSubjectText())
-	msg.SetBody("text/html", data.(string))
-	if err := endpoint.mailer.Send(msg); err != nil {
+	contentType := "text/plain"
+	if endpoint.TemplateKind() == TemplateHTML {
+		contentType = "text/html"
+	}
+	msg.SetBody(contentType, data.(string))
+	err := endpoint.mailer.Send(msg)
+	metrics.ObserveNotification("smtp", err)
+	if err != nil {
 		logger.ERROR("[#notify#] smtp endpoint post error: %s", err.Error())
 	}
 }
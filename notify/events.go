@@ -1,10 +1,9 @@
 package notify
 
+import "github.com/humpback/gounits/logger"
 import "github.com/humpback/gounits/rand"
 
 import (
-	"bytes"
-	"html/template"
 	"time"
 )
 
@@ -64,17 +63,23 @@ func NewEvent(eventType EventType, description string, err error, contactInfo st
 	return event
 }
 
-//Dispatch is exported
-func (event *Event) dispatch(templateBody string) {
+//dispatch is exported
+//render event body of every endpoint template kind, a render failure endpoint is skipped.
+func (event *Event) dispatch(templates *Templates) {
 
-	if len(templateBody) > 0 {
-		var buf bytes.Buffer
-		t := template.New("")
-		t.Parse(templateBody)
-		t.Execute(&buf, event.data)
-		for _, endPoint := range event.Endpoints {
-			endPoint.DoEvent(event, buf.String())
+	bodies := map[TemplateKind]string{}
+	for _, endPoint := range event.Endpoints {
+		kind := endPoint.TemplateKind()
+		body, ret := bodies[kind]
+		if !ret {
+			var err error
+			if body, err = templates.Render(event, kind); err != nil {
+				logger.ERROR("[#notify#] event %s render %s template error: %s", event.Name, kind, err.Error())
+				continue
+			}
+			bodies[kind] = body
 		}
+		endPoint.DoEvent(event, body)
 	}
 }
 
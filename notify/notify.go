@@ -1,39 +1,46 @@
 package notify
 
 import (
-	"io/ioutil"
+	"fmt"
 	"strings"
 	"sync"
 	"time"
 )
 
-//notify template string
-var templateBody string
-
 //NotifySender is exported
 type NotifySender struct {
 	sync.RWMutex
 	initWatch bool
+	templates *Templates
 	endPoints []IEndPoint
 	events    map[string]*Event
 }
 
 //NewNotifySender is exported
-func NewNotifySender(endPoints []EndPoint) *NotifySender {
+//templates and endpoints format are validated, invalid return error.
+func NewNotifySender(notifications Notifications) (*NotifySender, error) {
+
+	templates, err := NewTemplates(notifications.Templates)
+	if err != nil {
+		return nil, err
+	}
 
 	sender := &NotifySender{
 		initWatch: true,
+		templates: templates,
 		endPoints: []IEndPoint{},
 		events:    make(map[string]*Event),
 	}
 
-	if buf, err := ioutil.ReadFile("./notify/template.html"); err == nil {
-		templateBody = string(buf)
-	}
-
 	factory := &NotifyEndPointFactory{}
 	sender.Lock()
-	for _, endPoint := range endPoints {
+	defer sender.Unlock()
+	for _, endPoint := range notifications.EndPoints {
+		kind, err := ParseTemplateKind(endPoint.Format)
+		if err != nil {
+			return nil, fmt.Errorf("notify endpoint %s, %s", endPoint.Name, err.Error())
+		}
+		endPoint.Format = string(kind)
 		switch strings.ToUpper(endPoint.Name) {
 		case "API":
 			apiEndPoint := factory.CreateAPIEndPoint(endPoint)
@@ -43,13 +50,12 @@ func NewNotifySender(endPoints []EndPoint) *NotifySender {
 			sender.endPoints = append(sender.endPoints, smtpEndPoint)
 		}
 	}
-	sender.Unlock()
 
 	go func() {
 		time.Sleep(30 * time.Second)
 		sender.initWatch = false
 	}()
-	return sender
+	return sender, nil
 }
 
 //AddGroupEnginesWatchEvent is exported
@@ -88,7 +94,7 @@ func (sender *NotifySender) dispatchEvents() {
 			for _, event := range sender.events {
 				wgroup.Add(1)
 				go func(e *Event) {
-					e.dispatch(templateBody)
+					e.dispatch(sender.templates)
 					wgroup.Done()
 				}(event)
 			}
@@ -0,0 +1,260 @@
+package notify
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateKind is exported
+type TemplateKind string
+
+const (
+	// TemplateHTML is exported
+	// html mail body, default kind of endpoints.
+	TemplateHTML TemplateKind = "html"
+	// TemplateText is exported
+	// plain text body.
+	TemplateText TemplateKind = "text"
+	// TemplateJSON is exported
+	// json document body.
+	TemplateJSON TemplateKind = "json"
+)
+
+// templateKindsExtMap, template file extension of kinds.
+// template file name is <EventName><ext>.
+var templateKindsExtMap = map[TemplateKind]string{
+	TemplateHTML: ".html",
+	TemplateText: ".txt",
+	TemplateJSON: ".json",
+}
+
+// defaultTemplates, embedded default templates of all events and kinds.
+//
+//go:embed templates
+var defaultTemplates embed.FS
+
+// templateExecutor, html/template or text/template template.
+type templateExecutor interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// templateFuncs, helper functions of templates.
+var templateFuncs = map[string]interface{}{
+	"duration":   formatDuration,
+	"since":      formatSince,
+	"shortid":    shortID,
+	"statecolor": stateColor,
+	"json":       jsonValue,
+}
+
+// Templates is exported
+// parsed templates of events, key is event type and template kind.
+type Templates struct {
+	executors map[EventType]map[TemplateKind]templateExecutor
+}
+
+// ParseTemplateKind is exported
+// empty format is html kind.
+func ParseTemplateKind(format string) (TemplateKind, error) {
+
+	kind := TemplateKind(strings.ToLower(strings.TrimSpace(format)))
+	if kind == "" {
+		return TemplateHTML, nil
+	}
+
+	if _, ret := templateKindsExtMap[kind]; !ret {
+		return "", fmt.Errorf("notify template format %s invalid, html, text or json", format)
+	}
+	return kind, nil
+}
+
+// NewTemplates is exported
+// load embedded default templates, templates of dir replace the defaults of same name.
+// every template is parsed and executed with sample event data, any error is returned.
+func NewTemplates(dir string) (*Templates, error) {
+
+	overrides := map[string]string{}
+	if dir = strings.TrimSpace(dir); dir != "" {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("notify templates dir invalid, %s", err.Error())
+		}
+
+		for _, file := range files {
+			if file.IsDir() {
+				continue
+			}
+			overrides[file.Name()] = filepath.Join(dir, file.Name())
+		}
+	}
+
+	templates := &Templates{
+		executors: make(map[EventType]map[TemplateKind]templateExecutor),
+	}
+
+	for eventType, eventName := range eventsTextMap {
+		templates.executors[eventType] = make(map[TemplateKind]templateExecutor)
+		for kind, ext := range templateKindsExtMap {
+			fileName := eventName + ext
+			var (
+				buf []byte
+				err error
+			)
+			if path, ret := overrides[fileName]; ret {
+				buf, err = ioutil.ReadFile(path)
+				delete(overrides, fileName)
+			} else {
+				buf, err = defaultTemplates.ReadFile("templates/" + fileName)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("notify template %s read error, %s", fileName, err.Error())
+			}
+
+			executor, err := parseTemplate(fileName, kind, string(buf))
+			if err != nil {
+				return nil, fmt.Errorf("notify template %s parse error, %s", fileName, err.Error())
+			}
+
+			if err := validateTemplate(executor, kind, eventType); err != nil {
+				return nil, fmt.Errorf("notify template %s invalid, %s", fileName, err.Error())
+			}
+			templates.executors[eventType][kind] = executor
+		}
+	}
+
+	for fileName := range overrides {
+		return nil, fmt.Errorf("notify template %s unknown, name must be <EventName>.html, .txt or .json", fileName)
+	}
+	return templates, nil
+}
+
+// Render is exported
+// render event body of template kind.
+func (templates *Templates) Render(event *Event, kind TemplateKind) (string, error) {
+
+	executor, ret := templates.executors[event.Type][kind]
+	if !ret {
+		return "", fmt.Errorf("notify template %s %s not found", event.Name, kind)
+	}
+
+	var buf bytes.Buffer
+	if err := executor.Execute(&buf, event.data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// parseTemplate, html kind is parsed with html/template, other kinds with text/template.
+func parseTemplate(name string, kind TemplateKind, text string) (templateExecutor, error) {
+
+	if kind == TemplateHTML {
+		return htmltemplate.New(name).Funcs(templateFuncs).Parse(text)
+	}
+	return template.New(name).Funcs(templateFuncs).Parse(text)
+}
+
+// validateTemplate, execute template with sample event data, json kind output must be a valid json.
+func validateTemplate(executor templateExecutor, kind TemplateKind, eventType EventType) error {
+
+	var buf bytes.Buffer
+	if err := executor.Execute(&buf, sampleEvent(eventType).data); err != nil {
+		return err
+	}
+
+	if kind == TemplateJSON && !json.Valid(buf.Bytes()) {
+		return fmt.Errorf("output is not a valid json")
+	}
+	return nil
+}
+
+// sampleEvent, event of type with sample data, used to validate templates.
+func sampleEvent(eventType EventType) *Event {
+
+	event := NewEvent(eventType, "sample description", fmt.Errorf("sample exception"), "", nil)
+	switch eventType {
+	case GroupEnginesWatchEvent:
+		event.data["WatchGroup"] = &WatchGroup{
+			GroupID:   "sample",
+			GroupName: "sample",
+			Location:  "sample",
+			Engines:   []*Engine{{IP: "127.0.0.1", Name: "sample", State: "Healthy"}},
+		}
+	case GroupMetaContainersEvent:
+		event.data["GroupMeta"] = &GroupMeta{
+			MetaID:     "sample",
+			MetaName:   "sample",
+			Location:   "sample",
+			GroupID:    "sample",
+			GroupName:  "sample",
+			Instances:  1,
+			Image:      "sample:latest",
+			Containers: []Container{{ID: "0123456789abcdef", Name: "sample", Server: "127.0.0.1", State: "Running"}},
+		}
+	}
+	return event
+}
+
+// formatDuration, format time.Duration or seconds number, rounded to second, eg: 1h2m3s.
+func formatDuration(value interface{}) string {
+
+	var duration time.Duration
+	switch v := value.(type) {
+	case time.Duration:
+		duration = v
+	case int:
+		duration = time.Duration(v) * time.Second
+	case int64:
+		duration = time.Duration(v) * time.Second
+	case float64:
+		duration = time.Duration(v * float64(time.Second))
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+	return duration.Round(time.Second).String()
+}
+
+// formatSince, format duration since time, rounded to second.
+func formatSince(t time.Time) string {
+
+	return formatDuration(time.Since(t))
+}
+
+// shortID, container short id is the first 12 characters.
+func shortID(id string) string {
+
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+// stateColor, color of engine or container state.
+func stateColor(state string) string {
+
+	switch strings.ToLower(state) {
+	case "healthy", "running":
+		return "green"
+	case "pending", "created", "restarting", "paused":
+		return "orange"
+	}
+	return "red"
+}
+
+// jsonValue, json encoding of value, used by json kind templates.
+func jsonValue(value interface{}) (string, error) {
+
+	buf, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
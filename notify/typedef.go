@@ -13,10 +13,13 @@ type EndPoint struct {
 	Port     int         `yaml:"port"`
 	User     string      `yaml:"user"`
 	Password string      `yaml:"password"`
+	Format   string      `yaml:"format"`
 }
 
 //Notifications is exported
+//Templates: templates dir, <EventName>.html, .txt or .json replace the embedded default template.
 type Notifications struct {
+	Templates string     `yaml:"templates,omitempty"`
 	EndPoints []EndPoint `yaml:"endpoints,omitempty"`
 }
 
@@ -0,0 +1,15 @@
+package repository
+
+import "errors"
+
+// define repository errors
+var (
+	//repository is disabled, host is not configured
+	ErrRepositoryDisabled = errors.New("repository is disabled")
+	//repository name or tag not found
+	ErrRepositoryNotFound = errors.New("repository image not found")
+	//repository registry authentication failure
+	ErrRepositoryUnauthorized = errors.New("repository registry unauthorized")
+	//repository registry delete is not enabled
+	ErrRepositoryDeleteUnsupported = errors.New("repository registry delete is not enabled")
+)
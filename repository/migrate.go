@@ -0,0 +1,267 @@
+package repository
+
+import "github.com/humpback/gounits/logger"
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// migrate tags listing page size
+const migrateTagsPageSize = 100
+
+/*
+MigrateImage is exported
+Name: source repository name.
+Tags: tags to migrate, empty is all tags of source repository.
+TargetName: destination repository name, empty is same as Name.
+*/
+type MigrateImage struct {
+	Name       string   `json:"Name" required:"true"`
+	Tags       []string `json:"Tags"`
+	TargetName string   `json:"TargetName"`
+}
+
+// MigrateImageResult is exported
+// Retries: retried times of the image tag, Error is the last attempt error.
+type MigrateImageResult struct {
+	Name         string `json:"Name"`
+	Tag          string `json:"Tag"`
+	TargetName   string `json:"TargetName"`
+	Digest       string `json:"Digest"`
+	BlobsCopied  int    `json:"BlobsCopied"`
+	BlobsSkipped int    `json:"BlobsSkipped"`
+	Retries      int    `json:"Retries"`
+	Error        string `json:"Error"`
+}
+
+// MigrateResult is exported
+type MigrateResult struct {
+	Source      string                `json:"Source"`
+	Destination string                `json:"Destination"`
+	Images      []*MigrateImageResult `json:"Images"`
+	Succeeded   int                   `json:"Succeeded"`
+	Failed      int                   `json:"Failed"`
+}
+
+// manifestDescriptor, registry manifest content descriptor.
+type manifestDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+}
+
+// registryManifest, image manifest or manifest list, schema1 manifest layers are fsLayers.
+type registryManifest struct {
+	Config    *manifestDescriptor  `json:"config"`
+	Layers    []manifestDescriptor `json:"layers"`
+	Manifests []manifestDescriptor `json:"manifests"`
+	FSLayers  []struct {
+		BlobSum string `json:"blobSum"`
+	} `json:"fsLayers"`
+}
+
+// imageMigrator, copy manifests and blobs of a repository from source to destination.
+type imageMigrator struct {
+	source      *Registry
+	destination *Registry
+	result      *MigrateImageResult
+}
+
+// copyBlob, skip blob already exists in destination.
+func (migrator *imageMigrator) copyBlob(digest string) error {
+
+	exists, err := migrator.destination.BlobExists(migrator.result.TargetName, digest)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		migrator.result.BlobsSkipped++
+		return nil
+	}
+
+	reader, size, err := migrator.source.GetBlob(migrator.result.Name, digest)
+	if err != nil {
+		return fmt.Errorf("pull blob %s, %s", digest, err.Error())
+	}
+
+	defer reader.Close()
+	if err := migrator.destination.PushBlob(migrator.result.TargetName, digest, reader, size); err != nil {
+		return fmt.Errorf("push blob %s, %s", digest, err.Error())
+	}
+	migrator.result.BlobsCopied++
+	return nil
+}
+
+// copyManifest, manifests of a manifest list are copied by digest before the list.
+// Return copied manifest digest.
+func (migrator *imageMigrator) copyManifest(reference string) (string, error) {
+
+	mediaType, data, digest, err := migrator.source.GetManifest(migrator.result.Name, reference)
+	if err != nil {
+		return "", fmt.Errorf("pull manifest %s, %s", reference, err.Error())
+	}
+
+	manifest := registryManifest{}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return "", fmt.Errorf("manifest %s invalid, %s", reference, err.Error())
+	}
+
+	for _, descriptor := range manifest.Manifests {
+		if _, err := migrator.copyManifest(descriptor.Digest); err != nil {
+			return "", err
+		}
+	}
+
+	blobs := []string{}
+	if manifest.Config != nil && manifest.Config.Digest != "" {
+		blobs = append(blobs, manifest.Config.Digest)
+	}
+	for _, layer := range manifest.Layers {
+		blobs = append(blobs, layer.Digest)
+	}
+	for _, layer := range manifest.FSLayers {
+		blobs = append(blobs, layer.BlobSum)
+	}
+
+	for _, blob := range blobs {
+		if err := migrator.copyBlob(blob); err != nil {
+			return "", err
+		}
+	}
+
+	if err := migrator.destination.PutManifest(migrator.result.TargetName, reference, mediaType, data); err != nil {
+		return "", fmt.Errorf("push manifest %s, %s", reference, err.Error())
+	}
+	return digest, nil
+}
+
+// migrateTags, resolve tags of migrate image, empty tags are all tags of source repository.
+func migrateTags(source *Registry, image MigrateImage) ([]string, error) {
+
+	if len(image.Tags) > 0 {
+		return image.Tags, nil
+	}
+
+	tags, last := []string{}, ""
+	for {
+		pageTags, next, err := source.Tags(image.Name, migrateTagsPageSize, last)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, pageTags...)
+		if next == "" || len(pageTags) == 0 {
+			break
+		}
+		last = next
+	}
+	return tags, nil
+}
+
+/*
+Migrate is exported
+copy images tags from source registry to destination registry, up to MaxRoutine tags are copied in parallel.
+a failure tag is retried up to Recovery times, failure tags are reported in result, not stop other tags.
+progress is called with completed and total tags, canceled is checked before every tag.
+*/
+func (cache *RepositoryCache) Migrate(source *Registry, destination *Registry, images []MigrateImage, progress func(int, int), canceled func() bool) (*MigrateResult, error) {
+
+	result := &MigrateResult{
+		Source:      source.Name(),
+		Destination: destination.Name(),
+		Images:      []*MigrateImageResult{},
+	}
+
+	for _, image := range images {
+		targetName := image.TargetName
+		if targetName == "" {
+			targetName = image.Name
+		}
+
+		tags, err := migrateTags(source, image)
+		if err != nil {
+			result.Images = append(result.Images, &MigrateImageResult{Name: image.Name, TargetName: targetName, Error: err.Error()})
+			continue
+		}
+
+		for _, tag := range tags {
+			result.Images = append(result.Images, &MigrateImageResult{Name: image.Name, Tag: tag, TargetName: targetName})
+		}
+	}
+
+	maxRoutine := cache.MaxRoutine
+	if maxRoutine <= 0 {
+		maxRoutine = 1
+	}
+
+	mutex := sync.Mutex{}
+	waitGroup := sync.WaitGroup{}
+	completed, total := 0, len(result.Images)
+	progress(completed, total)
+	imagesCh := make(chan *MigrateImageResult)
+	for i := 0; i < maxRoutine; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for imageResult := range imagesCh {
+				if imageResult.Error == "" {
+					cache.migrateImage(source, destination, imageResult, canceled)
+				}
+				mutex.Lock()
+				completed++
+				progress(completed, total)
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	for _, imageResult := range result.Images {
+		if canceled() && imageResult.Error == "" {
+			imageResult.Error = "migrate canceled"
+		}
+		imagesCh <- imageResult
+	}
+	close(imagesCh)
+	waitGroup.Wait()
+
+	for _, imageResult := range result.Images {
+		if imageResult.Error == "" {
+			result.Succeeded++
+		} else {
+			result.Failed++
+		}
+	}
+
+	logger.INFO("[#repository#] migrate images %s to %s, succeeded %d, failed %d.", result.Source, result.Destination, result.Succeeded, result.Failed)
+	if result.Failed > 0 {
+		return result, fmt.Errorf("migrate images failure, %d of %d failed", result.Failed, total)
+	}
+	return result, nil
+}
+
+// migrateImage, copy an image tag, retry up to Recovery times.
+func (cache *RepositoryCache) migrateImage(source *Registry, destination *Registry, imageResult *MigrateImageResult, canceled func() bool) {
+
+	migrator := &imageMigrator{
+		source:      source,
+		destination: destination,
+		result:      imageResult,
+	}
+
+	for retries := 0; ; retries++ {
+		imageResult.Retries = retries
+		digest, err := migrator.copyManifest(imageResult.Tag)
+		if err == nil {
+			imageResult.Digest = digest
+			imageResult.Error = ""
+			return
+		}
+
+		imageResult.Error = err.Error()
+		logger.WARN("[#repository#] migrate image %s:%s to %s, attempt %d error, %s", imageResult.Name, imageResult.Tag, imageResult.TargetName, retries+1, err.Error())
+		if retries >= cache.Recovery || canceled() {
+			return
+		}
+	}
+}
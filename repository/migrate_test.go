@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newMigrateTestRegistries(t *testing.T, config Config) (*testRegistry, *Registry, *testRegistry, *Registry) {
+
+	source, destination := newTestRegistry(), newTestRegistry()
+	sourceServer, destinationServer := httptest.NewServer(source), httptest.NewServer(destination)
+	t.Cleanup(sourceServer.Close)
+	t.Cleanup(destinationServer.Close)
+
+	config.Host = sourceServer.URL
+	sourceRegistry, err := NewRegistry(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config.Host = destinationServer.URL
+	destinationRegistry, err := NewRegistry(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return source, sourceRegistry, destination, destinationRegistry
+}
+
+func TestMigrateImages(t *testing.T) {
+
+	source, sourceRegistry, destination, destinationRegistry := newMigrateTestRegistries(t, Config{})
+	digest := source.putImage("app/web", "1.0", "layer1")
+	source.putImage("app/web", "1.1", "layer2")
+	destination.putImage("base", "1.0", "layer1")
+
+	cache, _ := NewRepositoryCache(Config{MaxRoutine: 2})
+	completed := 0
+	result, err := cache.Migrate(sourceRegistry, destinationRegistry, []MigrateImage{{Name: "app/web", TargetName: "mirror/web"}},
+		func(c int, total int) { completed = c }, func() bool { return false })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Succeeded != 2 || result.Failed != 0 || completed != 2 {
+		t.Fatalf("migrate succeeded %d failed %d completed %d, want 2 0 2", result.Succeeded, result.Failed, completed)
+	}
+
+	copied, skipped := 0, 0
+	for _, imageResult := range result.Images {
+		copied += imageResult.BlobsCopied
+		skipped += imageResult.BlobsSkipped
+		if imageResult.Tag == "1.0" && imageResult.Digest != digest {
+			t.Fatalf("migrated 1.0 digest %s, want %s", imageResult.Digest, digest)
+		}
+	}
+
+	//config and layer1 blobs exist in destination, only layer2 is copied.
+	if copied != 1 || skipped != 3 {
+		t.Fatalf("blobs copied %d skipped %d, want 1 3", copied, skipped)
+	}
+
+	if tags, _, err := destinationRegistry.Tags("mirror/web", 0, ""); err != nil || len(tags) != 2 {
+		t.Fatalf("destination tags %v, %v", tags, err)
+	}
+}
+
+func TestMigrateImageRetry(t *testing.T) {
+
+	source, sourceRegistry, destination, destinationRegistry := newMigrateTestRegistries(t, Config{})
+	source.putImage("app/web", "1.0", "layer1")
+	destination.failPushes = 1
+
+	cache, _ := NewRepositoryCache(Config{Recovery: 1})
+	result, err := cache.Migrate(sourceRegistry, destinationRegistry, []MigrateImage{{Name: "app/web", Tags: []string{"1.0"}}},
+		func(int, int) {}, func() bool { return false })
+	if err != nil || result.Images[0].Retries != 1 || result.Images[0].Error != "" {
+		t.Fatalf("migrate retry result %+v, %v", result.Images[0], err)
+	}
+
+	destination.failPushes = 2
+	source.putImage("app/web", "1.1", "layer2")
+	result, err = cache.Migrate(sourceRegistry, destinationRegistry, []MigrateImage{{Name: "app/web", Tags: []string{"1.1"}}},
+		func(int, int) {}, func() bool { return false })
+	if err == nil || result.Failed != 1 || result.Images[0].Error == "" {
+		t.Fatalf("migrate failure after retries result %+v, %v", result.Images[0], err)
+	}
+}
+
+func TestMigrateBlobLongerThanTimeout(t *testing.T) {
+
+	source, sourceRegistry, _, destinationRegistry := newMigrateTestRegistries(t, Config{Timeout: "200ms"})
+	source.putImage("app/web", "1.0", "layer1")
+	source.blobDelay = 500 * time.Millisecond
+
+	cache, _ := NewRepositoryCache(Config{})
+	result, err := cache.Migrate(sourceRegistry, destinationRegistry, []MigrateImage{{Name: "app/web", Tags: []string{"1.0"}}},
+		func(int, int) {}, func() bool { return false })
+	if err != nil || result.Succeeded != 1 {
+		t.Fatalf("migrate slow blob result %+v, %v", result.Images[0], err)
+	}
+}
+
+func TestMigrateCanceled(t *testing.T) {
+
+	source, sourceRegistry, _, destinationRegistry := newMigrateTestRegistries(t, Config{})
+	source.putImage("app/web", "1.0", "layer1")
+
+	cache, _ := NewRepositoryCache(Config{})
+	result, err := cache.Migrate(sourceRegistry, destinationRegistry, []MigrateImage{{Name: "app/web"}},
+		func(int, int) {}, func() bool { return true })
+	if err == nil || result.Failed != 1 || result.Images[0].Error != "migrate canceled" {
+		t.Fatalf("migrate canceled result %+v, %v", result.Images[0], err)
+	}
+}
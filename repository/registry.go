@@ -0,0 +1,564 @@
+package repository
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// registry request default timeout, blob transfer is limited by dial and response header timeout only.
+	registryTimeout = 30 * time.Second
+	// registry token default expires, token response without expires_in
+	registryTokenExpires = 60 * time.Second
+	// registry basic authorization cache expires
+	registryBasicExpires = time.Hour
+)
+
+// manifestMediaTypes, accepted manifest media types, digest of a tag is the digest of its stored manifest.
+var manifestMediaTypes = []string{
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.v1+prettyjws",
+}
+
+// challengeParamsRegexp, parse WWW-Authenticate challenge params, eg: realm="https://auth/token",service="registry"
+var challengeParamsRegexp = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// registryAuthorization, a authorization header of registry scope, bearer token or basic.
+type registryAuthorization struct {
+	authorization string
+	expires       time.Time
+}
+
+// registryErrors, registry v2 api error response.
+type registryErrors struct {
+	Errors []struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// Registry is exported
+// docker registry v2 http api client, supports basic auth and token auth.
+type Registry struct {
+	sync.Mutex
+	config         Config
+	host           string
+	client         *http.Client
+	blobClient     *http.Client
+	authorizations map[string]*registryAuthorization
+}
+
+// NewRegistry is exported
+func NewRegistry(config Config) (*Registry, error) {
+
+	host := strings.TrimRight(strings.TrimSpace(config.Host), "/")
+	if !strings.HasPrefix(host, "http://") && !strings.HasPrefix(host, "https://") {
+		host = "https://" + host
+	}
+
+	if _, err := url.Parse(host); err != nil {
+		return nil, fmt.Errorf("host invalid, %s", err.Error())
+	}
+
+	timeout := registryTimeout
+	if config.Timeout != "" {
+		value, err := time.ParseDuration(config.Timeout)
+		if err != nil || value <= 0 {
+			return nil, fmt.Errorf("timeout %s invalid", config.Timeout)
+		}
+		timeout = value
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = timeout
+	transport.ResponseHeaderTimeout = timeout
+	if config.Insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	return &Registry{
+		config:         config,
+		host:           host,
+		client:         &http.Client{Timeout: timeout, Transport: transport},
+		blobClient:     &http.Client{Transport: transport},
+		authorizations: make(map[string]*registryAuthorization),
+	}, nil
+}
+
+// Host is exported
+// Return registry address with scheme.
+func (registry *Registry) Host() string {
+
+	return registry.host
+}
+
+// Name is exported
+// Return registry address without scheme, prefix of repository image name.
+func (registry *Registry) Name() string {
+
+	return strings.TrimPrefix(strings.TrimPrefix(registry.host, "https://"), "http://")
+}
+
+/*
+Catalog is exported
+list repositories, page size n and last repository of previous page.
+Return next is last of next page, empty if no more pages.
+*/
+func (registry *Registry) Catalog(n int, last string) ([]string, string, error) {
+
+	query := url.Values{}
+	if n > 0 {
+		query.Set("n", strconv.Itoa(n))
+	}
+	if last != "" {
+		query.Set("last", last)
+	}
+
+	resp, err := registry.request(http.MethodGet, "/v2/_catalog", query, "registry:catalog:*", nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	defer resp.Body.Close()
+	catalog := struct {
+		Repositories []string `json:"repositories"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&catalog); err != nil {
+		return nil, "", err
+	}
+
+	if catalog.Repositories == nil {
+		catalog.Repositories = []string{}
+	}
+	return catalog.Repositories, nextLast(resp), nil
+}
+
+// Tags is exported
+// list tags of repository, page size n and last tag of previous page.
+func (registry *Registry) Tags(name string, n int, last string) ([]string, string, error) {
+
+	query := url.Values{}
+	if n > 0 {
+		query.Set("n", strconv.Itoa(n))
+	}
+	if last != "" {
+		query.Set("last", last)
+	}
+
+	resp, err := registry.request(http.MethodGet, "/v2/"+name+"/tags/list", query, "repository:"+name+":pull", nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	defer resp.Body.Close()
+	tags := struct {
+		Name string   `json:"name"`
+		Tags []string `json:"tags"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return nil, "", err
+	}
+
+	if tags.Tags == nil {
+		tags.Tags = []string{}
+	}
+	return tags.Tags, nextLast(resp), nil
+}
+
+// ManifestDigest is exported
+// resolve manifest digest of repository tag.
+func (registry *Registry) ManifestDigest(name string, tag string) (string, error) {
+
+	header := http.Header{"Accept": []string{strings.Join(manifestMediaTypes, ", ")}}
+	scope := "repository:" + name + ":pull"
+	resp, err := registry.request(http.MethodHead, "/v2/"+name+"/manifests/"+tag, nil, scope, header)
+	if err != nil {
+		return "", err
+	}
+
+	resp.Body.Close()
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+
+	//registry head response without digest header, digest of manifest content.
+	resp, err = registry.request(http.MethodGet, "/v2/"+name+"/manifests/"+tag, nil, scope, header)
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, resp.Body); err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// DeleteManifest is exported
+// delete manifest of repository reference, reference is a tag or a digest, tag is resolved to digest.
+// Return deleted manifest digest.
+func (registry *Registry) DeleteManifest(name string, reference string) (string, error) {
+
+	digest := reference
+	if !strings.Contains(reference, ":") {
+		value, err := registry.ManifestDigest(name, reference)
+		if err != nil {
+			return "", err
+		}
+		digest = value
+	}
+
+	resp, err := registry.request(http.MethodDelete, "/v2/"+name+"/manifests/"+digest, nil, "repository:"+name+":pull,delete", nil)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return digest, nil
+}
+
+// GetManifest is exported
+// Return manifest media type, content and digest of repository reference.
+func (registry *Registry) GetManifest(name string, reference string) (string, []byte, string, error) {
+
+	header := http.Header{"Accept": []string{strings.Join(manifestMediaTypes, ", ")}}
+	resp, err := registry.request(http.MethodGet, "/v2/"+name+"/manifests/"+reference, nil, "repository:"+name+":pull", header)
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		hash := sha256.Sum256(data)
+		digest = "sha256:" + hex.EncodeToString(hash[:])
+	}
+	mediaType := strings.TrimSpace(strings.SplitN(resp.Header.Get("Content-Type"), ";", 2)[0])
+	return mediaType, data, digest, nil
+}
+
+// PutManifest is exported
+// push manifest content to repository reference.
+func (registry *Registry) PutManifest(name string, reference string, mediaType string, data []byte) error {
+
+	header := http.Header{"Content-Type": []string{mediaType}}
+	resp, err := registry.requestBody(registry.client, http.MethodPut, "/v2/"+name+"/manifests/"+reference, nil, "repository:"+name+":pull,push", header, bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// BlobExists is exported
+func (registry *Registry) BlobExists(name string, digest string) (bool, error) {
+
+	resp, err := registry.request(http.MethodHead, "/v2/"+name+"/blobs/"+digest, nil, "repository:"+name+":pull,push", nil)
+	if err == ErrRepositoryNotFound {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	return true, nil
+}
+
+// GetBlob is exported
+// Return blob content stream and size, stream must be closed.
+// blob stream has no whole request timeout, a large layer takes longer than registry timeout.
+func (registry *Registry) GetBlob(name string, digest string) (io.ReadCloser, int64, error) {
+
+	resp, err := registry.requestBody(registry.blobClient, http.MethodGet, "/v2/"+name+"/blobs/"+digest, nil, "repository:"+name+":pull", nil, nil, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp.Body, resp.ContentLength, nil
+}
+
+// PushBlob is exported
+// monolithic upload blob content, upload is started without body, so credentials are authorized before streaming.
+func (registry *Registry) PushBlob(name string, digest string, reader io.Reader, size int64) error {
+
+	scope := "repository:" + name + ":pull,push"
+	resp, err := registry.request(http.MethodPost, "/v2/"+name+"/blobs/uploads/", nil, scope, nil)
+	if err != nil {
+		return err
+	}
+
+	resp.Body.Close()
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return fmt.Errorf("registry blob upload location invalid, can not be empty")
+	}
+
+	if !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		location = registry.host + "/" + strings.TrimPrefix(location, "/")
+	}
+
+	header := http.Header{"Content-Type": []string{"application/octet-stream"}}
+	query := url.Values{"digest": []string{digest}}
+	resp, err = registry.requestBody(registry.blobClient, http.MethodPut, location, query, scope, header, reader, size)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// nextLast, parse last param of registry pagination Link header, eg: </v2/_catalog?last=b&n=2>; rel="next"
+func nextLast(resp *http.Response) string {
+
+	link := resp.Header.Get("Link")
+	if link == "" || !strings.Contains(link, `rel="next"`) {
+		return ""
+	}
+
+	start, end := strings.Index(link, "<"), strings.Index(link, ">")
+	if start < 0 || end <= start {
+		return ""
+	}
+
+	linkURL, err := url.Parse(link[start+1 : end])
+	if err != nil {
+		return ""
+	}
+	return linkURL.Query().Get("last")
+}
+
+// request, send registry request without body.
+func (registry *Registry) request(method string, path string, query url.Values, scope string, header http.Header) (*http.Response, error) {
+
+	return registry.requestBody(registry.client, method, path, query, scope, header, nil, 0)
+}
+
+// requestBody, send registry request with client, path is a registry path or an absolute upload location.
+// a unauthorized response is retried once with the challenge credentials, a streaming body is not retried.
+// Return a successed response, response body must be closed.
+func (registry *Registry) requestBody(client *http.Client, method string, path string, query url.Values, scope string, header http.Header, body io.Reader, size int64) (*http.Response, error) {
+
+	rawurl := path
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		rawurl = registry.host + path
+	}
+
+	if len(query) > 0 {
+		if strings.Contains(rawurl, "?") {
+			rawurl += "&" + query.Encode()
+		} else {
+			rawurl += "?" + query.Encode()
+		}
+	}
+
+	authorization := registry.cachedAuthorization(scope)
+	if authorization == "" && registry.config.Token != "" {
+		authorization = "Bearer " + registry.config.Token
+	}
+
+	resp, err := registry.do(client, method, rawurl, header, authorization, body, size)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		seeker, ret := body.(io.Seeker)
+		if body != nil && !ret {
+			return nil, ErrRepositoryUnauthorized
+		}
+		if authorization, err = registry.authorize(challenge, scope); err != nil {
+			return nil, err
+		}
+		if seeker != nil {
+			if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+				return nil, err
+			}
+		}
+		if resp, err = registry.do(client, method, rawurl, header, authorization, body, size); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, ErrRepositoryUnauthorized
+	case http.StatusNotFound:
+		return nil, ErrRepositoryNotFound
+	case http.StatusMethodNotAllowed:
+		if method == http.MethodDelete {
+			return nil, ErrRepositoryDeleteUnsupported
+		}
+	}
+	return nil, parseRegistryError(resp)
+}
+
+func (registry *Registry) do(client *http.Client, method string, rawurl string, header http.Header, authorization string, body io.Reader, size int64) (*http.Response, error) {
+
+	req, err := http.NewRequest(method, rawurl, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		req.ContentLength = size
+	}
+
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return client.Do(req)
+}
+
+// authorize, returns authorization of registry challenge, basic or bearer token.
+func (registry *Registry) authorize(challenge string, scope string) (string, error) {
+
+	scheme := strings.ToLower(strings.SplitN(strings.TrimSpace(challenge), " ", 2)[0])
+	if scheme == "basic" {
+		if registry.config.User == "" {
+			return "", ErrRepositoryUnauthorized
+		}
+		req := &http.Request{Header: http.Header{}}
+		req.SetBasicAuth(registry.config.User, registry.config.Password)
+		registry.setAuthorization(scope, req.Header.Get("Authorization"), registryBasicExpires)
+		return req.Header.Get("Authorization"), nil
+	}
+
+	if scheme != "bearer" {
+		return "", ErrRepositoryUnauthorized
+	}
+
+	params := map[string]string{}
+	for _, match := range challengeParamsRegexp.FindAllStringSubmatch(challenge, -1) {
+		params[strings.ToLower(match[1])] = match[2]
+	}
+
+	if params["realm"] == "" {
+		return "", ErrRepositoryUnauthorized
+	}
+
+	query := url.Values{}
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+	if params["scope"] != "" {
+		query.Set("scope", params["scope"])
+	} else if scope != "" {
+		query.Set("scope", scope)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, params["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	if registry.config.User != "" {
+		req.SetBasicAuth(registry.config.User, registry.config.Password)
+	}
+
+	resp, err := registry.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", ErrRepositoryUnauthorized
+	}
+
+	token := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+
+	if token.Token == "" {
+		return "", ErrRepositoryUnauthorized
+	}
+
+	expires := registryTokenExpires
+	if token.ExpiresIn > 0 {
+		expires = time.Duration(token.ExpiresIn) * time.Second
+	}
+
+	registry.setAuthorization(scope, "Bearer "+token.Token, expires)
+	return "Bearer " + token.Token, nil
+}
+
+func (registry *Registry) setAuthorization(scope string, authorization string, expires time.Duration) {
+
+	registry.Lock()
+	registry.authorizations[scope] = &registryAuthorization{authorization: authorization, expires: time.Now().Add(expires)}
+	registry.Unlock()
+}
+
+func (registry *Registry) cachedAuthorization(scope string) string {
+
+	registry.Lock()
+	defer registry.Unlock()
+	if value, ret := registry.authorizations[scope]; ret {
+		if time.Now().Before(value.expires) {
+			return value.authorization
+		}
+		delete(registry.authorizations, scope)
+	}
+	return ""
+}
+
+// parseRegistryError, returns registry error response message.
+func parseRegistryError(resp *http.Response) error {
+
+	data, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	registryErrs := registryErrors{}
+	if err := json.Unmarshal(data, &registryErrs); err == nil && len(registryErrs.Errors) > 0 {
+		messages := []string{}
+		for _, registryErr := range registryErrs.Errors {
+			messages = append(messages, registryErr.Code+": "+registryErr.Message)
+		}
+		return fmt.Errorf("registry response status %d, %s", resp.StatusCode, strings.Join(messages, "; "))
+	}
+	return fmt.Errorf("registry response status %d, %s", resp.StatusCode, strings.TrimSpace(string(data)))
+}
@@ -0,0 +1,338 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testManifestMediaType = "application/vnd.docker.distribution.manifest.v2+json"
+
+// testRegistry, in-memory docker registry v2 api, manifests key is name:reference.
+type testRegistry struct {
+	sync.Mutex
+	manifests  map[string][]byte
+	blobs      map[string][]byte
+	uploads    int
+	deleted    []string
+	blobDelay  time.Duration
+	failPushes int
+}
+
+func newTestRegistry() *testRegistry {
+
+	return &testRegistry{
+		manifests: make(map[string][]byte),
+		blobs:     make(map[string][]byte),
+	}
+}
+
+func testDigest(data []byte) string {
+
+	hash := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(hash[:])
+}
+
+// putImage, store an image of a config and a layer blob, return manifest digest.
+func (registry *testRegistry) putImage(name string, tag string, layer string) string {
+
+	config := []byte(`{"architecture":"amd64"}`)
+	registry.Lock()
+	defer registry.Unlock()
+	registry.blobs[testDigest(config)] = config
+	registry.blobs[testDigest([]byte(layer))] = []byte(layer)
+	manifest := []byte(fmt.Sprintf(`{"schemaVersion":2,"mediaType":"%s","config":{"digest":"%s"},"layers":[{"digest":"%s"}]}`,
+		testManifestMediaType, testDigest(config), testDigest([]byte(layer))))
+	registry.manifests[name+":"+tag] = manifest
+	registry.manifests[name+":"+testDigest(manifest)] = manifest
+	return testDigest(manifest)
+}
+
+// page, values after last, up to n values, Link header is set if more values.
+func page(w http.ResponseWriter, r *http.Request, values []string) []string {
+
+	sort.Strings(values)
+	last := r.URL.Query().Get("last")
+	start := sort.SearchStrings(values, last)
+	if last != "" && start < len(values) && values[start] == last {
+		start++
+	}
+
+	values = values[start:]
+	if n, err := strconv.Atoi(r.URL.Query().Get("n")); err == nil && n < len(values) {
+		values = values[:n]
+		w.Header().Set("Link", fmt.Sprintf(`<%s?last=%s&n=%d>; rel="next"`, r.URL.Path, values[n-1], n))
+	}
+	return values
+}
+
+func (registry *testRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	registry.Lock()
+	defer registry.Unlock()
+	path := strings.TrimPrefix(r.URL.Path, "/v2/")
+	switch {
+	case path == "_catalog":
+		names := map[string]bool{}
+		for key := range registry.manifests {
+			names[key[:strings.Index(key, ":")]] = true
+		}
+		repositories := []string{}
+		for name := range names {
+			repositories = append(repositories, name)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"repositories": page(w, r, repositories)})
+	case strings.HasSuffix(path, "/tags/list"):
+		name := strings.TrimSuffix(path, "/tags/list")
+		tags := []string{}
+		for key := range registry.manifests {
+			if strings.HasPrefix(key, name+":") && !strings.HasPrefix(key, name+":sha256:") {
+				tags = append(tags, strings.TrimPrefix(key, name+":"))
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"name": name, "tags": page(w, r, tags)})
+	case strings.Contains(path, "/manifests/"):
+		parts := strings.SplitN(path, "/manifests/", 2)
+		registry.serveManifest(w, r, parts[0], parts[1])
+	case strings.Contains(path, "/blobs/uploads/"):
+		registry.serveUpload(w, r, strings.SplitN(path, "/blobs/uploads/", 2)[0])
+	case strings.Contains(path, "/blobs/"):
+		registry.serveBlob(w, r, strings.SplitN(path, "/blobs/", 2)[1])
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (registry *testRegistry) serveManifest(w http.ResponseWriter, r *http.Request, name string, reference string) {
+
+	if r.Method == http.MethodPut {
+		data, _ := ioutil.ReadAll(r.Body)
+		registry.manifests[name+":"+reference] = data
+		registry.manifests[name+":"+testDigest(data)] = data
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+
+	data, ret := registry.manifests[name+":"+reference]
+	if !ret {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		if !strings.HasPrefix(reference, "sha256:") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		registry.deleted = append(registry.deleted, name+"@"+reference)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	w.Header().Set("Content-Type", testManifestMediaType)
+	w.Header().Set("Docker-Content-Digest", testDigest(data))
+	if r.Method == http.MethodGet {
+		w.Write(data)
+	}
+}
+
+func (registry *testRegistry) serveBlob(w http.ResponseWriter, r *http.Request, digest string) {
+
+	data, ret := registry.blobs[digest]
+	if !ret {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	if r.Method != http.MethodGet {
+		return
+	}
+
+	//stream blob slowly, headers are sent before the delay.
+	w.WriteHeader(http.StatusOK)
+	w.(http.Flusher).Flush()
+	delay := registry.blobDelay
+	registry.Unlock()
+	time.Sleep(delay)
+	registry.Lock()
+	w.Write(data)
+}
+
+func (registry *testRegistry) serveUpload(w http.ResponseWriter, r *http.Request, name string) {
+
+	if r.Method == http.MethodPost {
+		registry.uploads++
+		w.Header().Set("Location", fmt.Sprintf("/v2/%s/blobs/uploads/%d", name, registry.uploads))
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	data, _ := ioutil.ReadAll(r.Body)
+	if registry.failPushes > 0 {
+		registry.failPushes--
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `{"errors":[{"code":"UNKNOWN","message":"push failure"}]}`)
+		return
+	}
+
+	digest := r.URL.Query().Get("digest")
+	if digest != testDigest(data) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"errors":[{"code":"DIGEST_INVALID","message":"digest mismatch"}]}`)
+		return
+	}
+	registry.blobs[digest] = data
+	w.WriteHeader(http.StatusCreated)
+}
+
+func newTestServer(t *testing.T, handler http.Handler) (*httptest.Server, *Registry) {
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	registry, err := NewRegistry(Config{Host: server.URL, User: "admin", Password: "123456"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server, registry
+}
+
+func TestRegistryCatalogPaging(t *testing.T) {
+
+	testRegistry := newTestRegistry()
+	for _, name := range []string{"app/a", "app/b", "app/c", "web"} {
+		testRegistry.putImage(name, "1.0", name)
+	}
+	_, registry := newTestServer(t, testRegistry)
+
+	repositories, next, err := registry.Catalog(2, "")
+	if err != nil || strings.Join(repositories, ",") != "app/a,app/b" || next != "app/b" {
+		t.Fatalf("catalog first page %v, next %s, %v", repositories, next, err)
+	}
+
+	repositories, next, err = registry.Catalog(2, next)
+	if err != nil || strings.Join(repositories, ",") != "app/c,web" || next != "" {
+		t.Fatalf("catalog last page %v, next %s, %v", repositories, next, err)
+	}
+}
+
+func TestRegistryTagsPaging(t *testing.T) {
+
+	testRegistry := newTestRegistry()
+	for _, tag := range []string{"1.0", "1.1", "2.0"} {
+		testRegistry.putImage("app/web", tag, tag)
+	}
+	_, registry := newTestServer(t, testRegistry)
+
+	tags, next, err := registry.Tags("app/web", 2, "")
+	if err != nil || strings.Join(tags, ",") != "1.0,1.1" || next != "1.1" {
+		t.Fatalf("tags first page %v, next %s, %v", tags, next, err)
+	}
+
+	tags, next, err = registry.Tags("app/web", 2, next)
+	if err != nil || strings.Join(tags, ",") != "2.0" || next != "" {
+		t.Fatalf("tags last page %v, next %s, %v", tags, next, err)
+	}
+
+	if tags, _, err := registry.Tags("app/none", 2, ""); err != nil || len(tags) != 0 {
+		t.Fatalf("tags of repository without tags %v, %v", tags, err)
+	}
+}
+
+func TestRegistryBearerAuth(t *testing.T) {
+
+	testRegistry := newTestRegistry()
+	testRegistry.putImage("app/web", "1.0", "layer")
+	tokenRequests := 0
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+		if user, password, ret := r.BasicAuth(); !ret || user != "admin" || password != "123456" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("scope") != "repository:app/web:pull" || r.URL.Query().Get("service") != "test" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"token":"token1","expires_in":300}`)
+	})
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token1" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:app/web:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		testRegistry.ServeHTTP(w, r)
+	})
+
+	registry, err := NewRegistry(Config{Host: server.URL, User: "admin", Password: "123456"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if tags, _, err := registry.Tags("app/web", 0, ""); err != nil || strings.Join(tags, ",") != "1.0" {
+			t.Fatalf("tags with bearer auth %v, %v", tags, err)
+		}
+	}
+
+	if tokenRequests != 1 {
+		t.Fatalf("token requests %d, want cached token", tokenRequests)
+	}
+
+	registry, _ = NewRegistry(Config{Host: server.URL, User: "admin", Password: "invalid"})
+	if _, _, err := registry.Tags("app/web", 0, ""); err != ErrRepositoryUnauthorized {
+		t.Fatalf("tags with invalid credentials error %v, want ErrRepositoryUnauthorized", err)
+	}
+}
+
+func TestRegistryBasicAuth(t *testing.T) {
+
+	testRegistry := newTestRegistry()
+	testRegistry.putImage("app/web", "1.0", "layer")
+	_, registry := newTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ret := r.BasicAuth(); !ret || user != "admin" || password != "123456" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		testRegistry.ServeHTTP(w, r)
+	}))
+
+	if repositories, _, err := registry.Catalog(0, ""); err != nil || strings.Join(repositories, ",") != "app/web" {
+		t.Fatalf("catalog with basic auth %v, %v", repositories, err)
+	}
+}
+
+func TestRegistryDeleteManifestTag(t *testing.T) {
+
+	testRegistry := newTestRegistry()
+	digest := testRegistry.putImage("app/web", "1.0", "layer")
+	_, registry := newTestServer(t, testRegistry)
+
+	deleted, err := registry.DeleteManifest("app/web", "1.0")
+	if err != nil || deleted != digest {
+		t.Fatalf("delete tag 1.0 digest %s, %v, want %s", deleted, err, digest)
+	}
+
+	if len(testRegistry.deleted) != 1 || testRegistry.deleted[0] != "app/web@"+digest {
+		t.Fatalf("registry deleted %v, want tag resolved to digest", testRegistry.deleted)
+	}
+
+	if _, err := registry.DeleteManifest("app/web", "2.0"); err != ErrRepositoryNotFound {
+		t.Fatalf("delete not exists tag error %v, want ErrRepositoryNotFound", err)
+	}
+}
@@ -1,17 +1,205 @@
 package repository
 
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+const (
+	// docker hub image reference domain and registry host
+	dockerHubDomain = "docker.io"
+	dockerHubHost   = "registry-1.docker.io"
+)
+
+// upgrade image check modes
+const (
+	// CheckImageRegistry is exported, only images of the configured registry are checked, default mode.
+	CheckImageRegistry = "registry"
+	// CheckImageAll is exported, images of other registries are also checked anonymously, eg: docker hub.
+	CheckImageAll = "all"
+	// CheckImageNone is exported, images are not checked.
+	CheckImageNone = "none"
+)
+
+/*
+Config is exported
+docker registry v2 repository options, repository is disabled if host is empty.
+Host: registry address, eg: https://registry.example.com:5000, no scheme is https.
+User & Password: basic auth, also used to request token of token auth registry.
+Token: static bearer token, used before the registry token challenge.
+Insecure: skip registry tls certificate verify.
+Timeout: registry request timeout, default 30s, blob transfer is limited by connect and response header timeout only.
+MaxRoutine: images migrate parallel workers, default 1.
+Recovery: images migrate failure retries.
+CheckImage: upgrade image check mode, registry, all or none, default registry.
+*/
+type Config struct {
+	Host       string `yaml:"host"`
+	User       string `yaml:"user"`
+	Password   string `yaml:"password"`
+	Token      string `yaml:"token"`
+	Insecure   bool   `yaml:"insecure"`
+	Timeout    string `yaml:"timeout"`
+	MaxRoutine int    `yaml:"maxroutine"`
+	Recovery   int    `yaml:"recovery"`
+	CheckImage string `yaml:"checkimage"`
+}
+
+// RepositoryCache is exported
 type RepositoryCache struct {
 	Name             string
 	Host             string
-	Root             string
 	DirFilter        []string
-	AuthRoot         string
 	MaxRoutine       int
 	Recovery         int
+	CheckImageMode   string
 	EngineAPIVersion string
 	DockerAPIVersion string
+	registry         *Registry
+	mutex            sync.Mutex
+	registries       map[string]*Registry
+}
+
+// NewRepositoryCache is exported
+func NewRepositoryCache(config Config) (*RepositoryCache, error) {
+
+	repositoryCache := &RepositoryCache{
+		MaxRoutine: config.MaxRoutine,
+		Recovery:   config.Recovery,
+		registries: make(map[string]*Registry),
+	}
+
+	switch checkImage := strings.ToLower(strings.TrimSpace(config.CheckImage)); checkImage {
+	case "":
+		repositoryCache.CheckImageMode = CheckImageRegistry
+	case CheckImageRegistry, CheckImageAll, CheckImageNone:
+		repositoryCache.CheckImageMode = checkImage
+	default:
+		return nil, fmt.Errorf("repository checkimage %s invalid, registry, all or none", config.CheckImage)
+	}
+
+	if repositoryCache.MaxRoutine <= 0 {
+		repositoryCache.MaxRoutine = 1
+	}
+
+	if repositoryCache.Recovery < 0 {
+		repositoryCache.Recovery = 0
+	}
+
+	if strings.TrimSpace(config.Host) == "" {
+		return repositoryCache, nil
+	}
+
+	registry, err := NewRegistry(config)
+	if err != nil {
+		return nil, fmt.Errorf("repository init error, %s", err.Error())
+	}
+
+	repositoryCache.Name = registry.Name()
+	repositoryCache.Host = registry.Host()
+	repositoryCache.registry = registry
+	return repositoryCache, nil
 }
 
-func NewRepositoryCache() *RepositoryCache {
-	return &RepositoryCache{}
+// Registry is exported
+// Return repository registry, repository is disabled return ErrRepositoryDisabled.
+func (cache *RepositoryCache) Registry() (*Registry, error) {
+
+	if cache.registry == nil {
+		return nil, ErrRepositoryDisabled
+	}
+	return cache.registry, nil
+}
+
+// parseImageReference, returns registry domain, repository name and tag or digest of image reference.
+// eg: registry.example.com:5000/app/web:1.0, nginx:latest is docker.io library/nginx.
+func parseImageReference(image string) (string, string, string) {
+
+	domain, remainder := dockerHubDomain, image
+	if nPos := strings.Index(image, "/"); nPos >= 0 {
+		first := image[:nPos]
+		if strings.ContainsAny(first, ".:") || first == "localhost" {
+			domain, remainder = first, image[nPos+1:]
+		}
+	}
+
+	name, reference := remainder, "latest"
+	if nPos := strings.Index(remainder, "@"); nPos >= 0 {
+		name, reference = remainder[:nPos], remainder[nPos+1:]
+	} else if nPos := strings.LastIndex(remainder, ":"); nPos >= 0 {
+		name, reference = remainder[:nPos], remainder[nPos+1:]
+	}
+
+	if domain == dockerHubDomain && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	return domain, name, reference
+}
+
+// imageRegistry, the configured registry of domain, else an anonymous registry.
+func (cache *RepositoryCache) imageRegistry(domain string) (*Registry, error) {
+
+	if cache.registry != nil && cache.registry.Name() == domain {
+		return cache.registry, nil
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if registry, ret := cache.registries[domain]; ret {
+		return registry, nil
+	}
+
+	host := domain
+	if domain == dockerHubDomain {
+		host = dockerHubHost
+	}
+
+	registry, err := NewRegistry(Config{Host: host})
+	if err != nil {
+		return nil, err
+	}
+	cache.registries[domain] = registry
+	return registry, nil
+}
+
+// CheckImageEnabled is exported
+// Return true if upgrade images are checked, registry mode is enabled only when the registry is configured.
+func (cache *RepositoryCache) CheckImageEnabled() bool {
+
+	switch cache.CheckImageMode {
+	case CheckImageAll:
+		return true
+	case CheckImageRegistry:
+		return cache.registry != nil
+	}
+	return false
+}
+
+// CheckImage is exported
+// check image reference exists in its registry, the configured registry is used with credentials.
+// registry mode, images of other registries are not checked, return true.
+func (cache *RepositoryCache) CheckImage(image string) (bool, error) {
+
+	domain, name, reference := parseImageReference(image)
+	if cache.CheckImageMode == CheckImageNone {
+		return true, nil
+	}
+
+	if cache.CheckImageMode != CheckImageAll && (cache.registry == nil || cache.registry.Name() != domain) {
+		return true, nil
+	}
+
+	registry, err := cache.imageRegistry(domain)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := registry.ManifestDigest(name, reference); err != nil {
+		if err == ErrRepositoryNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
 }
@@ -3,34 +3,53 @@ package server
 import "github.com/humpback/gounits/fprocess"
 import "github.com/humpback/gounits/logger"
 import "humpback-center/api"
+import "humpback-center/api/middleware"
 import "humpback-center/ctrl"
 import "humpback-center/etc"
 
 import (
+	"context"
 	"flag"
+	"fmt"
+	"strings"
+	"time"
 )
 
 /*
 CenterService is exported
 humpback center service
+MigrateCache: service run in migrate cache mode, only migrate metas and exit.
 */
 type CenterService struct {
-	PIDFile    *fprocess.PIDFile
-	APIServer  *api.Server
-	Controller *ctrl.Controller
+	MigrateCache  bool
+	Configuration *etc.Configuration
+	PIDFile       *fprocess.PIDFile
+	APIServer     *api.Server
+	Controller    *ctrl.Controller
 }
 
 // NewCenterService exported
 func NewCenterService() (*CenterService, error) {
 
-	var conf string
+	var (
+		conf         string
+		migrateCache bool
+	)
 	flag.StringVar(&conf, "f", "etc/config.yaml", "humpback center configuration file.")
+	flag.BoolVar(&migrateCache, "migrate-cache", false, "rewrite all cluster metas to current schema version and exit.")
 	flag.Parse()
 	configuration, err := etc.NewConfiguration(conf)
 	if err != nil {
 		return nil, err
 	}
 
+	if migrateCache {
+		return &CenterService{
+			MigrateCache:  true,
+			Configuration: configuration,
+		}, nil
+	}
+
 	pidfile, err := fprocess.New(configuration.PIDFile)
 	if err != nil {
 		return nil, err
@@ -43,11 +62,39 @@ func NewCenterService() (*CenterService, error) {
 		return nil, err
 	}
 
-	apiserver := api.NewServer(configuration.API.Hosts, nil, controller, configuration.API.EnableCors)
+	authenticator, err := middleware.NewAuthenticator(configuration.API.Auth, "/v1/_ping")
+	if err != nil {
+		return nil, err
+	}
+
+	tlsOptions := api.TLSOptions{
+		CertFile:     configuration.API.TLS.CertFile,
+		KeyFile:      configuration.API.TLS.KeyFile,
+		ClientCAFile: configuration.API.TLS.ClientCAFile,
+		VerifyClient: configuration.API.TLS.VerifyClient,
+		MinVersion:   configuration.API.TLS.MinVersion,
+	}
+
+	tlsConfig, err := api.NewTLSConfig(tlsOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	leaderTransport, err := api.NewLeaderTransport(tlsOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	if leaderTransport != nil {
+		api.SetLeaderTransport(leaderTransport)
+	}
+
+	apiserver := api.NewServer(configuration.API.Hosts, tlsConfig, authenticator, controller, configuration.API.EnableCors)
 	return &CenterService{
-		PIDFile:    pidfile,
-		APIServer:  apiserver,
-		Controller: controller,
+		Configuration: configuration,
+		PIDFile:       pidfile,
+		APIServer:     apiserver,
+		Controller:    controller,
 	}, nil
 }
 
@@ -68,8 +115,60 @@ func (service *CenterService) Startup() error {
 	return nil
 }
 
+// MigrateMetaCache is exported
+// migrate all cluster metas to current schema version, print every meta changes.
+func (service *CenterService) MigrateMetaCache() error {
+
+	migrations, err := ctrl.MigrateMetaCache(service.Configuration)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, migration := range migrations {
+		if migration.Error != "" {
+			failed++
+			fmt.Printf("%s failed, %s\n", migration.MetaID, migration.Error)
+			continue
+		}
+		changes := "unchanged"
+		if len(migration.Changes) > 0 {
+			changes = strings.Join(migration.Changes, "; ")
+		}
+		fmt.Printf("%s version %d to %d, %s\n", migration.MetaID, migration.FromVersion, migration.ToVersion, changes)
+	}
+
+	fmt.Printf("migrate cache done, metas:%d failed:%d\n", len(migrations), failed)
+	if failed > 0 {
+		return fmt.Errorf("migrate cache %d metas failed", failed)
+	}
+	return nil
+}
+
+// Stop is exported
+// graceful shutdown, drain cluster operations, shutdown api listeners and uninit cluster.
+// configuration shutdown timeout is split, drain uses two thirds of timeout,
+// api shutdown uses the remaining timeout, at least one third, in-flight requests are replied.
 func (service *CenterService) Stop() error {
 
+	timeout := service.Configuration.GetShutdownTimeout()
+	deadline := time.Now().Add(timeout)
+	logger.INFO("[#service#] service stopping, timeout %s", timeout)
+	if err := service.Controller.Drain(timeout * 2 / 3); err != nil {
+		logger.WARN("[#service#] service drain error:%s", err.Error())
+	}
+
+	shutdownTimeout := time.Until(deadline)
+	if shutdownTimeout < timeout/3 {
+		shutdownTimeout = timeout / 3
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := service.APIServer.Shutdown(ctx); err != nil {
+		logger.WARN("[#service#] service API shutdown error:%s", err.Error())
+	}
+
 	service.Controller.UnInitialize()
 	service.PIDFile.Remove()
 	logger.INFO("[#service#] service closed.")